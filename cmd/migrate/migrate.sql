@@ -12,6 +12,8 @@ CREATE TABLE users (
     email VARCHAR(255) NOT NULL,
     hashed_password CHAR(60) NOT NULL,
     created DATETIME NOT NULL,
+    verified BOOLEAN NOT NULL DEFAULT FALSE,
+    pending_email VARCHAR(255) DEFAULT NULL,
     UNIQUE KEY users_uc_email (email)
 );
 
@@ -19,7 +21,10 @@ CREATE TABLE workspaces (
     id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
     title VARCHAR(100) NOT NULL UNIQUE,
     description TEXT NOT NULL,
-    created DATETIME NOT NULL
+    created DATETIME NOT NULL,
+    block_open_subtasks BOOLEAN NOT NULL DEFAULT FALSE,
+    deleted_at DATETIME DEFAULT NULL,
+    archived BOOLEAN NOT NULL DEFAULT FALSE
 );
 
 CREATE TABLE users_workspaces (
@@ -45,7 +50,188 @@ CREATE TABLE tasks (
     workspace_id INTEGER NOT NULL,
     user_id INTEGER NOT NULL,
     status VARCHAR(50) NOT NULL DEFAULT 'To Do',
+    due_date DATE DEFAULT NULL,
+    due_time TIME DEFAULT NULL,
+    parent_id INTEGER DEFAULT NULL,
+    deleted_at DATETIME DEFAULT NULL,
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
     FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-    INDEX idx_tasks_created (created)
+    FOREIGN KEY (parent_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    INDEX idx_tasks_created (created),
+    INDEX idx_tasks_due_date (due_date)
+);
+
+CREATE TABLE comments (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    parent_id INTEGER DEFAULT NULL,
+    content TEXT NOT NULL,
+    created DATETIME NOT NULL,
+    updated DATETIME DEFAULT NULL,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (parent_id) REFERENCES comments(id) ON DELETE CASCADE,
+    INDEX idx_comments_task_id (task_id)
+);
+
+CREATE TABLE task_events (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    field VARCHAR(50) NOT NULL,
+    old_value TEXT DEFAULT NULL,
+    new_value TEXT DEFAULT NULL,
+    created DATETIME NOT NULL,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_task_events_task_id (task_id)
+);
+
+CREATE TABLE api_tokens (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created DATETIME NOT NULL,
+    last_used DATETIME DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_api_tokens_user_id (user_id)
+);
+
+CREATE TABLE workspace_statuses (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    workspace_id INTEGER NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    position INTEGER NOT NULL,
+    is_done BOOLEAN NOT NULL DEFAULT FALSE,
+    created DATETIME NOT NULL,
+    UNIQUE (workspace_id, name),
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE
+);
+
+CREATE TABLE status_transitions (
+    from_status_id INTEGER NOT NULL,
+    to_status_id INTEGER NOT NULL,
+    PRIMARY KEY (from_status_id, to_status_id),
+    FOREIGN KEY (from_status_id) REFERENCES workspace_statuses(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_status_id) REFERENCES workspace_statuses(id) ON DELETE CASCADE
+);
+
+CREATE TABLE labels (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    workspace_id INTEGER NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(20) NOT NULL,
+    created DATETIME NOT NULL,
+    UNIQUE (workspace_id, name),
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE
+);
+
+CREATE TABLE task_labels (
+    task_id INTEGER NOT NULL,
+    label_id INTEGER NOT NULL,
+    PRIMARY KEY (task_id, label_id),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (label_id) REFERENCES labels(id) ON DELETE CASCADE,
+    INDEX idx_task_labels_label_id (label_id)
+);
+
+CREATE TABLE checklist_items (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    task_id INTEGER NOT NULL,
+    content VARCHAR(255) NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    created DATETIME NOT NULL,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE task_dependencies (
+    task_id INTEGER NOT NULL,
+    blocked_by_id INTEGER NOT NULL,
+    created DATETIME NOT NULL,
+    PRIMARY KEY (task_id, blocked_by_id),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (blocked_by_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE invitations (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    workspace_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    invited_by INTEGER NOT NULL,
+    created DATETIME NOT NULL,
+    expires DATETIME NOT NULL,
+    UNIQUE (workspace_id, user_id),
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE invite_links (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    workspace_id INTEGER NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    role VARCHAR(20) NOT NULL,
+    created_by INTEGER NOT NULL,
+    created DATETIME NOT NULL,
+    expires DATETIME NOT NULL,
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE user_limits (
+    user_id INTEGER NOT NULL PRIMARY KEY,
+    max_owned_workspaces INTEGER DEFAULT NULL,
+    max_member_workspaces INTEGER DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE task_users (
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    relation VARCHAR(20) NOT NULL,
+    PRIMARY KEY (task_id, user_id, relation),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_task_users_user_id (user_id)
+);
+
+CREATE TABLE user_tokens (
+    token_hash CHAR(64) NOT NULL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    scope VARCHAR(20) NOT NULL,
+    created DATETIME NOT NULL,
+    expires DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user_tokens_user_id (user_id)
+);
+
+CREATE TABLE two_factor (
+    user_id INTEGER NOT NULL PRIMARY KEY,
+    secret VARCHAR(64) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    last_step BIGINT NOT NULL DEFAULT 0,
+    created DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE recovery_codes (
+    code_hash CHAR(64) NOT NULL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_recovery_codes_user_id (user_id)
+);
+
+CREATE TABLE auth_events (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    event VARCHAR(30) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL,
+    created DATETIME NOT NULL,
+    INDEX idx_auth_events_email (email, event),
+    INDEX idx_auth_events_ip (ip, event)
 );
@@ -0,0 +1,1109 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/andres085/task_manager/internal/models"
+	"github.com/andres085/task_manager/internal/validator"
+)
+
+func (app *application) apiWorkspaceAccess(w http.ResponseWriter, r *http.Request, action models.Action) (int, bool) {
+	workspaceId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || workspaceId < 1 {
+		app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		return 0, false
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	perm, err := app.workspacePermission(userId, workspaceId, action)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return 0, false
+	}
+
+	if !app.apiCheckPermission(w, r, perm) {
+		return 0, false
+	}
+
+	return workspaceId, true
+}
+
+func (app *application) apiTaskAccess(w http.ResponseWriter, r *http.Request, action models.Action) (models.Task, bool) {
+	taskId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || taskId < 1 {
+		app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		return models.Task{}, false
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	perm, err := app.taskPermission(userId, taskId, action)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return models.Task{}, false
+	}
+
+	if !app.apiCheckPermission(w, r, perm) {
+		return models.Task{}, false
+	}
+
+	task, err := app.tasks.Get(taskId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return models.Task{}, false
+	}
+
+	return task, true
+}
+
+func (app *application) apiCheckPermission(w http.ResponseWriter, r *http.Request, perm permission) bool {
+	if !perm.Member {
+		app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		return false
+	}
+
+	if !perm.Allowed {
+		if perm.Archived {
+			app.errorJSON(w, r, http.StatusForbidden, "the workspace is archived and read-only")
+		} else {
+			app.errorJSON(w, r, http.StatusForbidden, "you don't have permission to perform this action")
+		}
+		return false
+	}
+
+	return true
+}
+
+func (app *application) apiWorkspaceList(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	includeArchived := getBoolParam(r, "archived")
+
+	ownWorkspaces, err := app.workspaces.GetAll(userId, true, includeArchived)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	invitedWorkspaces, err := app.workspaces.GetAll(userId, false, includeArchived)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	quota, err := app.limits.GetQuota(userId)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	trashedWorkspaces, err := app.workspaces.GetTrash(userId)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"owned": ownWorkspaces, "invited": invitedWorkspaces, "trashed": trashedWorkspaces, "quota": quota})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+type apiWorkspaceInput struct {
+	Title             *string `json:"title"`
+	Description       *string `json:"description"`
+	BlockOpenSubtasks *bool   `json:"block_open_subtasks"`
+}
+
+func (input apiWorkspaceInput) apply(form *workspaceCreateForm) {
+	if input.Title != nil {
+		form.Title = *input.Title
+	}
+	if input.Description != nil {
+		form.Description = *input.Description
+	}
+	if input.BlockOpenSubtasks != nil {
+		form.BlockOpenSubtasks = *input.BlockOpenSubtasks
+	}
+}
+
+func (app *application) apiWorkspaceCreate(w http.ResponseWriter, r *http.Request) {
+	var input apiWorkspaceInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errorJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var form workspaceCreateForm
+	input.apply(&form)
+	form.validate()
+
+	if !form.Valid() {
+		app.failedValidationJSON(w, r, form.Validator)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	id, err := app.workspaces.Insert(form.Title, form.Description, userId)
+	if err != nil {
+		if errors.Is(err, models.ErrWorkspaceLimit) {
+			app.errorJSON(w, r, http.StatusConflict, "you have reached the maximum number of workspaces you can own")
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	workspace, err := app.workspaces.Get(id)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"workspace": workspace})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiWorkspaceGet(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionView)
+	if !ok {
+		return
+	}
+
+	workspace, err := app.workspaces.Get(workspaceId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"workspace": workspace})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiWorkspaceUpdate(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionManageWorkspace)
+	if !ok {
+		return
+	}
+
+	workspace, err := app.workspaces.Get(workspaceId)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	var input apiWorkspaceInput
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.errorJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	form := workspaceCreateForm{
+		Title:             workspace.Title,
+		Description:       workspace.Description,
+		BlockOpenSubtasks: workspace.BlockOpenSubtasks,
+	}
+	input.apply(&form)
+	form.validate()
+
+	if !form.Valid() {
+		app.failedValidationJSON(w, r, form.Validator)
+		return
+	}
+
+	err = app.workspaces.Update(workspaceId, form.Title, form.Description, form.BlockOpenSubtasks)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	workspace.Title = form.Title
+	workspace.Description = form.Description
+	workspace.BlockOpenSubtasks = form.BlockOpenSubtasks
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"workspace": workspace})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiWorkspaceDelete(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionDeleteWorkspace)
+	if !ok {
+		return
+	}
+
+	_, err := app.workspaces.Delete(workspaceId)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) apiWorkspaceRestore(w http.ResponseWriter, r *http.Request) {
+	workspaceId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || workspaceId < 1 {
+		app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	err = app.workspaces.Restore(workspaceId, userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		case errors.Is(err, models.ErrWorkspaceLimit):
+			app.errorJSON(w, r, http.StatusConflict, "you have reached the maximum number of workspaces you can own")
+		default:
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) apiWorkspaceArchive(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionArchiveWorkspace)
+	if !ok {
+		return
+	}
+
+	err := app.workspaces.SetArchived(workspaceId, true)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) apiWorkspaceUnarchive(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionArchiveWorkspace)
+	if !ok {
+		return
+	}
+
+	err := app.workspaces.SetArchived(workspaceId, false)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) apiWorkspaceArchiveTasks(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionEditTasks)
+	if !ok {
+		return
+	}
+
+	archived, err := app.tasks.ArchiveFinished(workspaceId)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"archived": archived})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiWorkspaceTrash(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionDeleteTasks)
+	if !ok {
+		return
+	}
+
+	tasks, err := app.tasks.GetTrash(workspaceId)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"tasks": tasks})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiTaskRestore(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionDeleteTasks)
+	if !ok {
+		return
+	}
+
+	taskId, err := strconv.Atoi(r.PathValue("taskId"))
+	if err != nil || taskId < 1 {
+		app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		return
+	}
+
+	err = app.tasks.Restore(taskId, workspaceId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		case errors.Is(err, models.ErrInvalidParent):
+			app.errorJSON(w, r, http.StatusConflict, "restore the parent task first")
+		default:
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) apiTaskPurge(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionDeleteTasks)
+	if !ok {
+		return
+	}
+
+	taskId, err := strconv.Atoi(r.PathValue("taskId"))
+	if err != nil || taskId < 1 {
+		app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		return
+	}
+
+	err = app.tasks.Purge(taskId, workspaceId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) apiWorkspaceUsers(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionView)
+	if !ok {
+		return
+	}
+
+	workspaceUsers, err := app.users.GetWorkspaceUsers(workspaceId)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"users": workspaceUsers})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+type apiAddUserInput struct {
+	Email               string `json:"email"`
+	Role                string `json:"role"`
+	validator.Validator `json:"-"`
+}
+
+func (app *application) apiWorkspaceAddUser(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionManageWorkspace)
+	if !ok {
+		return
+	}
+
+	var input apiAddUserInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errorJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	input.CheckField(validator.NotBlank(input.Email), "email", "This field cannot be blank")
+	input.CheckField(validator.Matches(input.Email, validator.EmailRX), "email", "This field must be a valid email address")
+
+	if input.Role == "" {
+		input.Role = models.RoleEditor
+	}
+	input.CheckField(models.ValidInviteRole(input.Role), "role", "This field must be ADMIN, EDITOR or VIEWER")
+
+	if !input.Valid() {
+		app.failedValidationJSON(w, r, input.Validator)
+		return
+	}
+
+	user, err := app.users.GetUserToInvite(input.Email, workspaceId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.errorJSON(w, r, http.StatusNotFound, "user not found, not verified yet or already added")
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	invitationId, err := app.invitations.Insert(workspaceId, user.ID, userId, input.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrDuplicateInvitation):
+			input.AddNonFieldError("This user already has a pending invitation")
+			app.failedValidationJSON(w, r, input.Validator)
+		case errors.Is(err, models.ErrWorkspaceLimit):
+			app.errorJSON(w, r, http.StatusConflict, "this user can't join any more workspaces")
+		default:
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"invitation_id": invitationId, "user": user})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiInvitationList(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	invitations, err := app.invitations.GetPending(userId)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"invitations": invitations})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiInvitationAccept(w http.ResponseWriter, r *http.Request) {
+	invitationId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || invitationId < 1 {
+		app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	workspaceId, err := app.invitations.Accept(invitationId, userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		case errors.Is(err, models.ErrWorkspaceLimit):
+			app.errorJSON(w, r, http.StatusConflict, "you have reached the maximum number of workspaces you can be a member of")
+		default:
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	workspace, err := app.workspaces.Get(workspaceId)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"workspace": workspace})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiInvitationDecline(w http.ResponseWriter, r *http.Request) {
+	invitationId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || invitationId < 1 {
+		app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	err = app.invitations.Decline(invitationId, userId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) apiWorkspaceRemoveUser(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionManageWorkspace)
+	if !ok {
+		return
+	}
+
+	userId, err := strconv.Atoi(r.PathValue("userId"))
+	if err != nil || userId < 1 {
+		app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		return
+	}
+
+	actorId := r.Context().Value(userIDContextKey).(int)
+
+	row, err := app.users.RemoveUserFromWorkspace(workspaceId, userId, actorId)
+	if err != nil || row < 1 {
+		app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type apiWorkspaceRoleInput struct {
+	Role                string `json:"role"`
+	validator.Validator `json:"-"`
+}
+
+func (app *application) apiWorkspaceUpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionManageWorkspace)
+	if !ok {
+		return
+	}
+
+	memberId, err := strconv.Atoi(r.PathValue("userId"))
+	if err != nil || memberId < 1 {
+		app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		return
+	}
+
+	var input apiWorkspaceRoleInput
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.errorJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	input.CheckField(models.ValidInviteRole(input.Role), "role", "This field must be ADMIN, EDITOR or VIEWER")
+
+	if !input.Valid() {
+		app.failedValidationJSON(w, r, input.Validator)
+		return
+	}
+
+	err = app.users.UpdateWorkspaceRole(workspaceId, memberId, input.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		case errors.Is(err, models.ErrOwnerRole):
+			app.errorJSON(w, r, http.StatusConflict, "the owner's role can only change by transferring ownership")
+		default:
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"user_id": memberId, "role": input.Role})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiWorkspaceTransferOwnership(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionTransferOwnership)
+	if !ok {
+		return
+	}
+
+	newOwnerId, err := strconv.Atoi(r.PathValue("userId"))
+	if err != nil || newOwnerId < 1 {
+		app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+	if newOwnerId == userId {
+		app.errorJSON(w, r, http.StatusBadRequest, "you already own this workspace")
+		return
+	}
+
+	err = app.users.TransferOwnership(workspaceId, userId, newOwnerId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		case errors.Is(err, models.ErrWorkspaceLimit):
+			app.errorJSON(w, r, http.StatusConflict, "this user can't own any more workspaces")
+		default:
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) apiWorkspaceLeave(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionParticipate)
+	if !ok {
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	reassigned, err := app.users.LeaveWorkspace(workspaceId, userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		case errors.Is(err, models.ErrOwnerRole):
+			app.errorJSON(w, r, http.StatusConflict, "transfer ownership to another member before leaving this workspace")
+		default:
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"reassigned_tasks": reassigned})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiWorkspaceStatuses(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionView)
+	if !ok {
+		return
+	}
+
+	statuses, err := app.statuses.GetAll(workspaceId)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"statuses": statuses})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiWorkspaceLabels(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionView)
+	if !ok {
+		return
+	}
+
+	labels, err := app.labels.GetAll(workspaceId)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	if labels == nil {
+		labels = []models.Label{}
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"labels": labels})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiTaskList(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionView)
+	if !ok {
+		return
+	}
+
+	queryParams := r.URL.Query()
+
+	filter := models.TaskFilter{
+		Title:           queryParams.Get("title"),
+		Priority:        queryParams.Get("priority"),
+		Status:          queryParams.Get("status"),
+		Due:             queryParams.Get("due"),
+		Labels:          getLabelFilter(r),
+		SortBy:          queryParams.Get("sort_by"),
+		Sort:            queryParams.Get("sort"),
+		IncludeArchived: getBoolParam(r, "archived"),
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	if getBoolParam(r, "assigned") {
+		filter.AssignedTo = userId
+	}
+
+	if getBoolParam(r, "watching") {
+		filter.WatchedBy = userId
+	}
+
+	limit, page, offset := getPaginationParams(r, 10)
+
+	tasks, err := app.tasks.GetAll(workspaceId, limit, offset, filter)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	totalTasks, err := app.tasks.GetTotalTasks(workspaceId, filter)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+
+	metadata := envelope{
+		"page":        page,
+		"limit":       limit,
+		"total":       totalTasks,
+		"total_pages": int(math.Ceil(float64(totalTasks) / float64(limit))),
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"tasks": tasks, "metadata": metadata})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiTaskAssigned(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(userIDContextKey).(int)
+	queryParams := r.URL.Query()
+
+	filter := models.TaskFilter{
+		Title:    queryParams.Get("title"),
+		Priority: queryParams.Get("priority"),
+		Status:   queryParams.Get("status"),
+	}
+
+	limit, page, offset := getPaginationParams(r, 10)
+
+	tasks, err := app.tasks.GetAssigned(userId, limit, offset, filter)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	totalTasks, err := app.tasks.GetTotalAssigned(userId, filter)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+
+	metadata := envelope{
+		"page":        page,
+		"limit":       limit,
+		"total":       totalTasks,
+		"total_pages": int(math.Ceil(float64(totalTasks) / float64(limit))),
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"tasks": tasks, "metadata": metadata})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+type apiTaskInput struct {
+	Title       *string `json:"title"`
+	Content     *string `json:"content"`
+	Priority    *string `json:"priority"`
+	Status      *string `json:"status"`
+	DueDate     *string `json:"due_date"`
+	DueTime     *string `json:"due_time"`
+	UserID      *int    `json:"user_id"`
+	AssigneeIDs *[]int  `json:"assignee_ids"`
+	LabelIDs    *[]int  `json:"label_ids"`
+	ParentID    *int    `json:"parent_id"`
+}
+
+func (input apiTaskInput) apply(form *taskCreateForm) {
+	if input.Title != nil {
+		form.Title = *input.Title
+	}
+	if input.Content != nil {
+		form.Content = *input.Content
+	}
+	if input.Priority != nil {
+		form.Priority = *input.Priority
+	}
+	if input.Status != nil {
+		form.Status = *input.Status
+	}
+	if input.DueDate != nil {
+		form.DueDate = *input.DueDate
+	}
+	if input.DueTime != nil {
+		form.DueTime = *input.DueTime
+	}
+	if input.UserID != nil {
+		form.AssigneeIDs = []int{*input.UserID}
+	}
+	if input.AssigneeIDs != nil {
+		form.AssigneeIDs = *input.AssigneeIDs
+	}
+	if input.LabelIDs != nil {
+		form.LabelIDs = *input.LabelIDs
+	}
+	if input.ParentID != nil {
+		form.ParentID = *input.ParentID
+	}
+}
+
+func apiValidateTask(form *taskCreateForm) {
+	form.validate()
+	form.CheckField(validator.PermittedValue(form.Priority, "LOW", "MEDIUM", "HIGH"), "priority", "This field must equal LOW, MEDIUM or HIGH")
+}
+
+func (app *application) apiTaskCreate(w http.ResponseWriter, r *http.Request) {
+	workspaceId, ok := app.apiWorkspaceAccess(w, r, models.ActionEditTasks)
+	if !ok {
+		return
+	}
+
+	var input apiTaskInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errorJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	form := taskCreateForm{
+		Priority:    "LOW",
+		WorkspaceID: workspaceId,
+		AssigneeIDs: []int{r.Context().Value(userIDContextKey).(int)},
+	}
+	input.apply(&form)
+
+	apiValidateTask(&form)
+
+	if !form.Valid() {
+		app.failedValidationJSON(w, r, form.Validator)
+		return
+	}
+
+	id, err := app.tasks.Insert(form.Title, form.Content, form.Priority, form.Status, workspaceId, form.AssigneeIDs, form.LabelIDs, form.ParentID, form.DueDate, form.DueTime)
+	if err != nil {
+		if checkTaskError(&form.Validator, err) {
+			app.failedValidationJSON(w, r, form.Validator)
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	task, err := app.tasks.Get(id)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"task": task})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiTaskGet(w http.ResponseWriter, r *http.Request) {
+	task, ok := app.apiTaskAccess(w, r, models.ActionView)
+	if !ok {
+		return
+	}
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"task": task})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiTaskUpdate(w http.ResponseWriter, r *http.Request) {
+	task, ok := app.apiTaskAccess(w, r, models.ActionEditTasks)
+	if !ok {
+		return
+	}
+
+	var input apiTaskInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errorJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var dueDate, dueTime string
+	if task.DueDate != nil {
+		dueDate = task.DueDate.Format("2006-01-02")
+	}
+	if task.DueTime != nil && len(*task.DueTime) >= 5 {
+		dueTime = (*task.DueTime)[:5]
+	}
+
+	assigneeIds := make([]int, len(task.Assignees))
+	for i, user := range task.Assignees {
+		assigneeIds[i] = user.ID
+	}
+
+	labelIds := make([]int, len(task.Labels))
+	for i, label := range task.Labels {
+		labelIds[i] = label.ID
+	}
+
+	form := taskCreateForm{
+		Title:       task.Title,
+		Content:     task.Content,
+		Priority:    task.Priority,
+		Status:      task.Status,
+		DueDate:     dueDate,
+		DueTime:     dueTime,
+		AssigneeIDs: assigneeIds,
+		LabelIDs:    labelIds,
+	}
+	input.apply(&form)
+
+	apiValidateTask(&form)
+
+	if input.ParentID != nil {
+		form.CheckField(parentUnchanged(task, *input.ParentID), "parent_id", "The parent task can't be changed")
+	}
+
+	if !form.Valid() {
+		app.failedValidationJSON(w, r, form.Validator)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	err = app.tasks.Update(task.ID, userId, form.Title, form.Content, form.Priority, form.AssigneeIDs, form.LabelIDs, form.Status, form.DueDate, form.DueTime)
+	if err != nil {
+		if checkTaskError(&form.Validator, err) {
+			app.failedValidationJSON(w, r, form.Validator)
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	task, err = app.tasks.Get(task.ID)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"task": task})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (app *application) apiTaskArchive(w http.ResponseWriter, r *http.Request) {
+	task, ok := app.apiTaskAccess(w, r, models.ActionEditTasks)
+	if !ok {
+		return
+	}
+
+	err := app.tasks.SetArchived(task.ID, true)
+	if err != nil {
+		if errors.Is(err, models.ErrTaskNotFinished) {
+			app.errorJSON(w, r, http.StatusConflict, "only finished tasks can be archived")
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) apiTaskUnarchive(w http.ResponseWriter, r *http.Request) {
+	task, ok := app.apiTaskAccess(w, r, models.ActionEditTasks)
+	if !ok {
+		return
+	}
+
+	err := app.tasks.SetArchived(task.ID, false)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) apiTaskWatch(w http.ResponseWriter, r *http.Request) {
+	task, ok := app.apiTaskAccess(w, r, models.ActionParticipate)
+	if !ok {
+		return
+	}
+
+	err := app.tasks.Watch(task.ID, r.Context().Value(userIDContextKey).(int))
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) apiTaskUnwatch(w http.ResponseWriter, r *http.Request) {
+	task, ok := app.apiTaskAccess(w, r, models.ActionParticipate)
+	if !ok {
+		return
+	}
+
+	err := app.tasks.Unwatch(task.ID, r.Context().Value(userIDContextKey).(int))
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) apiTaskDelete(w http.ResponseWriter, r *http.Request) {
+	task, ok := app.apiTaskAccess(w, r, models.ActionDeleteTasks)
+	if !ok {
+		return
+	}
+
+	_, err := app.tasks.Delete(task.ID)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
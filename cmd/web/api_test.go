@@ -0,0 +1,791 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andres085/task_manager/internal/assert"
+)
+
+func TestAPIRequiresAuthentication(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, header, body := ts.get(t, "/api/v1/workspaces")
+
+	assert.Equal(t, code, http.StatusUnauthorized)
+	assert.Equal(t, header.Get("Content-Type"), "application/json")
+	assert.StringContains(t, body, "you must be authenticated")
+}
+
+func TestAPIBearerAuthentication(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name          string
+		method        string
+		urlPath       string
+		authorization string
+		data          string
+		wantCode      int
+	}{
+		{
+			name:          "Valid token",
+			method:        http.MethodGet,
+			urlPath:       "/api/v1/workspaces",
+			authorization: "Bearer VALIDTOKEN",
+			wantCode:      http.StatusOK,
+		},
+		{
+			name:          "Valid token skips CSRF",
+			method:        http.MethodPost,
+			urlPath:       "/api/v1/workspaces",
+			authorization: "Bearer VALIDTOKEN",
+			data:          `{"title": "Second Workspace", "description": "Description"}`,
+			wantCode:      http.StatusCreated,
+		},
+		{
+			name:          "Valid token ignored on HTML routes",
+			method:        http.MethodGet,
+			urlPath:       "/workspace/view/1",
+			authorization: "Bearer VALIDTOKEN",
+			wantCode:      http.StatusSeeOther,
+		},
+		{
+			name:          "Valid token doesn't skip CSRF on HTML routes",
+			method:        http.MethodPost,
+			urlPath:       "/workspace/create",
+			authorization: "Bearer VALIDTOKEN",
+			wantCode:      http.StatusBadRequest,
+		},
+		{
+			name:          "Unknown token",
+			method:        http.MethodGet,
+			urlPath:       "/api/v1/workspaces",
+			authorization: "Bearer UNKNOWNTOKEN",
+			wantCode:      http.StatusUnauthorized,
+		},
+		{
+			name:          "Malformed header",
+			method:        http.MethodGet,
+			urlPath:       "/api/v1/workspaces",
+			authorization: "Basic VALIDTOKEN",
+			wantCode:      http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.urlPath, strings.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.Header.Set("Authorization", tt.authorization)
+
+			rs, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rs.Body.Close()
+
+			assert.Equal(t, rs.StatusCode, tt.wantCode)
+
+			if tt.wantCode == http.StatusUnauthorized {
+				assert.Equal(t, rs.Header.Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+}
+
+func TestAPIWorkspaces(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name     string
+		method   string
+		urlPath  string
+		data     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "List",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/workspaces",
+			wantCode: http.StatusOK,
+			wantBody: `"owned":[{"id":1,"title":"First Workspace"`,
+		},
+		{
+			name:     "List quota",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/workspaces",
+			wantCode: http.StatusOK,
+			wantBody: `"quota":{"owned_workspaces":6,"member_workspaces":6,"owned":2,"member":5}`,
+		},
+		{
+			name:     "Get",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/workspaces/1",
+			wantCode: http.StatusOK,
+			wantBody: `"title":"First Workspace"`,
+		},
+		{
+			name:     "Get not member",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/workspaces/2",
+			wantCode: http.StatusNotFound,
+			wantBody: `"error":"the requested resource could not be found"`,
+		},
+		{
+			name:     "Create",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces",
+			data:     `{"title": "Second Workspace", "description": "Second workspace Description"}`,
+			wantCode: http.StatusCreated,
+			wantBody: `"id":2`,
+		},
+		{
+			name:     "Create over the workspace limit",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces",
+			data:     `{"title": "Over The Limit", "description": "Description"}`,
+			wantCode: http.StatusConflict,
+			wantBody: `"error":"you have reached the maximum number of workspaces you can own"`,
+		},
+		{
+			name:     "Create blank title",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces",
+			data:     `{"title": "", "description": "Description"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"title":"This field cannot be blank"`,
+		},
+		{
+			name:     "Create unknown field",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces",
+			data:     `{"title": "Title", "owner": 2}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `body contains unknown key \"owner\"`,
+		},
+		{
+			name:     "Create malformed body",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces",
+			data:     `{"title": `,
+			wantCode: http.StatusBadRequest,
+			wantBody: `badly-formed JSON`,
+		},
+		{
+			name:     "Update",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/workspaces/1",
+			data:     `{"title": "Updated Workspace"}`,
+			wantCode: http.StatusOK,
+			wantBody: `"title":"Updated Workspace","description":"First workspace Description"`,
+		},
+		{
+			name:     "Update not admin",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/workspaces/2",
+			data:     `{"title": "Updated Workspace"}`,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "List archived",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/workspaces?archived=true",
+			wantCode: http.StatusOK,
+			wantBody: `"title":"Archived Workspace"`,
+		},
+		{
+			name:     "Archive",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/archive",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Archive as viewer",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/7/archive",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Unarchive",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/11/unarchive",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Update archived",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/workspaces/11",
+			data:     `{"title":"Updated Workspace"}`,
+			wantCode: http.StatusForbidden,
+			wantBody: `"error":"the workspace is archived and read-only"`,
+		},
+		{
+			name:     "Delete",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/workspaces/1",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "List trashed",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/workspaces",
+			wantCode: http.StatusOK,
+			wantBody: `"trashed":[{"id":9,"title":"Trashed Workspace"`,
+		},
+		{
+			name:     "Restore",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/9/restore",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Restore over the workspace limit",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/10/restore",
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "Restore not in the trash",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/restore",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Trash",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/workspaces/1/trash",
+			wantCode: http.StatusOK,
+			wantBody: `"title":"Trashed Test Task"`,
+		},
+		{
+			name:     "Trash as viewer",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/workspaces/7/trash",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Restore task",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/trash/8/restore",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Restore subtask of a deleted task",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/trash/5/restore",
+			wantCode: http.StatusConflict,
+			wantBody: `"error":"restore the parent task first"`,
+		},
+		{
+			name:     "Purge task",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/workspaces/1/trash/8",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Purge task not in the trash",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/workspaces/1/trash/2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Missing CSRF token",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/workspaces/1",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csrfToken := validCSRFToken
+			if tt.name == "Missing CSRF token" {
+				csrfToken = ""
+			}
+
+			code, _, body := ts.doJSON(t, tt.method, tt.urlPath, csrfToken, tt.data)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestAPIWorkspaceUsers(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name     string
+		method   string
+		urlPath  string
+		data     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "List",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/workspaces/1/users",
+			wantCode: http.StatusOK,
+			wantBody: `"email":"pete@mail.com"`,
+		},
+		{
+			name:     "Add invalid email",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/users",
+			data:     `{"email": "not-an-email"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"email":"This field must be a valid email address"`,
+		},
+		{
+			name:     "Add unknown user",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/users",
+			data:     `{"email": "nobody@mail.com"}`,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Invite user",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/users",
+			data:     `{"email": "testmctesterson@mail.com"}`,
+			wantCode: http.StatusCreated,
+			wantBody: `"invitation_id":2`,
+		},
+		{
+			name:     "List invitations",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/invitations",
+			wantCode: http.StatusOK,
+			wantBody: `"workspace_title":"Invited Workspace"`,
+		},
+		{
+			name:     "Accept invitation",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/invitations/1/accept",
+			wantCode: http.StatusOK,
+			wantBody: `"workspace":`,
+		},
+		{
+			name:     "Accept invitation over the workspace limit",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/invitations/5/accept",
+			wantCode: http.StatusConflict,
+			wantBody: `"error":"you have reached the maximum number of workspaces you can be a member of"`,
+		},
+		{
+			name:     "Decline unknown invitation",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/invitations/9/decline",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Promote",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/workspaces/1/users/2",
+			data:     `{"role": "ADMIN"}`,
+			wantCode: http.StatusOK,
+			wantBody: `"role":"ADMIN"`,
+		},
+		{
+			name:     "Promote to owner",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/workspaces/1/users/2",
+			data:     `{"role": "OWNER"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"role":"This field must be ADMIN, EDITOR or VIEWER"`,
+		},
+		{
+			name:     "Demote owner",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/workspaces/1/users/1",
+			data:     `{"role": "EDITOR"}`,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "Transfer ownership",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/users/2/transfer",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Transfer ownership over the workspace limit",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/users/4/transfer",
+			wantCode: http.StatusConflict,
+			wantBody: `"error":"this user can't own any more workspaces"`,
+		},
+		{
+			name:     "Leave",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/7/leave",
+			wantCode: http.StatusOK,
+			wantBody: `"reassigned_tasks":2`,
+		},
+		{
+			name:     "Leave as owner",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/leave",
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "Leave an archived workspace",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/11/leave",
+			wantCode: http.StatusForbidden,
+			wantBody: `"error":"the workspace is archived and read-only"`,
+		},
+		{
+			name:     "Transfer ownership as viewer",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/7/users/2/transfer",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Remove",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/workspaces/1/users/1",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Remove not found user",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/workspaces/1/users/2",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.doJSON(t, tt.method, tt.urlPath, validCSRFToken, tt.data)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestAPITasks(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name     string
+		method   string
+		urlPath  string
+		data     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "List",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/workspaces/1/tasks?priority=LOW&sort=desc&limit=5&page=1",
+			wantCode: http.StatusOK,
+			wantBody: `"metadata":{"limit":5,"page":1`,
+		},
+		{
+			name:     "List statuses",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/workspaces/1/statuses",
+			wantCode: http.StatusOK,
+			wantBody: `"name":"In Progress","position":2,"is_done":false`,
+		},
+		{
+			name:     "List labels",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/workspaces/1/labels",
+			wantCode: http.StatusOK,
+			wantBody: `"name":"Bug","color":"red"`,
+		},
+		{
+			name:     "Create with labels",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/tasks",
+			data:     `{"title": "Title", "content": "Content", "label_ids": [1, 2]}`,
+			wantCode: http.StatusCreated,
+			wantBody: `"labels":`,
+		},
+		{
+			name:     "Create with a label from another workspace",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/tasks",
+			data:     `{"title": "Title", "content": "Content", "label_ids": [1, 9]}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"label_ids":"Every label has to belong to the workspace"`,
+		},
+		{
+			name:     "Create subtask with invalid parent",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/tasks",
+			data:     `{"title": "Title", "content": "Content", "parent_id": 7}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"parent_id":"The parent task must be a top level task of this workspace"`,
+		},
+		{
+			name:     "Create with status",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/tasks",
+			data:     `{"title": "Title", "content": "Content", "status": "Blocked"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"status":"This status is not part of the workspace workflow"`,
+		},
+		{
+			name:     "List not member",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/workspaces/2/tasks",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Create",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/tasks",
+			data:     `{"title": "Second Test Task", "content": "Second Test Task Content", "priority": "MEDIUM"}`,
+			wantCode: http.StatusCreated,
+			wantBody: `"task":{"id":2`,
+		},
+		{
+			name:     "Create invalid fields",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/tasks",
+			data:     `{"title": "", "content": "Content", "priority": "URGENT", "due_time": "10:00"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"priority":"This field must equal LOW, MEDIUM or HIGH"`,
+		},
+		{
+			name:     "Create with a non member assignee",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/tasks",
+			data:     `{"title": "Title", "content": "Content", "user_id": 3}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"assignee_ids":"Every assignee has to be a member of the workspace"`,
+		},
+		{
+			name:     "Create with several assignees",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/tasks",
+			data:     `{"title": "Title", "content": "Content", "assignee_ids": [1, 2]}`,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "Create without assignees",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/tasks",
+			data:     `{"title": "Title", "content": "Content", "assignee_ids": []}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"assignee_ids":"Pick at least one assignee"`,
+		},
+		{
+			name:     "Get",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/tasks/1",
+			wantCode: http.StatusOK,
+			wantBody: `"title":"First Test Task"`,
+		},
+		{
+			name:     "List assigned to me",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/tasks?status=To+Do&limit=5",
+			wantCode: http.StatusOK,
+			wantBody: `"workspace_title":"Viewer Workspace"`,
+		},
+		{
+			name:     "Get assignees and watchers",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/tasks/1",
+			wantCode: http.StatusOK,
+			wantBody: `"watchers":[{"id":1`,
+		},
+		{
+			name:     "List assigned and watching",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/workspaces/1/tasks?assigned=true&watching=true",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Watch",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/tasks/6/watch",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Unwatch",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/tasks/1/watch",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Watch not member",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/tasks/2/watch",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Watch in an archived workspace",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/tasks/11/watch",
+			wantCode: http.StatusForbidden,
+			wantBody: `"error":"the workspace is archived and read-only"`,
+		},
+		{
+			name:     "Get not member",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/tasks/2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Update",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/tasks/1",
+			data:     `{"status": "Completed"}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Update invalid status",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/tasks/1",
+			data:     `{"status": "Blocked"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"status":"This status is not part of the workspace workflow"`,
+		},
+		{
+			name:     "Update disallowed transition",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/tasks/1",
+			data:     `{"status": "Archived"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"status":"The workspace workflow doesn't allow this status change"`,
+		},
+		{
+			name:     "Update parent",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/tasks/1",
+			data:     `{"parent_id": 5}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"parent_id":"The parent task can't be changed"`,
+		},
+		{
+			name:     "Update with a label from another workspace",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/tasks/1",
+			data:     `{"label_ids": [9]}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"label_ids":"Every label has to belong to the workspace"`,
+		},
+		{
+			name:     "Update wrong type",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/tasks/1",
+			data:     `{"user_id": "two"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `incorrect JSON type for field \"user_id\"`,
+		},
+		{
+			name:     "Archive",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/tasks/12/archive",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Archive unfinished",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/tasks/1/archive",
+			wantCode: http.StatusConflict,
+			wantBody: `"error":"only finished tasks can be archived"`,
+		},
+		{
+			name:     "Unarchive",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/tasks/12/unarchive",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Update in an archived workspace",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/tasks/11",
+			data:     `{"title":"Updated Task"}`,
+			wantCode: http.StatusForbidden,
+			wantBody: `"error":"the workspace is archived and read-only"`,
+		},
+		{
+			name:     "Archive finished",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/workspaces/1/tasks/archive",
+			wantCode: http.StatusOK,
+			wantBody: `"archived":2`,
+		},
+		{
+			name:     "Delete",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/tasks/1",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Delete not member",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/tasks/2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Delete as viewer",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/tasks/6",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Update as viewer",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/tasks/6",
+			data:     `{"title": "Updated"}`,
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.doJSON(t, tt.method, tt.urlPath, validCSRFToken, tt.data)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -5,10 +5,15 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/andres085/task_manager/internal/models"
+	"github.com/andres085/task_manager/internal/totp"
 	"github.com/andres085/task_manager/internal/validator"
+	"github.com/skip2/go-qrcode"
 )
 
 func (app *application) home(w http.ResponseWriter, r *http.Request) {
@@ -35,33 +40,24 @@ func (app *application) taskView(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userId := r.Context().Value(userIDContextKey).(int)
-	userIsAdmin, err := app.workspaces.ValidateAdmin(userId, task.WorkspaceId)
+	perm, err := app.taskPermission(userId, task.ID, models.ActionView)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	isTaskOwner, err := app.tasks.ValidateOwnership(userId, task.ID)
-	if err != nil {
-		app.serverError(w, r, err)
-		return
-	}
-
-	if !isTaskOwner {
+	if !perm.Allowed {
 		http.NotFound(w, r)
 		return
 	}
 
-	taskOwner, err := app.users.GetUser(task.UserId)
+	data, err := app.newTaskViewData(r, task, perm)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	data := app.newTemplateData(r)
-	data.Task = task
-	data.IsAdmin = userIsAdmin
-	data.TaskOwner = taskOwner
+	data.Form = commentForm{}
 
 	app.render(w, r, http.StatusOK, "task_view.html", data)
 }
@@ -74,13 +70,13 @@ func (app *application) taskViewAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	isOwner, err := app.workspaces.ValidateOwnership(userId, workspaceId)
+	perm, err := app.workspacePermission(userId, workspaceId, models.ActionView)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	if !isOwner {
+	if !perm.Allowed {
 		http.NotFound(w, r)
 		return
 	}
@@ -89,22 +85,50 @@ func (app *application) taskViewAll(w http.ResponseWriter, r *http.Request) {
 	title := queryParams.Get("title")
 	priority := queryParams.Get("priority")
 	status := queryParams.Get("status")
+	due := queryParams.Get("due")
 	sort := queryParams.Get("sort")
+	sortBy := queryParams.Get("sort_by")
+
+	filter := models.TaskFilter{
+		Title:           title,
+		Priority:        priority,
+		Status:          status,
+		Due:             due,
+		Labels:          getLabelFilter(r),
+		SortBy:          sortBy,
+		Sort:            sort,
+		IncludeArchived: getBoolParam(r, "archived"),
+	}
+
+	if getBoolParam(r, "assigned") {
+		filter.AssignedTo = userId
+	}
+
+	if getBoolParam(r, "watching") {
+		filter.WatchedBy = userId
+	}
+
+	limit, page, offset := getPaginationParams(r, 10)
 
-	userIsAdmin, err := app.workspaces.ValidateAdmin(userId, workspaceId)
+	tasks, err := app.tasks.GetAll(workspaceId, limit, offset, filter)
 	if err != nil {
 		app.serverError(w, r, err)
+		return
 	}
 
-	limit, page, offset := getPaginationParams(r, 10)
+	totalTasks, err := app.tasks.GetTotalTasks(workspaceId, filter)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
 
-	tasks, err := app.tasks.GetAll(workspaceId, limit, offset, title, priority, status, sort)
+	statuses, err := app.statuses.GetAll(workspaceId)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	totalTasks, err := app.tasks.GetTotalTasks(workspaceId, title, priority, status)
+	labels, err := app.labels.GetAll(workspaceId)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
@@ -114,31 +138,250 @@ func (app *application) taskViewAll(w http.ResponseWriter, r *http.Request) {
 
 	data := app.newTemplateData(r)
 	data.Tasks = tasks
+	data.Statuses = statuses
+	data.Labels = labels
 	data.Workspace.ID = workspaceId
 	data.Limit = limit
 	data.CurrentPage = page
 	data.TotalPages = totalPages
-	data.IsAdmin = userIsAdmin
+	data.setPermission(perm)
 	data.Filter = title
 	data.PriorityFilter = priority
 	data.StatusFilter = status
+	data.DueFilter = due
+	data.LabelFilter = filter.Labels
+	data.IncludeArchived = filter.IncludeArchived
+	data.AssignedFilter = filter.AssignedTo != 0
+	data.WatchingFilter = filter.WatchedBy != 0
 
 	app.render(w, r, http.StatusOK, "tasks_view.html", data)
 }
 
+func (app *application) taskDashboard(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	queryParams := r.URL.Query()
+	title := queryParams.Get("title")
+	priority := queryParams.Get("priority")
+	status := queryParams.Get("status")
+
+	filter := models.TaskFilter{
+		Title:    title,
+		Priority: priority,
+		Status:   status,
+	}
+
+	limit, page, offset := getPaginationParams(r, 10)
+
+	tasks, err := app.tasks.GetAssigned(userId, limit, offset, filter)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	totalTasks, err := app.tasks.GetTotalAssigned(userId, filter)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	statusNames, err := app.statuses.GetNamesByUser(userId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.TaskGroups = groupTasks(tasks)
+	data.StatusNames = statusNames
+	data.Limit = limit
+	data.CurrentPage = page
+	data.TotalPages = int(math.Ceil(float64(totalTasks) / float64(limit)))
+	data.Filter = title
+	data.PriorityFilter = priority
+	data.StatusFilter = status
+
+	app.render(w, r, http.StatusOK, "tasks_dashboard.html", data)
+}
+
+func (app *application) workspaceBoard(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	workspace, err := app.workspaces.Get(workspaceId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+	perm, err := app.workspacePermission(userId, workspaceId, models.ActionView)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	queryParams := r.URL.Query()
+	title := queryParams.Get("title")
+	priority := queryParams.Get("priority")
+
+	filter := models.TaskFilter{
+		Title:    title,
+		Priority: priority,
+	}
+
+	tasks, err := app.tasks.GetAll(workspaceId, 0, 0, filter)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	statuses, err := app.statuses.GetAll(workspaceId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	columns := make([]boardColumn, len(statuses))
+	for i, status := range statuses {
+		columns[i].Status = status
+	}
+
+	for _, task := range tasks {
+		for i := range columns {
+			if columns[i].Status.Name == task.Status {
+				columns[i].Tasks = append(columns[i].Tasks, task)
+			}
+		}
+	}
+
+	data := app.newTemplateData(r)
+	data.Workspace = workspace
+	data.BoardColumns = columns
+	data.setPermission(perm)
+	data.Filter = title
+	data.PriorityFilter = priority
+
+	app.render(w, r, http.StatusOK, "workspace_board.html", data)
+}
+
+type taskStatusForm struct {
+	Status              string `form:"status"`
+	validator.Validator `form:"-"`
+}
+
+func checkTaskError(v *validator.Validator, err error) bool {
+	switch {
+	case errors.Is(err, models.ErrInvalidStatus):
+		v.AddFieldError("status", "This status is not part of the workspace workflow")
+	case errors.Is(err, models.ErrInvalidTransition):
+		v.AddFieldError("status", "The workspace workflow doesn't allow this status change")
+	case errors.Is(err, models.ErrOpenSubtasks):
+		v.AddFieldError("status", "Every subtask has to be finished before this task can be completed")
+	case errors.Is(err, models.ErrTaskBlocked):
+		v.AddFieldError("status", "This task is blocked by tasks that are still open")
+	case errors.Is(err, models.ErrInvalidParent):
+		v.AddFieldError("parent_id", "The parent task must be a top level task of this workspace")
+	case errors.Is(err, models.ErrInvalidAssignee):
+		v.AddFieldError("assignee_ids", "Every assignee has to be a member of the workspace")
+	case errors.Is(err, models.ErrInvalidLabel):
+		v.AddFieldError("label_ids", "Every label has to belong to the workspace")
+	default:
+		return false
+	}
+
+	return true
+}
+
+// parentUnchanged reports whether parentId leaves the task under its current parent. Tasks can't
+// be moved between parents, so edits reject a different parent_id instead of ignoring it.
+func parentUnchanged(task models.Task, parentId int) bool {
+	if task.ParentId == nil {
+		return parentId == 0
+	}
+
+	return *task.ParentId == parentId
+}
+
+func (app *application) taskStatusUpdatePost(w http.ResponseWriter, r *http.Request) {
+	taskId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || taskId < 1 {
+		app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		return
+	}
+
+	var form taskStatusForm
+
+	err = app.decodePostForm(r, &form)
+	if err != nil {
+		app.errorJSON(w, r, http.StatusBadRequest, "the request body could not be decoded")
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Status), "status", "This field cannot be blank")
+
+	if !form.Valid() {
+		app.failedValidationJSON(w, r, form.Validator)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	err = app.tasks.UpdateStatus(taskId, userId, form.Status)
+	if err != nil {
+		if checkTaskError(&form.Validator, err) {
+			app.failedValidationJSON(w, r, form.Validator)
+		} else if errors.Is(err, models.ErrNoRecord) {
+			app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	task, err := app.tasks.Get(taskId)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"task": task})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
 type taskCreateForm struct {
 	ID                  *int
 	Title               string                `form:"title"`
 	Content             string                `form:"content"`
 	Priority            string                `form:"priority"`
 	Status              string                `form:"status"`
+	DueDate             string                `form:"due_date"`
+	DueTime             string                `form:"due_time"`
 	WorkspaceID         int                   `form:"workspace_id"`
-	UserID              int                   `form:"user_id"`
-	DefaultUser         models.UserWithRole   `form:"-"`
+	AssigneeIDs         []int                 `form:"assignee_ids"`
+	ParentID            int                   `form:"parent_id"`
+	LabelIDs            []int                 `form:"label_ids"`
 	WorkspaceUsers      []models.UserWithRole `form:"-"`
 	validator.Validator `form:"-"`
 }
 
+func (form *taskCreateForm) validate() {
+	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Title, 100), "title", "This field cannot be more than 100 characters long")
+	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank")
+	form.CheckField(len(form.AssigneeIDs) > 0, "assignee_ids", "Pick at least one assignee")
+
+	if form.DueDate != "" {
+		form.CheckField(validator.ValidTime(form.DueDate, "2006-01-02"), "due_date", "This field must be a valid date")
+	}
+
+	if form.DueTime != "" {
+		form.CheckField(validator.NotBlank(form.DueDate), "due_time", "A due time requires a due date")
+		form.CheckField(validator.ValidTime(form.DueTime, "15:04"), "due_time", "This field must be a valid time")
+	}
+}
+
 func (app *application) taskCreate(w http.ResponseWriter, r *http.Request) {
 	workspaceId, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil || workspaceId < 1 {
@@ -148,17 +391,50 @@ func (app *application) taskCreate(w http.ResponseWriter, r *http.Request) {
 
 	data := app.newTemplateData(r)
 
-	adminUser, regularUsers, err := app.getFormsDefaultUser(workspaceId)
+	workspaceUsers, err := app.users.GetWorkspaceUsers(workspaceId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data.Labels, err = app.labels.GetAll(workspaceId)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
+	var parentId int
+	if r.URL.Query().Has("parent") {
+		parentId, err = strconv.Atoi(r.URL.Query().Get("parent"))
+		if err != nil || parentId < 1 {
+			http.NotFound(w, r)
+			return
+		}
+
+		parent, err := app.tasks.Get(parentId)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) {
+				http.NotFound(w, r)
+			} else {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+
+		if parent.WorkspaceId != workspaceId || parent.ParentId != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		data.ParentTask = &parent
+	}
+
 	data.Form = taskCreateForm{
 		Priority:       "LOW",
 		WorkspaceID:    workspaceId,
-		DefaultUser:    adminUser,
-		WorkspaceUsers: regularUsers,
+		ParentID:       parentId,
+		AssigneeIDs:    []int{r.Context().Value(userIDContextKey).(int)},
+		WorkspaceUsers: workspaceUsers,
 	}
 
 	app.render(w, r, http.StatusOK, "task_create.html", data)
@@ -174,36 +450,57 @@ func (app *application) taskCreatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank")
-	form.CheckField(validator.MaxChars(form.Title, 100), "title", "This field cannot be more than 100 characters long")
-	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank")
+	userId := r.Context().Value(userIDContextKey).(int)
+	perm, err := app.workspacePermission(userId, form.WorkspaceID, models.ActionEditTasks)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
 
-	if !form.Valid() {
-		data := app.newTemplateData(r)
+	if !perm.Allowed {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
 
-		adminUser, regularUsers, err := app.getFormsDefaultUser(form.WorkspaceID)
-		if err != nil {
+	form.validate()
+
+	if form.Valid() {
+		id, err := app.tasks.Insert(form.Title, form.Content, form.Priority, "", form.WorkspaceID, form.AssigneeIDs, form.LabelIDs, form.ParentID, form.DueDate, form.DueTime)
+		if err == nil {
+			app.sessionManager.Put(r.Context(), "flash", "Task successfully created!")
+
+			http.Redirect(w, r, fmt.Sprintf("/task/view/%d", id), http.StatusSeeOther)
+			return
+		}
+
+		if errors.Is(err, models.ErrInvalidParent) {
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+
+		if !checkTaskError(&form.Validator, err) {
 			app.serverError(w, r, err)
 			return
 		}
+	}
 
-		form.DefaultUser = adminUser
-		form.WorkspaceUsers = regularUsers
-		data.Form = form
+	data := app.newTemplateData(r)
 
-		app.render(w, r, http.StatusUnprocessableEntity, "task_create.html", data)
+	form.WorkspaceUsers, err = app.users.GetWorkspaceUsers(form.WorkspaceID)
+	if err != nil {
+		app.serverError(w, r, err)
 		return
 	}
 
-	id, err := app.tasks.Insert(form.Title, form.Content, form.Priority, form.WorkspaceID, form.UserID)
+	data.Labels, err = app.labels.GetAll(form.WorkspaceID)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	app.sessionManager.Put(r.Context(), "flash", "Task successfully created!")
+	data.Form = form
 
-	http.Redirect(w, r, fmt.Sprintf("/task/view/%d", id), http.StatusSeeOther)
+	app.render(w, r, http.StatusUnprocessableEntity, "task_create.html", data)
 }
 
 func (app *application) taskUpdate(w http.ResponseWriter, r *http.Request) {
@@ -229,26 +526,51 @@ func (app *application) taskUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var assignedUser models.UserWithRole
-	otherUsers := make([]models.UserWithRole, len(workspaceUsers)-1)
+	statuses, err := app.statuses.GetAll(task.WorkspaceId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
 
-	for i, user := range workspaceUsers {
-		if user.ID == task.UserId {
-			assignedUser = user
-			otherUsers = append(workspaceUsers[:i], workspaceUsers[i+1:]...)
-		}
+	labels, err := app.labels.GetAll(task.WorkspaceId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	labelIds := make([]int, len(task.Labels))
+	for i, label := range task.Labels {
+		labelIds[i] = label.ID
+	}
+
+	assigneeIds := make([]int, len(task.Assignees))
+	for i, user := range task.Assignees {
+		assigneeIds[i] = user.ID
+	}
+
+	var dueDate, dueTime string
+	if task.DueDate != nil {
+		dueDate = task.DueDate.Format("2006-01-02")
+	}
+	if task.DueTime != nil && len(*task.DueTime) >= 5 {
+		dueTime = (*task.DueTime)[:5]
 	}
 
 	data := app.newTemplateData(r)
+	data.Statuses = statuses
+	data.Labels = labels
 
 	data.Form = taskCreateForm{
 		ID:             &task.ID,
 		Title:          task.Title,
 		Content:        task.Content,
 		Priority:       task.Priority,
-		DefaultUser:    assignedUser,
-		WorkspaceUsers: otherUsers,
+		AssigneeIDs:    assigneeIds,
+		WorkspaceUsers: workspaceUsers,
 		Status:         task.Status,
+		DueDate:        dueDate,
+		DueTime:        dueTime,
+		LabelIDs:       labelIds,
 	}
 
 	app.render(w, r, http.StatusOK, "task_update.html", data)
@@ -261,45 +583,71 @@ func (app *application) taskUpdatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userId := r.Context().Value(userIDContextKey).(int)
-	isTaskOwner, err := app.tasks.ValidateOwnership(userId, id)
+	var form taskCreateForm
+
+	err = app.decodePostForm(r, &form)
 	if err != nil {
-		app.serverError(w, r, err)
+		app.clientError(w, http.StatusBadRequest)
 		return
 	}
 
-	if !isTaskOwner {
-		http.NotFound(w, r)
-		return
+	form.validate()
+
+	if r.PostForm.Has("parent_id") {
+		task, err := app.tasks.Get(id)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		form.CheckField(parentUnchanged(task, form.ParentID), "parent_id", "The parent task can't be changed")
 	}
 
-	var form taskCreateForm
+	userId := r.Context().Value(userIDContextKey).(int)
 
-	err = app.decodePostForm(r, &form)
+	if form.Valid() {
+		err = app.tasks.Update(id, userId, form.Title, form.Content, form.Priority, form.AssigneeIDs, form.LabelIDs, form.Status, form.DueDate, form.DueTime)
+		if err == nil {
+			http.Redirect(w, r, fmt.Sprintf("/task/view/%d", id), http.StatusSeeOther)
+			return
+		}
+
+		if !checkTaskError(&form.Validator, err) {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	task, err := app.tasks.Get(id)
 	if err != nil {
-		app.clientError(w, http.StatusBadRequest)
+		app.serverError(w, r, err)
 		return
 	}
 
-	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank")
-	form.CheckField(validator.MaxChars(form.Title, 100), "title", "This field cannot be more than 100 characters long")
-	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank")
+	statuses, err := app.statuses.GetAll(task.WorkspaceId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
 
-	if !form.Valid() {
-		data := app.newTemplateData(r)
-		form.ID = &id
-		data.Form = form
-		app.render(w, r, http.StatusUnprocessableEntity, "task_update.html", data)
+	labels, err := app.labels.GetAll(task.WorkspaceId)
+	if err != nil {
+		app.serverError(w, r, err)
 		return
 	}
 
-	err = app.tasks.Update(id, form.Title, form.Content, form.Priority, form.UserID, form.Status)
+	form.WorkspaceUsers, err = app.users.GetWorkspaceUsers(task.WorkspaceId)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	http.Redirect(w, r, fmt.Sprintf("/task/view/%d", id), http.StatusSeeOther)
+	data := app.newTemplateData(r)
+	form.ID = &id
+	data.Form = form
+	data.Statuses = statuses
+	data.Labels = labels
+	app.render(w, r, http.StatusUnprocessableEntity, "task_update.html", data)
 }
 
 func (app *application) taskDelete(w http.ResponseWriter, r *http.Request) {
@@ -309,7 +657,7 @@ func (app *application) taskDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Removed the error validation here because we do this validation in the checkTaskAdmin middleware
+	// requireTaskPermission(models.ActionDeleteTasks) has already validated the id.
 	id, _ := strconv.Atoi(r.PathValue("id"))
 
 	_, err = app.tasks.Delete(id)
@@ -318,30 +666,2064 @@ func (app *application) taskDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	app.sessionManager.Put(r.Context(), "flash", "Task moved to the trash")
+
 	http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d/tasks", workspaceId), http.StatusSeeOther)
 }
 
-func (app *application) ping(w http.ResponseWriter, r *http.Request) {
-	w.Write([]byte("OK"))
-}
+func (app *application) taskArchivePost(w http.ResponseWriter, r *http.Request) {
+	taskId, _ := strconv.Atoi(r.PathValue("id"))
 
-type workspaceCreateForm struct {
+	err := app.tasks.SetArchived(taskId, true)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			http.NotFound(w, r)
+		case errors.Is(err, models.ErrTaskNotFinished):
+			app.sessionManager.Put(r.Context(), "flash", "Only finished tasks can be archived")
+			http.Redirect(w, r, fmt.Sprintf("/task/view/%d", taskId), http.StatusSeeOther)
+		default:
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Task archived")
+
+	http.Redirect(w, r, fmt.Sprintf("/task/view/%d", taskId), http.StatusSeeOther)
+}
+
+func (app *application) taskUnarchivePost(w http.ResponseWriter, r *http.Request) {
+	taskId, _ := strconv.Atoi(r.PathValue("id"))
+
+	err := app.tasks.SetArchived(taskId, false)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Task unarchived")
+
+	http.Redirect(w, r, fmt.Sprintf("/task/view/%d", taskId), http.StatusSeeOther)
+}
+
+func (app *application) taskWatchPost(w http.ResponseWriter, r *http.Request) {
+	taskId, _ := strconv.Atoi(r.PathValue("id"))
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	err := app.tasks.Watch(taskId, userId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "You are now watching this task")
+
+	http.Redirect(w, r, fmt.Sprintf("/task/view/%d", taskId), http.StatusSeeOther)
+}
+
+func (app *application) taskUnwatchPost(w http.ResponseWriter, r *http.Request) {
+	taskId, _ := strconv.Atoi(r.PathValue("id"))
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	err := app.tasks.Unwatch(taskId, userId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "You are no longer watching this task")
+
+	http.Redirect(w, r, fmt.Sprintf("/task/view/%d", taskId), http.StatusSeeOther)
+}
+
+func (app *application) workspaceArchiveTasksPost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	archived, err := app.tasks.ArchiveFinished(workspaceId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", fmt.Sprintf("%d finished tasks archived", archived))
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d/tasks", workspaceId), http.StatusSeeOther)
+}
+
+func (app *application) workspaceTrash(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	workspace, err := app.workspaces.Get(workspaceId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	tasks, err := app.tasks.GetTrash(workspaceId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Workspace = workspace
+	data.Tasks = tasks
+	data.TrashRetention = app.trashRetention
+
+	app.render(w, r, http.StatusOK, "workspace_trash.html", data)
+}
+
+func (app *application) taskRestorePost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	taskId, err := strconv.Atoi(r.PathValue("taskId"))
+	if err != nil || taskId < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = app.tasks.Restore(taskId, workspaceId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			http.NotFound(w, r)
+		case errors.Is(err, models.ErrInvalidParent):
+			app.sessionManager.Put(r.Context(), "flash", "Restore the parent task first")
+			http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d/trash", workspaceId), http.StatusSeeOther)
+		default:
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Task restored")
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d/trash", workspaceId), http.StatusSeeOther)
+}
+
+func (app *application) taskPurgePost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	taskId, err := strconv.Atoi(r.PathValue("taskId"))
+	if err != nil || taskId < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = app.tasks.Purge(taskId, workspaceId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Task permanently deleted")
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d/trash", workspaceId), http.StatusSeeOther)
+}
+
+type checklistItemForm struct {
+	Content             string `form:"content"`
+	validator.Validator `form:"-"`
+}
+
+func (app *application) getTaskChecklistItem(r *http.Request) (models.ChecklistItem, error) {
+	taskId, _ := strconv.Atoi(r.PathValue("id"))
+
+	itemId, err := strconv.Atoi(r.PathValue("itemId"))
+	if err != nil || itemId < 1 {
+		return models.ChecklistItem{}, models.ErrNoRecord
+	}
+
+	item, err := app.checklists.Get(itemId)
+	if err != nil {
+		return models.ChecklistItem{}, err
+	}
+
+	if item.TaskId != taskId {
+		return models.ChecklistItem{}, models.ErrNoRecord
+	}
+
+	return item, nil
+}
+
+func (app *application) checklistItemCreatePost(w http.ResponseWriter, r *http.Request) {
+	// requireTaskPermission(models.ActionEditTasks) has already validated the id.
+	taskId, _ := strconv.Atoi(r.PathValue("id"))
+
+	var form checklistItemForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Content, 255), "content", "This field cannot be more than 255 characters long")
+
+	if !form.Valid() {
+		app.sessionManager.Put(r.Context(), "flash", "Checklist items must have between 1 and 255 characters")
+		http.Redirect(w, r, fmt.Sprintf("/task/view/%d#checklist", taskId), http.StatusSeeOther)
+		return
+	}
+
+	_, err = app.checklists.Insert(taskId, form.Content)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/task/view/%d#checklist", taskId), http.StatusSeeOther)
+}
+
+func (app *application) checklistItemTogglePost(w http.ResponseWriter, r *http.Request) {
+	item, err := app.getTaskChecklistItem(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	err = app.checklists.SetDone(item.ID, !item.Done)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/task/view/%d#checklist", item.TaskId), http.StatusSeeOther)
+}
+
+func (app *application) checklistItemDeletePost(w http.ResponseWriter, r *http.Request) {
+	item, err := app.getTaskChecklistItem(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	err = app.checklists.Delete(item.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/task/view/%d#checklist", item.TaskId), http.StatusSeeOther)
+}
+
+type dependencyForm struct {
+	BlockedByID int `form:"blocked_by_id"`
+}
+
+func (app *application) dependencyCreatePost(w http.ResponseWriter, r *http.Request) {
+	// requireTaskPermission(models.ActionEditTasks) has already validated the id.
+	taskId, _ := strconv.Atoi(r.PathValue("id"))
+
+	var form dependencyForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.dependencies.Insert(taskId, form.BlockedByID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.sessionManager.Put(r.Context(), "flash", "The blocking task must belong to the same workspace")
+		case errors.Is(err, models.ErrDependencyCycle):
+			app.sessionManager.Put(r.Context(), "flash", "That dependency would create a cycle")
+		default:
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/task/view/%d#dependencies", taskId), http.StatusSeeOther)
+}
+
+func (app *application) dependencyDeletePost(w http.ResponseWriter, r *http.Request) {
+	taskId, _ := strconv.Atoi(r.PathValue("id"))
+
+	blockerId, err := strconv.Atoi(r.PathValue("blockerId"))
+	if err != nil || blockerId < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = app.dependencies.Delete(taskId, blockerId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/task/view/%d#dependencies", taskId), http.StatusSeeOther)
+}
+
+type commentForm struct {
+	Content             string `form:"content"`
+	ParentID            int    `form:"parent_id"`
+	validator.Validator `form:"-"`
+}
+
+func (app *application) commentCreatePost(w http.ResponseWriter, r *http.Request) {
+	// requireTaskPermission(models.ActionEditTasks) has already validated the id.
+	taskId, _ := strconv.Atoi(r.PathValue("id"))
+
+	task, err := app.tasks.Get(taskId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	var form commentForm
+
+	err = app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Content, 1000), "content", "This field cannot be more than 1000 characters long")
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	if !form.Valid() {
+		perm, err := app.taskPermission(userId, task.ID, models.ActionView)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		data, err := app.newTaskViewData(r, task, perm)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "task_view.html", data)
+		return
+	}
+
+	_, err = app.comments.Insert(task.ID, userId, form.ParentID, form.Content)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.clientError(w, http.StatusBadRequest)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Comment successfully added!")
+
+	http.Redirect(w, r, fmt.Sprintf("/task/view/%d#comments", task.ID), http.StatusSeeOther)
+}
+
+func (app *application) getTaskComment(r *http.Request) (models.Comment, error) {
+	taskId, _ := strconv.Atoi(r.PathValue("id"))
+
+	commentId, err := strconv.Atoi(r.PathValue("commentId"))
+	if err != nil || commentId < 1 {
+		return models.Comment{}, models.ErrNoRecord
+	}
+
+	comment, err := app.comments.Get(commentId)
+	if err != nil {
+		return models.Comment{}, err
+	}
+
+	if comment.TaskId != taskId {
+		return models.Comment{}, models.ErrNoRecord
+	}
+
+	return comment, nil
+}
+
+func (app *application) commentUpdatePost(w http.ResponseWriter, r *http.Request) {
+	comment, err := app.getTaskComment(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+	if comment.UserId != userId {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	var form commentForm
+
+	err = app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Content, 1000), "content", "This field cannot be more than 1000 characters long")
+
+	if !form.Valid() {
+		task, err := app.tasks.Get(comment.TaskId)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		perm, err := app.taskPermission(userId, task.ID, models.ActionView)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		data, err := app.newTaskViewData(r, task, perm)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "task_view.html", data)
+		return
+	}
+
+	err = app.comments.Update(comment.ID, form.Content)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/task/view/%d#comments", comment.TaskId), http.StatusSeeOther)
+}
+
+func (app *application) commentDeletePost(w http.ResponseWriter, r *http.Request) {
+	comment, err := app.getTaskComment(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+	if comment.UserId != userId {
+		perm, err := app.taskPermission(userId, comment.TaskId, models.ActionDeleteTasks)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		if !perm.Allowed {
+			app.clientError(w, http.StatusForbidden)
+			return
+		}
+	}
+
+	_, err = app.comments.Delete(comment.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/task/view/%d#comments", comment.TaskId), http.StatusSeeOther)
+}
+
+func (app *application) ping(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("OK"))
+}
+
+type workspaceCreateForm struct {
 	ID                  *int
 	Title               string `form:"title"`
 	Description         string `form:"description"`
+	BlockOpenSubtasks   bool   `form:"block_open_subtasks"`
+	validator.Validator `form:"-"`
+}
+
+func (form *workspaceCreateForm) validate() {
+	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Title, 100), "title", "This field cannot be more than 100 characters long")
+	form.CheckField(validator.NotBlank(form.Description), "description", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Description, 255), "description", "This field cannot be more than 255 characters long")
+}
+
+func (app *application) workspaceCreate(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+
+	data.Form = workspaceCreateForm{}
+
+	app.render(w, r, http.StatusOK, "workspace_create.html", data)
+}
+
+func (app *application) workspaceCreatePost(w http.ResponseWriter, r *http.Request) {
+	var form workspaceCreateForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.validate()
+
+	if !form.Valid() {
+		data := app.newTemplateData(r)
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "workspace_create.html", data)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	id, err := app.workspaces.Insert(form.Title, form.Description, userId)
+	if err != nil {
+		if errors.Is(err, models.ErrWorkspaceLimit) {
+			form.AddNonFieldError("You have reached the maximum number of workspaces you can own")
+
+			data := app.newTemplateData(r)
+			data.Form = form
+			app.render(w, r, http.StatusUnprocessableEntity, "workspace_create.html", data)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Workspace successfully created!")
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d", id), http.StatusSeeOther)
+}
+
+func (app *application) workspaceView(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+
+	workspace, err := app.workspaces.Get(id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+	perm, err := app.workspacePermission(userId, id, models.ActionView)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	workspaceUsers, err := app.users.GetWorkspaceUsers(id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Workspace = workspace
+	data.setPermission(perm)
+	data.WorkspaceUsers = workspaceUsers
+
+	app.render(w, r, http.StatusOK, "workspace_view.html", data)
+}
+
+func (app *application) workspaceViewAll(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(userIDContextKey).(int)
+	includeArchived := getBoolParam(r, "archived")
+
+	ownWorkspaces, err := app.workspaces.GetAll(userId, true, includeArchived)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	invitedWorkspaces, err := app.workspaces.GetAll(userId, false, includeArchived)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	quota, err := app.limits.GetQuota(userId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	trashedWorkspaces, err := app.workspaces.GetTrash(userId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.OwnedWorkspaces = ownWorkspaces
+	data.InvitedWorkspaces = invitedWorkspaces
+	data.Quota = quota
+	data.TrashedWorkspaces = trashedWorkspaces
+	data.TrashRetention = app.trashRetention
+	data.WorkspaceLimit = quota.OwnedRemaining() > 0
+	data.IncludeArchived = includeArchived
+
+	app.render(w, r, http.StatusOK, "workspaces_view.html", data)
+}
+
+func (app *application) workspaceUpdate(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+
+	workspace, err := app.workspaces.Get(id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+
+	data.Form = workspaceCreateForm{
+		ID:                &workspace.ID,
+		Title:             workspace.Title,
+		Description:       workspace.Description,
+		BlockOpenSubtasks: workspace.BlockOpenSubtasks,
+	}
+
+	app.render(w, r, http.StatusOK, "workspace_update.html", data)
+}
+
+func (app *application) workspaceUpdatePost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	var form workspaceCreateForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.validate()
+
+	if !form.Valid() {
+		data := app.newTemplateData(r)
+		form.ID = &workspaceId
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "workspace_update.html", data)
+		return
+	}
+
+	err = app.workspaces.Update(workspaceId, form.Title, form.Description, form.BlockOpenSubtasks)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d", workspaceId), http.StatusSeeOther)
+}
+
+func (app *application) workspaceAddUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	workspace, err := app.workspaces.Get(id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	email := r.URL.Query().Get("email")
+	var foundUser *models.User
+
+	if email != "" {
+		foundUser, err = app.users.GetUserToInvite(email, workspace.ID)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) {
+				app.sessionManager.Put(r.Context(), "flash", "User not found, not verified yet or already added")
+				http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspace.ID), http.StatusSeeOther)
+			} else {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+	}
+
+	app.renderWorkspaceUsers(w, r, workspace, foundUser, "")
+}
+
+func (app *application) renderWorkspaceUsers(w http.ResponseWriter, r *http.Request, workspace models.Workspace, foundUser *models.User, newInviteLink string) {
+	workspaceUsers, err := app.users.GetWorkspaceUsers(workspace.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+	perm, err := app.workspacePermission(userId, workspace.ID, models.ActionManageWorkspace)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	invitations, err := app.invitations.GetWorkspacePending(workspace.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	inviteLinks, err := app.inviteLinks.GetAll(workspace.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Workspace = workspace
+	data.setPermission(perm)
+	data.User = foundUser
+	data.WorkspaceUsers = workspaceUsers
+	data.Invitations = invitations
+	data.InviteLinks = inviteLinks
+	data.NewInviteLink = newInviteLink
+
+	app.render(w, r, http.StatusOK, "workspace_users.html", data)
+}
+
+type addUserForm struct {
+	Email string `form:"email"`
+	Role  string `form:"role"`
+}
+
+func (app *application) workspaceAddUserPost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	var form addUserForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	if form.Role == "" {
+		form.Role = models.RoleEditor
+	}
+
+	if !models.ValidInviteRole(form.Role) {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	user, err := app.users.GetUserToInvite(form.Email, workspaceId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.sessionManager.Put(r.Context(), "flash", "User not found, not verified yet or already added")
+			http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspaceId), http.StatusSeeOther)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	_, err = app.invitations.Insert(workspaceId, user.ID, userId, form.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrDuplicateInvitation):
+			app.sessionManager.Put(r.Context(), "flash", "This user already has a pending invitation")
+			http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspaceId), http.StatusSeeOther)
+		case errors.Is(err, models.ErrWorkspaceLimit):
+			app.sessionManager.Put(r.Context(), "flash", "This user can't join any more workspaces")
+			http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspaceId), http.StatusSeeOther)
+		default:
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Invitation sent")
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspaceId), http.StatusSeeOther)
+}
+
+func (app *application) workspaceInvitationDeletePost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	invitationId, err := strconv.Atoi(r.PathValue("invitationId"))
+	if err != nil || invitationId < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = app.invitations.Delete(invitationId, workspaceId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspaceId), http.StatusSeeOther)
+}
+
+type inviteLinkForm struct {
+	Email string `form:"email"`
+	Role  string `form:"role"`
+}
+
+func (app *application) workspaceInviteLinkCreatePost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	var form inviteLinkForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.Email = strings.TrimSpace(form.Email)
+	if form.Role == "" {
+		form.Role = models.RoleEditor
+	}
+
+	if !models.ValidInviteRole(form.Role) {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	if form.Email != "" && !validator.Matches(form.Email, validator.EmailRX) {
+		app.sessionManager.Put(r.Context(), "flash", "Invite links can only be limited to a valid email address")
+		http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspaceId), http.StatusSeeOther)
+		return
+	}
+
+	workspace, err := app.workspaces.Get(workspaceId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	token, err := app.inviteLinks.Insert(workspaceId, userId, form.Email, form.Role)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.renderWorkspaceUsers(w, r, workspace, nil, fmt.Sprintf("%s/invite/%s", app.baseURL, token))
+}
+
+func (app *application) workspaceInviteLinkDeletePost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	linkId, err := strconv.Atoi(r.PathValue("linkId"))
+	if err != nil || linkId < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = app.inviteLinks.Delete(linkId, workspaceId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspaceId), http.StatusSeeOther)
+}
+
+// The token is kept in the session so signing up or logging in can finish joining the workspace.
+func (app *application) inviteLinkView(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+
+	link, err := app.inviteLinks.GetByToken(token)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	if !app.isAuthenticated(r) {
+		app.sessionManager.Put(r.Context(), "inviteToken", token)
+		app.sessionManager.Put(r.Context(), "flash", fmt.Sprintf("Log in or register to join %s", link.WorkspaceTitle))
+		http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+		return
+	}
+
+	// Joining only happens on the POST below, so loading the link from another site can't add anyone.
+	data := app.newTemplateData(r)
+	data.InviteLink = &link
+	data.Form = verifyForm{Token: token}
+
+	app.render(w, r, http.StatusOK, "invite_link.html", data)
+}
+
+func (app *application) inviteLinkJoinPost(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	workspaceId, err := app.redeemInviteLink(r, token, userId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if workspaceId == 0 {
+		http.Redirect(w, r, "/workspace/view", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d", workspaceId), http.StatusSeeOther)
+}
+
+func (app *application) invitationsView(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	invitations, err := app.invitations.GetPending(userId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Invitations = invitations
+
+	app.render(w, r, http.StatusOK, "invitations.html", data)
+}
+
+func (app *application) invitationAcceptPost(w http.ResponseWriter, r *http.Request) {
+	invitationId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || invitationId < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	workspaceId, err := app.invitations.Accept(invitationId, userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			http.NotFound(w, r)
+		case errors.Is(err, models.ErrWorkspaceLimit):
+			app.sessionManager.Put(r.Context(), "flash", "You have reached the maximum number of workspaces you can be a member of")
+			http.Redirect(w, r, "/invitations", http.StatusSeeOther)
+		default:
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d", workspaceId), http.StatusSeeOther)
+}
+
+func (app *application) invitationDeclinePost(w http.ResponseWriter, r *http.Request) {
+	invitationId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || invitationId < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	err = app.invitations.Decline(invitationId, userId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	http.Redirect(w, r, "/invitations", http.StatusSeeOther)
+}
+
+func (app *application) workspaceRemoveUserPost(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(r.PathValue("userId"))
+	if err != nil || userId < 1 {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	actorId := r.Context().Value(userIDContextKey).(int)
+
+	row, err := app.users.RemoveUserFromWorkspace(workspaceId, userId, actorId)
+	if err != nil || row < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspaceId), http.StatusSeeOther)
+}
+
+func (app *application) workspaceLeavePost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	workspace, err := app.workspaces.Get(workspaceId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	reassigned, err := app.users.LeaveWorkspace(workspaceId, userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			http.NotFound(w, r)
+		case errors.Is(err, models.ErrOwnerRole):
+			app.sessionManager.Put(r.Context(), "flash", "Transfer ownership to another member before leaving this workspace")
+			http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d", workspaceId), http.StatusSeeOther)
+		default:
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	flash := fmt.Sprintf("You left %s", workspace.Title)
+	if reassigned > 0 {
+		flash += fmt.Sprintf(", %d of your tasks were reassigned to the owner", reassigned)
+	}
+	app.sessionManager.Put(r.Context(), "flash", flash)
+
+	http.Redirect(w, r, "/workspace/view", http.StatusSeeOther)
+}
+
+type workspaceRoleForm struct {
+	Role string `form:"role"`
+}
+
+func (app *application) workspaceUserRolePost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	memberId, err := strconv.Atoi(r.PathValue("userId"))
+	if err != nil || memberId < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	var form workspaceRoleForm
+
+	err = app.decodePostForm(r, &form)
+	if err != nil || !models.ValidInviteRole(form.Role) {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.users.UpdateWorkspaceRole(workspaceId, memberId, form.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			http.NotFound(w, r)
+		case errors.Is(err, models.ErrOwnerRole):
+			app.sessionManager.Put(r.Context(), "flash", "The owner's role can only change by transferring ownership")
+			http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspaceId), http.StatusSeeOther)
+		default:
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Role updated")
+
+	userId := r.Context().Value(userIDContextKey).(int)
+	if memberId == userId {
+		http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d", workspaceId), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspaceId), http.StatusSeeOther)
+}
+
+func (app *application) workspaceTransferOwnershipPost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	newOwnerId, err := strconv.Atoi(r.PathValue("userId"))
+	if err != nil || newOwnerId < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+	if newOwnerId == userId {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.users.TransferOwnership(workspaceId, userId, newOwnerId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			http.NotFound(w, r)
+		case errors.Is(err, models.ErrWorkspaceLimit):
+			app.sessionManager.Put(r.Context(), "flash", "This user can't own any more workspaces")
+			http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspaceId), http.StatusSeeOther)
+		default:
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Ownership transferred, you are now an admin of this workspace")
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspaceId), http.StatusSeeOther)
+}
+
+type statusForm struct {
+	ID                  int    `form:"-"`
+	Name                string `form:"name"`
+	IsDone              bool   `form:"is_done"`
+	AllowedTo           []int  `form:"allowed_to"`
+	validator.Validator `form:"-"`
+}
+
+func (form *statusForm) validate() {
+	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Name, 50), "name", "This field cannot be more than 50 characters long")
+}
+
+func (app *application) renderWorkspaceStatuses(w http.ResponseWriter, r *http.Request, status int, form statusForm) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	workspace, err := app.workspaces.Get(workspaceId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	statuses, err := app.statuses.GetAll(workspaceId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Workspace = workspace
+	data.Statuses = statuses
+	data.Form = form
+
+	app.render(w, r, status, "workspace_statuses.html", data)
+}
+
+func (app *application) getWorkspaceStatus(r *http.Request) (models.Status, error) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	statusId, err := strconv.Atoi(r.PathValue("statusId"))
+	if err != nil || statusId < 1 {
+		return models.Status{}, models.ErrNoRecord
+	}
+
+	status, err := app.statuses.Get(statusId)
+	if err != nil {
+		return models.Status{}, err
+	}
+
+	if status.WorkspaceId != workspaceId {
+		return models.Status{}, models.ErrNoRecord
+	}
+
+	return status, nil
+}
+
+func (app *application) workspaceStatuses(w http.ResponseWriter, r *http.Request) {
+	app.renderWorkspaceStatuses(w, r, http.StatusOK, statusForm{})
+}
+
+func (app *application) workspaceStatusCreatePost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	var form statusForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.validate()
+
+	if form.Valid() {
+		_, err = app.statuses.Insert(workspaceId, form.Name, form.IsDone)
+		if err == nil {
+			app.sessionManager.Put(r.Context(), "flash", "Status successfully created!")
+			http.Redirect(w, r, fmt.Sprintf("/workspace/%d/statuses/edit", workspaceId), http.StatusSeeOther)
+			return
+		}
+
+		if !errors.Is(err, models.ErrDuplicateStatus) {
+			app.serverError(w, r, err)
+			return
+		}
+
+		form.AddFieldError("name", "This workspace already has a status with that name")
+	}
+
+	app.renderWorkspaceStatuses(w, r, http.StatusUnprocessableEntity, form)
+}
+
+func (app *application) workspaceStatusUpdatePost(w http.ResponseWriter, r *http.Request) {
+	status, err := app.getWorkspaceStatus(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	var form statusForm
+
+	err = app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.ID = status.ID
+	form.validate()
+
+	if form.Valid() {
+		err = app.statuses.Update(status.ID, form.Name, form.IsDone, form.AllowedTo)
+		if err == nil {
+			app.sessionManager.Put(r.Context(), "flash", "Status successfully updated!")
+			http.Redirect(w, r, fmt.Sprintf("/workspace/%d/statuses/edit", status.WorkspaceId), http.StatusSeeOther)
+			return
+		}
+
+		if !errors.Is(err, models.ErrDuplicateStatus) {
+			app.serverError(w, r, err)
+			return
+		}
+
+		form.AddFieldError("name", "This workspace already has a status with that name")
+	}
+
+	app.renderWorkspaceStatuses(w, r, http.StatusUnprocessableEntity, form)
+}
+
+func (app *application) workspaceStatusMovePost(w http.ResponseWriter, r *http.Request) {
+	status, err := app.getWorkspaceStatus(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.statuses.Move(status.ID, r.PostForm.Get("direction") == "up")
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/%d/statuses/edit", status.WorkspaceId), http.StatusSeeOther)
+}
+
+func (app *application) workspaceStatusDeletePost(w http.ResponseWriter, r *http.Request) {
+	status, err := app.getWorkspaceStatus(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	err = app.statuses.Delete(status.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrStatusInUse) {
+			app.sessionManager.Put(r.Context(), "flash", "Statuses used by tasks, or the last remaining status, can't be deleted")
+			http.Redirect(w, r, fmt.Sprintf("/workspace/%d/statuses/edit", status.WorkspaceId), http.StatusSeeOther)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Status successfully deleted!")
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/%d/statuses/edit", status.WorkspaceId), http.StatusSeeOther)
+}
+
+type labelForm struct {
+	ID                  int    `form:"-"`
+	Name                string `form:"name"`
+	Color               string `form:"color"`
+	validator.Validator `form:"-"`
+}
+
+func (form *labelForm) validate() {
+	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Name, 50), "name", "This field cannot be more than 50 characters long")
+	form.CheckField(validator.PermittedValue(form.Color, models.LabelColors...), "color", "This field must be one of the listed colors")
+}
+
+func (app *application) renderWorkspaceLabels(w http.ResponseWriter, r *http.Request, status int, form labelForm) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	workspace, err := app.workspaces.Get(workspaceId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	labels, err := app.labels.GetAll(workspaceId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Workspace = workspace
+	data.Labels = labels
+	data.Form = form
+
+	app.render(w, r, status, "workspace_labels.html", data)
+}
+
+func (app *application) getWorkspaceLabel(r *http.Request) (models.Label, error) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	labelId, err := strconv.Atoi(r.PathValue("labelId"))
+	if err != nil || labelId < 1 {
+		return models.Label{}, models.ErrNoRecord
+	}
+
+	label, err := app.labels.Get(labelId)
+	if err != nil {
+		return models.Label{}, err
+	}
+
+	if label.WorkspaceId != workspaceId {
+		return models.Label{}, models.ErrNoRecord
+	}
+
+	return label, nil
+}
+
+func (app *application) workspaceLabels(w http.ResponseWriter, r *http.Request) {
+	app.renderWorkspaceLabels(w, r, http.StatusOK, labelForm{Color: models.LabelColors[0]})
+}
+
+func (app *application) workspaceLabelCreatePost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	var form labelForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.validate()
+
+	if form.Valid() {
+		_, err = app.labels.Insert(workspaceId, form.Name, form.Color)
+		if err == nil {
+			app.sessionManager.Put(r.Context(), "flash", "Label successfully created!")
+			http.Redirect(w, r, fmt.Sprintf("/workspace/%d/labels/edit", workspaceId), http.StatusSeeOther)
+			return
+		}
+
+		if !errors.Is(err, models.ErrDuplicateLabel) {
+			app.serverError(w, r, err)
+			return
+		}
+
+		form.AddFieldError("name", "This workspace already has a label with that name")
+	}
+
+	app.renderWorkspaceLabels(w, r, http.StatusUnprocessableEntity, form)
+}
+
+func (app *application) workspaceLabelUpdatePost(w http.ResponseWriter, r *http.Request) {
+	label, err := app.getWorkspaceLabel(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	var form labelForm
+
+	err = app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.ID = label.ID
+	form.validate()
+
+	if form.Valid() {
+		err = app.labels.Update(label.ID, form.Name, form.Color)
+		if err == nil {
+			app.sessionManager.Put(r.Context(), "flash", "Label successfully updated!")
+			http.Redirect(w, r, fmt.Sprintf("/workspace/%d/labels/edit", label.WorkspaceId), http.StatusSeeOther)
+			return
+		}
+
+		if !errors.Is(err, models.ErrDuplicateLabel) {
+			app.serverError(w, r, err)
+			return
+		}
+
+		form.AddFieldError("name", "This workspace already has a label with that name")
+	}
+
+	app.renderWorkspaceLabels(w, r, http.StatusUnprocessableEntity, form)
+}
+
+func (app *application) workspaceLabelDeletePost(w http.ResponseWriter, r *http.Request) {
+	label, err := app.getWorkspaceLabel(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	err = app.labels.Delete(label.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Label successfully deleted!")
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/%d/labels/edit", label.WorkspaceId), http.StatusSeeOther)
+}
+
+func (app *application) workspaceDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	workspaceId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || workspaceId < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	row, err := app.workspaces.Delete(workspaceId)
+	if err != nil || row < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Workspace moved to the trash")
+
+	http.Redirect(w, r, "/workspace/view", http.StatusSeeOther)
+}
+
+func (app *application) workspaceRestorePost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || workspaceId < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	err = app.workspaces.Restore(workspaceId, userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			http.NotFound(w, r)
+		case errors.Is(err, models.ErrWorkspaceLimit):
+			app.sessionManager.Put(r.Context(), "flash", "You have reached the maximum number of workspaces you can own")
+			http.Redirect(w, r, "/workspace/view", http.StatusSeeOther)
+		default:
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Workspace restored")
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d", workspaceId), http.StatusSeeOther)
+}
+
+func (app *application) workspacePurgePost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || workspaceId < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	err = app.workspaces.Purge(workspaceId, userId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Workspace permanently deleted")
+
+	http.Redirect(w, r, "/workspace/view", http.StatusSeeOther)
+}
+
+func (app *application) workspaceArchivePost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	err := app.workspaces.SetArchived(workspaceId, true)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Workspace archived")
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d", workspaceId), http.StatusSeeOther)
+}
+
+func (app *application) workspaceUnarchivePost(w http.ResponseWriter, r *http.Request) {
+	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+
+	err := app.workspaces.SetArchived(workspaceId, false)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Workspace unarchived")
+
+	http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d", workspaceId), http.StatusSeeOther)
+}
+
+type userCreateForm struct {
+	FirstName           string `form:"firstName"`
+	LastName            string `form:"lastName"`
+	Email               string `form:"email"`
+	Password            string `form:"password"`
+	validator.Validator `form:"-"`
+}
+
+func (app *application) userSignUp(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+
+	data.Form = userCreateForm{}
+
+	app.render(w, r, http.StatusOK, "register.html", data)
+}
+
+func (app *application) userSignUpPost(w http.ResponseWriter, r *http.Request) {
+	var form userCreateForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.FirstName), "firstName", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.FirstName, 20), "firstName", "This field cannot be more than 20 characters long")
+	form.CheckField(validator.NotBlank(form.LastName), "lastName", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.LastName, 20), "lastName", "This field cannot be more than 20 characters long")
+	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be blank")
+	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "This field must be a valid email address")
+	form.CheckField(validator.NotBlank(form.Password), "password", "This field cannot be blank")
+	form.CheckField(validator.MinChars(form.Password, 6), "password", "This field cannot be less than 6 characters long")
+
+	if !form.Valid() {
+		data := app.newTemplateData(r)
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "register.html", data)
+		return
+	}
+
+	wait, err := app.authEvents.SignupThrottle(clientIP(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if wait > 0 {
+		err = app.auditAuthEvent(r, models.EventSignupThrottled, form.Email)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		form.AddNonFieldError(fmt.Sprintf("Too many accounts have been created from your network. Please try again in %s.", humanDuration(wait)))
+
+		data := app.newTemplateData(r)
+		data.Form = form
+
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		app.render(w, r, http.StatusTooManyRequests, "register.html", data)
+		return
+	}
+
+	id, err := app.users.Insert(form.FirstName, form.LastName, form.Email, form.Password)
+	if err != nil {
+		if errors.Is(err, models.ErrDuplicateEmail) {
+			err = app.auditAuthEvent(r, models.EventSignupFailed, form.Email)
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+
+			form.AddFieldError("email", "Email address is already in use")
+
+			data := app.newTemplateData(r)
+			data.Form = form
+			app.render(w, r, http.StatusUnprocessableEntity, "register.html", data)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	err = app.auditAuthEvent(r, models.EventSignup, form.Email)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.sendVerificationEmail(&models.User{ID: id, FirstName: form.FirstName, Email: form.Email})
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// A pending invite link stays in the session and is offered again on the first login after verification.
+	if app.sessionManager.Exists(r.Context(), "inviteToken") {
+		app.sessionManager.Put(r.Context(), "flash", "User registered successfully! Check your email to verify your address, then log in to open your new workspace.")
+	} else {
+		app.sessionManager.Put(r.Context(), "flash", "User registered successfully! Check your email to verify your address before logging in.")
+	}
+
+	http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+}
+
+type userLoginForm struct {
+	Email               string `form:"email"`
+	Password            string `form:"password"`
+	Unverified          bool   `form:"-"`
+	validator.Validator `form:"-"`
+}
+
+func (app *application) userLogin(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+	data.Form = userLoginForm{}
+
+	app.render(w, r, http.StatusOK, "login.html", data)
+}
+
+func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
+	var form userLoginForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be blank")
+	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "This field must be a valid email address")
+	form.CheckField(validator.NotBlank(form.Password), "password", "This field cannot be blank")
+
+	if !form.Valid() {
+		data := app.newTemplateData(r)
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "login.html", data)
+		return
+	}
+
+	attemptId, wait, locked, err := app.authEvents.BeginLoginAttempt(models.EventLoginFailed, form.Email, clientIP(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if wait > 0 {
+		err = app.resolveLoginAttempt(r, attemptId, models.EventLoginThrottled, form.Email)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		app.loginThrottled(w, r, form, wait, locked)
+		return
+	}
+
+	id, err := app.users.Authenticate(form.Email, form.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidCredentials):
+			err = app.resolveLoginAttempt(r, attemptId, models.EventLoginFailed, form.Email)
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+
+			wait, locked, err := app.authEvents.LoginThrottle(form.Email, clientIP(r))
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+
+			if locked {
+				err = app.auditAuthEvent(r, models.EventAccountLocked, form.Email)
+				if err != nil {
+					app.serverError(w, r, err)
+					return
+				}
+
+				app.loginThrottled(w, r, form, wait, locked)
+				return
+			}
+
+			form.AddNonFieldError("Email or password is incorrect")
+		case errors.Is(err, models.ErrUnverifiedEmail):
+			err = app.resolveLoginAttempt(r, attemptId, models.EventLoginUnverified, form.Email)
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+
+			form.AddNonFieldError("Please verify your email address before logging in")
+			form.Unverified = true
+		default:
+			app.serverError(w, r, err)
+			return
+		}
+
+		data := app.newTemplateData(r)
+		data.Form = form
+
+		app.render(w, r, http.StatusUnprocessableEntity, "login.html", data)
+		return
+	}
+
+	enabled, err := app.twoFactor.Enabled(id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if enabled {
+		err = app.resolveLoginAttempt(r, attemptId, models.EventTwoFactorNeeded, form.Email)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		err = app.sessionManager.RenewToken(r.Context())
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		app.sessionManager.Put(r.Context(), "twoFactorUserID", id)
+		app.sessionManager.Put(r.Context(), "twoFactorEmail", form.Email)
+		app.sessionManager.Put(r.Context(), "twoFactorExpires", time.Now().Add(twoFactorLoginTimeout).Unix())
+		app.sessionManager.Put(r.Context(), "twoFactorAttempts", 0)
+
+		http.Redirect(w, r, "/user/login/2fa", http.StatusSeeOther)
+		return
+	}
+
+	err = app.resolveLoginAttempt(r, attemptId, models.EventLoginSucceeded, form.Email)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.completeLogin(w, r, id)
+}
+
+func (app *application) loginThrottled(w http.ResponseWriter, r *http.Request, form userLoginForm, wait time.Duration, locked bool) {
+	if locked {
+		form.AddNonFieldError(fmt.Sprintf("Too many failed login attempts. This account is locked for the next %s.", humanDuration(wait)))
+	} else {
+		form.AddNonFieldError(fmt.Sprintf("Too many failed login attempts. Please wait %s before trying again.", humanDuration(wait)))
+	}
+
+	data := app.newTemplateData(r)
+	data.Form = form
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	app.render(w, r, http.StatusTooManyRequests, "login.html", data)
+}
+
+const (
+	twoFactorLoginTimeout     = 5 * time.Minute
+	twoFactorLoginMaxAttempts = 5
+)
+
+type twoFactorLoginForm struct {
+	Code                string `form:"code"`
+	validator.Validator `form:"-"`
+}
+
+func (app *application) userLoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if app.pendingTwoFactorUserID(r) == 0 {
+		http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Form = twoFactorLoginForm{}
+
+	app.render(w, r, http.StatusOK, "login_2fa.html", data)
+}
+
+func (app *application) userLoginTwoFactorPost(w http.ResponseWriter, r *http.Request) {
+	id := app.pendingTwoFactorUserID(r)
+	if id == 0 {
+		app.sessionManager.Put(r.Context(), "flash", "Your login has expired. Please log in again.")
+		http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+		return
+	}
+
+	var form twoFactorLoginForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Code), "code", "This field cannot be blank")
+
+	if !form.Valid() {
+		data := app.newTemplateData(r)
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "login_2fa.html", data)
+		return
+	}
+
+	email := app.sessionManager.GetString(r.Context(), "twoFactorEmail")
+
+	attemptId, wait, locked, err := app.authEvents.BeginLoginAttempt(models.EventTwoFactorFailed, email, clientIP(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if wait > 0 {
+		err = app.resolveLoginAttempt(r, attemptId, models.EventLoginThrottled, email)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		if locked {
+			app.clearTwoFactorLogin(r)
+			app.sessionManager.Put(r.Context(), "flash", fmt.Sprintf("Too many failed login attempts. This account is locked for the next %s.", humanDuration(wait)))
+			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+			return
+		}
+
+		form.AddNonFieldError(fmt.Sprintf("Too many failed login attempts. Please wait %s before trying again.", humanDuration(wait)))
+
+		data := app.newTemplateData(r)
+		form.Code = ""
+		data.Form = form
+
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		app.render(w, r, http.StatusTooManyRequests, "login_2fa.html", data)
+		return
+	}
+
+	err = app.twoFactor.Authenticate(id, form.Code)
+	if err == nil {
+		err = app.resolveLoginAttempt(r, attemptId, models.EventLoginSucceeded, email)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		app.clearTwoFactorLogin(r)
+		app.completeLogin(w, r, id)
+		return
+	}
+
+	if !errors.Is(err, models.ErrInvalidCode) {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.resolveLoginAttempt(r, attemptId, models.EventTwoFactorFailed, email)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	attempts := app.sessionManager.GetInt(r.Context(), "twoFactorAttempts") + 1
+	if attempts >= twoFactorLoginMaxAttempts {
+		app.clearTwoFactorLogin(r)
+		app.sessionManager.Put(r.Context(), "flash", "Too many invalid codes. Please log in again.")
+		http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "twoFactorAttempts", attempts)
+	form.AddNonFieldError("Invalid authentication code")
+
+	data := app.newTemplateData(r)
+	form.Code = ""
+	data.Form = form
+	app.render(w, r, http.StatusUnprocessableEntity, "login_2fa.html", data)
+}
+
+// completeLogin starts the authenticated session once every login step has passed.
+func (app *application) completeLogin(w http.ResponseWriter, r *http.Request, id int) {
+	err := app.sessionManager.RenewToken(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "authenticatedUserID", id)
+
+	if token := app.sessionManager.PopString(r.Context(), "inviteToken"); token != "" {
+		http.Redirect(w, r, "/invite/"+url.PathEscape(token), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/workspace/view", http.StatusSeeOther)
+}
+
+type verifyForm struct {
+	Token               string `form:"token"`
 	validator.Validator `form:"-"`
 }
 
-func (app *application) workspaceCreate(w http.ResponseWriter, r *http.Request) {
+func (app *application) userVerify(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.NotFound(w, r)
+		return
+	}
+
 	data := app.newTemplateData(r)
+	data.Form = verifyForm{Token: token}
 
-	data.Form = workspaceCreateForm{}
+	app.render(w, r, http.StatusOK, "verify.html", data)
+}
 
-	app.render(w, r, http.StatusOK, "workspace_create.html", data)
+func (app *application) userVerifyPost(w http.ResponseWriter, r *http.Request) {
+	var form verifyForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.users.Verify(form.Token)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidToken) {
+			app.sessionManager.Put(r.Context(), "flash", "This verification link is invalid or has expired")
+			http.Redirect(w, r, "/user/verify/resend", http.StatusSeeOther)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Your email address has been verified. Please log in.")
+
+	http.Redirect(w, r, "/user/login", http.StatusSeeOther)
 }
 
-func (app *application) workspaceCreatePost(w http.ResponseWriter, r *http.Request) {
-	var form workspaceCreateForm
+func (app *application) userVerifyResend(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+	data.Form = userEmailForm{}
+
+	app.render(w, r, http.StatusOK, "verify_resend.html", data)
+}
+
+func (app *application) userVerifyResendPost(w http.ResponseWriter, r *http.Request) {
+	var form userEmailForm
 
 	err := app.decodePostForm(r, &form)
 	if err != nil {
@@ -349,109 +2731,388 @@ func (app *application) workspaceCreatePost(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank")
-	form.CheckField(validator.MaxChars(form.Title, 100), "title", "This field cannot be more than 100 characters long")
-	form.CheckField(validator.NotBlank(form.Description), "description", "This field cannot be blank")
-	form.CheckField(validator.MaxChars(form.Description, 255), "description", "This field cannot be more than 255 characters long")
+	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be blank")
+	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "This field must be a valid email address")
 
 	if !form.Valid() {
 		data := app.newTemplateData(r)
 		data.Form = form
-		app.render(w, r, http.StatusUnprocessableEntity, "workspace_create.html", data)
+		app.render(w, r, http.StatusUnprocessableEntity, "verify_resend.html", data)
 		return
 	}
 
-	userId := r.Context().Value(userIDContextKey).(int)
+	user, err := app.users.GetByEmail(form.Email)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		app.serverError(w, r, err)
+		return
+	}
 
-	id, err := app.workspaces.Insert(form.Title, form.Description, userId)
+	if err == nil && !user.Verified {
+		err = app.sendVerificationEmail(user)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "If that address is waiting for verification, we've sent it a new link")
+
+	http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+}
+
+type userEmailForm struct {
+	Email               string `form:"email"`
+	validator.Validator `form:"-"`
+}
+
+func (app *application) userPasswordForgot(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+	data.Form = userEmailForm{}
+
+	app.render(w, r, http.StatusOK, "password_forgot.html", data)
+}
+
+func (app *application) userPasswordForgotPost(w http.ResponseWriter, r *http.Request) {
+	var form userEmailForm
+
+	err := app.decodePostForm(r, &form)
 	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be blank")
+	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "This field must be a valid email address")
+
+	if !form.Valid() {
+		data := app.newTemplateData(r)
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "password_forgot.html", data)
+		return
+	}
+
+	// Unknown addresses get the same answer so the form can't be used to probe for accounts.
+	user, err := app.users.GetByEmail(form.Email)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
 		app.serverError(w, r, err)
 		return
 	}
 
-	app.sessionManager.Put(r.Context(), "flash", "Workspace successfully created!")
+	if err == nil {
+		token, err := app.userTokens.Insert(user.ID, models.ScopePasswordReset, models.PasswordResetTTL)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
 
-	http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d", id), http.StatusSeeOther)
+		emailData := map[string]any{
+			"FirstName": user.FirstName,
+			"URL":       fmt.Sprintf("%s/user/password/reset?token=%s", app.baseURL, token),
+			"Expires":   humanDuration(models.PasswordResetTTL),
+		}
+
+		err = app.mailer.Send(user.Email, "password_reset.tmpl", emailData)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "If an account uses that email, we've sent it a link to reset the password")
+
+	http.Redirect(w, r, "/user/login", http.StatusSeeOther)
 }
 
-func (app *application) workspaceView(w http.ResponseWriter, r *http.Request) {
-	id, _ := strconv.Atoi(r.PathValue("id"))
+type passwordResetForm struct {
+	Token               string `form:"token"`
+	Password            string `form:"password"`
+	ConfirmPassword     string `form:"confirm_password"`
+	validator.Validator `form:"-"`
+}
 
-	workspace, err := app.workspaces.Get(id)
+func (app *application) userPasswordReset(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Form = passwordResetForm{Token: token}
+
+	app.render(w, r, http.StatusOK, "password_reset.html", data)
+}
+
+func (app *application) userPasswordResetPost(w http.ResponseWriter, r *http.Request) {
+	var form passwordResetForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Password), "password", "This field cannot be blank")
+	form.CheckField(validator.MinChars(form.Password, 6), "password", "This field cannot be less than 6 characters long")
+	form.CheckField(form.Password == form.ConfirmPassword, "confirm_password", "Passwords don't match")
+
+	if form.Valid() {
+		userId, err := app.users.ResetPassword(form.Token, form.Password)
+		if err == nil {
+			err = app.endOtherSessions(r, userId)
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+
+			app.sessionManager.Put(r.Context(), "flash", "Your password has been reset. Please log in.")
+			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+			return
+		}
+
+		if !errors.Is(err, models.ErrInvalidToken) {
+			app.serverError(w, r, err)
+			return
+		}
+
+		form.AddNonFieldError("This reset link is invalid or has expired")
+	}
+
+	data := app.newTemplateData(r)
+	form.Password, form.ConfirmPassword = "", ""
+	data.Form = form
+	app.render(w, r, http.StatusUnprocessableEntity, "password_reset.html", data)
+}
+
+func (app *application) userLogoutPost(w http.ResponseWriter, r *http.Request) {
+	err := app.sessionManager.RenewToken(r.Context())
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
+	app.sessionManager.Remove(r.Context(), "authenticatedUserID")
+
+	app.sessionManager.Put(r.Context(), "flash", "You've been logged out successfully!")
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+type accountProfileForm struct {
+	FirstName           string `form:"firstName"`
+	LastName            string `form:"lastName"`
+	validator.Validator `form:"-"`
+}
+
+type accountEmailForm struct {
+	Email               string `form:"email"`
+	Password            string `form:"email_password"`
+	validator.Validator `form:"-"`
+}
+
+type accountPasswordForm struct {
+	CurrentPassword     string `form:"current_password"`
+	NewPassword         string `form:"new_password"`
+	ConfirmPassword     string `form:"confirm_password"`
+	validator.Validator `form:"-"`
+}
+
+type accountSettingsForms struct {
+	Profile  accountProfileForm
+	Email    accountEmailForm
+	Password accountPasswordForm
+}
+
+// accountSettingsView renders the settings page with form replacing the blank or prefilled
+// version of the same form, so errors show up next to the form that was submitted.
+func (app *application) accountSettingsView(w http.ResponseWriter, r *http.Request, status int, form any) {
 	userId := r.Context().Value(userIDContextKey).(int)
-	userIsAdmin, err := app.workspaces.ValidateAdmin(userId, id)
+
+	user, err := app.users.GetUser(userId)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	workspaceUsers, err := app.users.GetWorkspaceUsers(id)
+	forms := accountSettingsForms{
+		Profile: accountProfileForm{FirstName: user.FirstName, LastName: user.LastName},
+	}
+
+	switch f := form.(type) {
+	case accountProfileForm:
+		forms.Profile = f
+	case accountEmailForm:
+		f.Password = ""
+		forms.Email = f
+	case accountPasswordForm:
+		f.CurrentPassword, f.NewPassword, f.ConfirmPassword = "", "", ""
+		forms.Password = f
+	}
+
+	data := app.newTemplateData(r)
+	data.User = user
+	data.Form = forms
+
+	app.render(w, r, status, "account_settings.html", data)
+}
+
+func (app *application) accountSettings(w http.ResponseWriter, r *http.Request) {
+	app.accountSettingsView(w, r, http.StatusOK, nil)
+}
+
+func (app *application) accountProfilePost(w http.ResponseWriter, r *http.Request) {
+	var form accountProfileForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.FirstName), "firstName", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.FirstName, 20), "firstName", "This field cannot be more than 20 characters long")
+	form.CheckField(validator.NotBlank(form.LastName), "lastName", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.LastName, 20), "lastName", "This field cannot be more than 20 characters long")
+
+	if !form.Valid() {
+		app.accountSettingsView(w, r, http.StatusUnprocessableEntity, form)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	err = app.users.UpdateProfile(userId, form.FirstName, form.LastName)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	data := app.newTemplateData(r)
-	data.Workspace = workspace
-	data.IsAdmin = userIsAdmin
-	data.WorkspaceUsers = workspaceUsers
+	app.sessionManager.Put(r.Context(), "flash", "Profile updated successfully!")
 
-	app.render(w, r, http.StatusOK, "workspace_view.html", data)
+	http.Redirect(w, r, "/account/settings", http.StatusSeeOther)
 }
 
-func (app *application) workspaceViewAll(w http.ResponseWriter, r *http.Request) {
+func (app *application) accountEmailPost(w http.ResponseWriter, r *http.Request) {
+	var form accountEmailForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be blank")
+	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "This field must be a valid email address")
+	form.CheckField(validator.NotBlank(form.Password), "email_password", "This field cannot be blank")
+
+	if !form.Valid() {
+		app.accountSettingsView(w, r, http.StatusUnprocessableEntity, form)
+		return
+	}
+
 	userId := r.Context().Value(userIDContextKey).(int)
-	ownWorkspaces, err := app.workspaces.GetAll(userId, "ADMIN")
+
+	err = app.users.RequestEmailChange(userId, form.Password, form.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidCredentials):
+			form.AddFieldError("email_password", "Password is incorrect")
+		case errors.Is(err, models.ErrDuplicateEmail):
+			form.AddFieldError("email", "Email address is already in use")
+		default:
+			app.serverError(w, r, err)
+			return
+		}
+
+		app.accountSettingsView(w, r, http.StatusUnprocessableEntity, form)
+		return
+	}
+
+	user, err := app.users.GetUser(userId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	token, err := app.userTokens.Insert(userId, models.ScopeEmailChange, models.EmailChangeTTL)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	canCreateWorkspaces := len(ownWorkspaces) < 6
+	emailData := map[string]any{
+		"FirstName": user.FirstName,
+		"URL":       fmt.Sprintf("%s/account/email/confirm?token=%s", app.baseURL, token),
+		"Expires":   humanDuration(models.EmailChangeTTL),
+	}
 
-	invitedWorkspaces, err := app.workspaces.GetAll(userId, "MEMBER")
+	err = app.mailer.Send(form.Email, "email_change.tmpl", emailData)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
+	app.sessionManager.Put(r.Context(), "flash", "We've sent a confirmation link to your new email address. Your email changes once you open it.")
+
+	http.Redirect(w, r, "/account/settings", http.StatusSeeOther)
+}
+
+func (app *application) accountEmailConfirm(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.NotFound(w, r)
+		return
+	}
+
 	data := app.newTemplateData(r)
-	data.OwnedWorkspaces = ownWorkspaces
-	data.InvitedWorkspaces = invitedWorkspaces
-	data.WorkspaceLimit = canCreateWorkspaces
+	data.Form = verifyForm{Token: token}
 
-	app.render(w, r, http.StatusOK, "workspaces_view.html", data)
+	app.render(w, r, http.StatusOK, "email_confirm.html", data)
 }
 
-func (app *application) workspaceUpdate(w http.ResponseWriter, r *http.Request) {
-	id, _ := strconv.Atoi(r.PathValue("id"))
+func (app *application) accountEmailConfirmPost(w http.ResponseWriter, r *http.Request) {
+	var form verifyForm
 
-	workspace, err := app.workspaces.Get(id)
+	err := app.decodePostForm(r, &form)
 	if err != nil {
-		app.serverError(w, r, err)
+		app.clientError(w, http.StatusBadRequest)
 		return
 	}
 
-	data := app.newTemplateData(r)
+	redirectURL := "/user/login"
+	if app.isAuthenticated(r) {
+		redirectURL = "/account/settings"
+	}
 
-	data.Form = workspaceCreateForm{
-		ID:          &workspace.ID,
-		Title:       workspace.Title,
-		Description: workspace.Description,
+	err = app.users.ConfirmEmailChange(form.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidToken):
+			app.sessionManager.Put(r.Context(), "flash", "This confirmation link is invalid or has expired")
+		case errors.Is(err, models.ErrDuplicateEmail):
+			app.sessionManager.Put(r.Context(), "flash", "That email address is already in use")
+		default:
+			app.serverError(w, r, err)
+			return
+		}
+
+		http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+		return
 	}
 
-	app.render(w, r, http.StatusOK, "workspace_update.html", data)
-}
+	err = app.sessionManager.RenewToken(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
 
-func (app *application) workspaceUpdatePost(w http.ResponseWriter, r *http.Request) {
-	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+	app.sessionManager.Put(r.Context(), "flash", "Your email address has been updated")
 
-	var form workspaceCreateForm
+	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+}
+
+func (app *application) accountPasswordPost(w http.ResponseWriter, r *http.Request) {
+	var form accountPasswordForm
 
 	err := app.decodePostForm(r, &form)
 	if err != nil {
@@ -459,166 +3120,168 @@ func (app *application) workspaceUpdatePost(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank")
-	form.CheckField(validator.MaxChars(form.Title, 100), "title", "This field cannot be more than 100 characters long")
-	form.CheckField(validator.NotBlank(form.Description), "description", "This field cannot be blank")
-	form.CheckField(validator.MaxChars(form.Description, 255), "description", "This field cannot be more than 255 characters long")
+	form.CheckField(validator.NotBlank(form.CurrentPassword), "current_password", "This field cannot be blank")
+	form.CheckField(validator.NotBlank(form.NewPassword), "new_password", "This field cannot be blank")
+	form.CheckField(validator.MinChars(form.NewPassword, 6), "new_password", "This field cannot be less than 6 characters long")
+	form.CheckField(form.NewPassword == form.ConfirmPassword, "confirm_password", "Passwords don't match")
 
 	if !form.Valid() {
-		data := app.newTemplateData(r)
-		form.ID = &workspaceId
-		data.Form = form
-		app.render(w, r, http.StatusUnprocessableEntity, "workspace_update.html", data)
+		app.accountSettingsView(w, r, http.StatusUnprocessableEntity, form)
 		return
 	}
 
-	err = app.workspaces.Update(workspaceId, form.Title, form.Description)
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	err = app.users.ChangePassword(userId, form.CurrentPassword, form.NewPassword)
 	if err != nil {
-		app.serverError(w, r, err)
+		if errors.Is(err, models.ErrInvalidCredentials) {
+			form.AddFieldError("current_password", "Current password is incorrect")
+			app.accountSettingsView(w, r, http.StatusUnprocessableEntity, form)
+		} else {
+			app.serverError(w, r, err)
+		}
 		return
 	}
 
-	http.Redirect(w, r, fmt.Sprintf("/workspace/view/%d", workspaceId), http.StatusSeeOther)
-}
-
-func (app *application) workspaceAddUser(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(r.PathValue("id"))
-	if err != nil || id < 1 {
-		http.NotFound(w, r)
+	err = app.sessionManager.RenewToken(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
 		return
 	}
 
-	workspace, err := app.workspaces.Get(id)
+	err = app.endOtherSessions(r, userId)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	email := r.URL.Query().Get("email")
-	var foundUser *models.User
-
-	if email != "" {
-		foundUser, err = app.users.GetUserToInvite(email, workspace.ID)
-		if err != nil {
-			if errors.Is(err, models.ErrNoRecord) {
-				app.sessionManager.Put(r.Context(), "flash", "User not found or already added")
-				http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspace.ID), http.StatusSeeOther)
-			} else {
-				app.serverError(w, r, err)
-			}
-			return
-		}
+	app.sessionManager.Put(r.Context(), "flash", "Your password has been changed")
 
-		totalWorkspaces, err := app.users.GetWorkspacesAsMemberCount(email)
-		if err != nil {
-			app.serverError(w, r, err)
-			return
-		}
+	http.Redirect(w, r, "/account/settings", http.StatusSeeOther)
+}
 
-		canBeInvitedToWorkspaces := totalWorkspaces < 6
+type twoFactorForm struct {
+	Code                string `form:"code"`
+	Password            string `form:"password"`
+	validator.Validator `form:"-"`
+}
 
-		if !canBeInvitedToWorkspaces {
-			app.sessionManager.Put(r.Context(), "flash", "User exceeds workspace limit")
-			http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspace.ID), http.StatusSeeOther)
-		}
-	}
+func (app *application) twoFactorView(w http.ResponseWriter, r *http.Request, status int, form twoFactorForm, recoveryCodes []string) {
+	userId := r.Context().Value(userIDContextKey).(int)
 
-	workspaceUsers, err := app.users.GetWorkspaceUsers(id)
-	if err != nil {
+	twoFactor, err := app.twoFactor.Get(userId)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
 		app.serverError(w, r, err)
 		return
 	}
 
 	data := app.newTemplateData(r)
-	data.Workspace = workspace
-	data.User = foundUser
-	data.WorkspaceUsers = workspaceUsers
+	data.TwoFactor = twoFactor
+	data.RecoveryCodes = recoveryCodes
+	form.Code, form.Password = "", ""
+	data.Form = form
 
-	app.render(w, r, http.StatusOK, "workspace_users.html", data)
+	app.render(w, r, status, "account_2fa.html", data)
 }
 
-type addUserForm struct {
-	UserID int `form:"userID"`
+func (app *application) accountTwoFactor(w http.ResponseWriter, r *http.Request) {
+	app.twoFactorView(w, r, http.StatusOK, twoFactorForm{}, nil)
 }
 
-func (app *application) workspaceAddUserPost(w http.ResponseWriter, r *http.Request) {
-	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+func (app *application) accountTwoFactorSetupPost(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(userIDContextKey).(int)
 
-	var form addUserForm
+	_, err := app.twoFactor.Setup(userId)
+	if err != nil {
+		if errors.Is(err, models.ErrTwoFactorEnabled) {
+			app.sessionManager.Put(r.Context(), "flash", "Two-factor authentication is already enabled")
+		} else {
+			app.serverError(w, r, err)
+			return
+		}
+	}
 
-	err := app.decodePostForm(r, &form)
+	http.Redirect(w, r, "/account/2fa", http.StatusSeeOther)
+}
+
+func (app *application) accountTwoFactorQR(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	twoFactor, err := app.twoFactor.Get(userId)
 	if err != nil {
-		app.clientError(w, http.StatusBadRequest)
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
 		return
 	}
 
-	userId := r.Context().Value(userIDContextKey).(int)
-	if userId == form.UserID {
-		http.Error(w, "Bad Request", http.StatusBadRequest)
+	if twoFactor.Enabled {
+		http.NotFound(w, r)
 		return
 	}
 
-	err = app.users.AddUserToWorkspace(form.UserID, workspaceId)
+	user, err := app.users.GetUser(userId)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspaceId), http.StatusSeeOther)
+	png, err := qrcode.Encode(totp.URL("Task Manager", user.Email, twoFactor.Secret), qrcode.Medium, 256)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(png)
 }
 
-func (app *application) workspaceRemoveUserPost(w http.ResponseWriter, r *http.Request) {
-	userId, err := strconv.Atoi(r.PathValue("userId"))
-	workspaceId, _ := strconv.Atoi(r.PathValue("id"))
+func (app *application) accountTwoFactorEnablePost(w http.ResponseWriter, r *http.Request) {
+	var form twoFactorForm
 
-	row, err := app.users.RemoveUserFromWorkspace(workspaceId, userId)
-	if err != nil || row < 1 {
-		http.NotFound(w, r)
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
 		return
 	}
 
-	http.Redirect(w, r, fmt.Sprintf("/workspace/%d/user/add", workspaceId), http.StatusSeeOther)
-}
+	form.CheckField(validator.NotBlank(form.Code), "code", "This field cannot be blank")
 
-func (app *application) workspaceDelete(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if !form.Valid() {
+		app.twoFactorView(w, r, http.StatusUnprocessableEntity, form, nil)
 		return
 	}
 
-	workspaceId, err := strconv.Atoi(r.PathValue("id"))
-	if err != nil || workspaceId < 1 {
-		http.NotFound(w, r)
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	recoveryCodes, err := app.twoFactor.Enable(userId, form.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidCode):
+			form.AddFieldError("code", "Invalid authentication code")
+			app.twoFactorView(w, r, http.StatusUnprocessableEntity, form, nil)
+		case errors.Is(err, models.ErrNoRecord):
+			http.NotFound(w, r)
+		default:
+			app.serverError(w, r, err)
+		}
 		return
 	}
 
-	row, err := app.workspaces.Delete(workspaceId)
-	if err != nil || row < 1 {
-		http.NotFound(w, r)
+	err = app.sessionManager.RenewToken(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
 		return
 	}
 
-	http.Redirect(w, r, "/workspace/view", http.StatusSeeOther)
-}
-
-type userCreateForm struct {
-	FirstName           string `form:"firstName"`
-	LastName            string `form:"lastName"`
-	Email               string `form:"email"`
-	Password            string `form:"password"`
-	validator.Validator `form:"-"`
-}
-
-func (app *application) userSignUp(w http.ResponseWriter, r *http.Request) {
-	data := app.newTemplateData(r)
-
-	data.Form = userCreateForm{}
-
-	app.render(w, r, http.StatusOK, "register.html", data)
+	app.twoFactorView(w, r, http.StatusOK, twoFactorForm{}, recoveryCodes)
 }
 
-func (app *application) userSignUpPost(w http.ResponseWriter, r *http.Request) {
-	var form userCreateForm
+func (app *application) accountTwoFactorDisablePost(w http.ResponseWriter, r *http.Request) {
+	var form twoFactorForm
 
 	err := app.decodePostForm(r, &form)
 	if err != nil {
@@ -626,48 +3289,71 @@ func (app *application) userSignUpPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	form.CheckField(validator.NotBlank(form.FirstName), "firstName", "This field cannot be blank")
-	form.CheckField(validator.MaxChars(form.FirstName, 20), "firstName", "This field cannot be more than 20 characters long")
-	form.CheckField(validator.NotBlank(form.LastName), "lastName", "This field cannot be blank")
-	form.CheckField(validator.MaxChars(form.LastName, 20), "lastName", "This field cannot be more than 20 characters long")
-	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be blank")
-	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "This field must be a valid email address")
 	form.CheckField(validator.NotBlank(form.Password), "password", "This field cannot be blank")
-	form.CheckField(validator.MinChars(form.Password, 6), "password", "This field cannot be less than 6 characters long")
 
 	if !form.Valid() {
-		data := app.newTemplateData(r)
-		data.Form = form
-		app.render(w, r, http.StatusUnprocessableEntity, "register.html", data)
+		app.twoFactorView(w, r, http.StatusUnprocessableEntity, form, nil)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	err = app.users.CheckPassword(userId, form.Password)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCredentials) {
+			form.AddFieldError("password", "Password is incorrect")
+			app.twoFactorView(w, r, http.StatusUnprocessableEntity, form, nil)
+		} else {
+			app.serverError(w, r, err)
+		}
 		return
 	}
 
-	err = app.users.Insert(form.FirstName, form.LastName, form.Email, form.Password)
+	err = app.twoFactor.Disable(userId)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	app.sessionManager.Put(r.Context(), "flash", "User registered successfully!")
+	err = app.sessionManager.RenewToken(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
 
-	http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+	app.sessionManager.Put(r.Context(), "flash", "Two-factor authentication has been disabled")
+
+	http.Redirect(w, r, "/account/2fa", http.StatusSeeOther)
 }
 
-type userLoginForm struct {
-	Email               string `form:"email"`
-	Password            string `form:"password"`
+type apiTokenForm struct {
+	Name                string `form:"name"`
 	validator.Validator `form:"-"`
 }
 
-func (app *application) userLogin(w http.ResponseWriter, r *http.Request) {
+func (app *application) apiTokenView(w http.ResponseWriter, r *http.Request, status int, form apiTokenForm, newToken string) {
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	tokens, err := app.apiTokens.GetAll(userId)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
 	data := app.newTemplateData(r)
-	data.Form = userLoginForm{}
+	data.APITokens = tokens
+	data.NewAPIToken = newToken
+	data.Form = form
 
-	app.render(w, r, http.StatusOK, "login.html", data)
+	app.render(w, r, status, "api_tokens.html", data)
 }
 
-func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
-	var form userLoginForm
+func (app *application) accountTokens(w http.ResponseWriter, r *http.Request) {
+	app.apiTokenView(w, r, http.StatusOK, apiTokenForm{}, "")
+}
+
+func (app *application) accountTokenCreatePost(w http.ResponseWriter, r *http.Request) {
+	var form apiTokenForm
 
 	err := app.decodePostForm(r, &form)
 	if err != nil {
@@ -675,53 +3361,46 @@ func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be blank")
-	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "This field must be a valid email address")
-	form.CheckField(validator.NotBlank(form.Password), "password", "This field cannot be blank")
+	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Name, 100), "name", "This field cannot be more than 100 characters long")
 
 	if !form.Valid() {
-		data := app.newTemplateData(r)
-		data.Form = form
-		app.render(w, r, http.StatusUnprocessableEntity, "login.html", data)
+		app.apiTokenView(w, r, http.StatusUnprocessableEntity, form, "")
 		return
 	}
 
-	id, err := app.users.Authenticate(form.Email, form.Password)
-	if err != nil {
-		if errors.Is(err, models.ErrInvalidCredentials) {
-			form.AddNonFieldError("Email or password is incorrect")
-
-			data := app.newTemplateData(r)
-			data.Form = form
-
-			app.render(w, r, http.StatusUnprocessableEntity, "login.html", data)
-		} else {
-			app.serverError(w, r, err)
-		}
-		return
-	}
+	userId := r.Context().Value(userIDContextKey).(int)
 
-	err = app.sessionManager.RenewToken(r.Context())
+	plaintext, err := app.apiTokens.Insert(userId, form.Name)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	app.sessionManager.Put(r.Context(), "authenticatedUserID", id)
-
-	http.Redirect(w, r, "/workspace/view", http.StatusSeeOther)
+	app.apiTokenView(w, r, http.StatusOK, apiTokenForm{}, plaintext)
 }
 
-func (app *application) userLogoutPost(w http.ResponseWriter, r *http.Request) {
-	err := app.sessionManager.RenewToken(r.Context())
+func (app *application) accountTokenDeletePost(w http.ResponseWriter, r *http.Request) {
+	tokenId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || tokenId < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	userId := r.Context().Value(userIDContextKey).(int)
+
+	row, err := app.apiTokens.Delete(tokenId, userId)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	app.sessionManager.Remove(r.Context(), "authenticatedUserID")
+	if row < 1 {
+		http.NotFound(w, r)
+		return
+	}
 
-	app.sessionManager.Put(r.Context(), "flash", "You've been logged out successfully!")
+	app.sessionManager.Put(r.Context(), "flash", "Token successfully revoked!")
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+	http.Redirect(w, r, "/account/tokens", http.StatusSeeOther)
 }
@@ -3,9 +3,16 @@ package main
 import (
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/andres085/task_manager/internal/assert"
+	"github.com/andres085/task_manager/internal/mailer"
+	"github.com/andres085/task_manager/internal/models"
+	"github.com/andres085/task_manager/internal/models/mocks"
 )
 
 func TestPing(t *testing.T) {
@@ -66,6 +73,57 @@ func TestTaskViewAll(t *testing.T) {
 		assert.StringContains(t, body, wantTitle)
 		assert.StringContains(t, body, firstTestTaskTitle)
 		assert.StringContains(t, body, secondTestTaskTitle)
+		assert.StringContains(t, body, "Overdue")
+		assert.StringContains(t, body, `<span class="badge label-badge label-red">Bug</span>`)
+	})
+
+	t.Run("Label filter", func(t *testing.T) {
+		ts.loginUser(t)
+
+		code, _, body := ts.get(t, "/workspace/view/1/tasks?label=1&label=x&label=1")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "Labels (1)")
+		assert.StringContains(t, body, "&due=&label=1&sort=asc")
+	})
+
+	t.Run("Assigned and watching filters", func(t *testing.T) {
+		ts.loginUser(t)
+
+		code, _, body := ts.get(t, "/workspace/view/1/tasks?assigned=true&watching=true")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "&assigned=true&watching=true&sort=asc")
+		assert.StringContains(t, body, "Pete Peterson")
+	})
+}
+
+func TestTaskDashboard(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/tasks")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	t.Run("Authenticated", func(t *testing.T) {
+		ts.loginUser(t)
+
+		code, _, body := ts.get(t, "/tasks?title=Test&priority=LOW&status=To+Do&limit=5")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "My Tasks")
+		assert.StringContains(t, body, `<a href="/workspace/view/1/tasks" class="text-decoration-none">First Workspace</a>`)
+		assert.StringContains(t, body, `<a href="/workspace/view/7/tasks" class="text-decoration-none">Viewer Workspace</a>`)
+		assert.StringContains(t, body, "First Test Task")
+		assert.StringContains(t, body, "Viewer Test Task")
+		assert.StringContains(t, body, `<option value="In Progress" >In Progress</option>`)
+		assert.StringContains(t, body, "?limit=5&page=1&title=Test&priority=LOW&status=To%20Do")
 	})
 }
 
@@ -98,6 +156,55 @@ func TestTaskView(t *testing.T) {
 			wantTitle:   "First Test Task",
 			wantContent: "First Test Task Content",
 		},
+		{
+			name:        "History",
+			urlPath:     "/task/view/1",
+			wantCode:    http.StatusOK,
+			wantTitle:   "changed status from <em>To Do</em> to <em>Completed</em>",
+			wantContent: "Test McTester",
+		},
+		{
+			name:        "Subtasks and checklist",
+			urlPath:     "/task/view/1",
+			wantCode:    http.StatusOK,
+			wantTitle:   `<a href="/task/view/5">Sub Test Task</a>`,
+			wantContent: "Write the docs",
+		},
+		{
+			name:        "Progress",
+			urlPath:     "/task/view/1",
+			wantCode:    http.StatusOK,
+			wantTitle:   `<progress class="task-progress" value="1" max="3">33%</progress>`,
+			wantContent: "/workspace/1/task/create?parent=1",
+		},
+		{
+			name:        "Dependencies",
+			urlPath:     "/task/view/1",
+			wantCode:    http.StatusOK,
+			wantTitle:   `<form action="/task/1/dependencies/2/delete" method="POST" class="m-0">`,
+			wantContent: "No tasks are waiting on this one...",
+		},
+		{
+			name:        "Comments",
+			urlPath:     "/task/view/1",
+			wantCode:    http.StatusOK,
+			wantTitle:   "First Test Comment",
+			wantContent: "Second Test Comment",
+		},
+		{
+			name:        "Assignees and watchers",
+			urlPath:     "/task/view/1",
+			wantCode:    http.StatusOK,
+			wantTitle:   "Pete Peterson",
+			wantContent: "Stop Watching",
+		},
+		{
+			name:        "Viewer",
+			urlPath:     "/task/view/6",
+			wantCode:    http.StatusOK,
+			wantTitle:   "Viewer Test Task",
+			wantContent: "Viewer Test Task Content",
+		},
 		{
 			name:     "Non-existent ID",
 			urlPath:  "/task/view/2",
@@ -174,6 +281,32 @@ func TestTaskCreate(t *testing.T) {
 		assert.Equal(t, code, http.StatusOK)
 		assert.StringContains(t, body, wantTitle)
 	})
+
+	t.Run("Subtask", func(t *testing.T) {
+		ts.loginUser(t)
+
+		code, _, body := ts.get(t, "/workspace/1/task/create?parent=1")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, `Subtask of <a href="/task/view/1">First Test Task</a>`)
+		assert.StringContains(t, body, `<input type="hidden" name="parent_id" value="1">`)
+	})
+
+	t.Run("Subtask of another workspace", func(t *testing.T) {
+		ts.loginUser(t)
+
+		code, _, _ := ts.get(t, "/workspace/1/task/create?parent=4")
+
+		assert.Equal(t, code, http.StatusNotFound)
+	})
+
+	t.Run("Subtask of unknown task", func(t *testing.T) {
+		ts.loginUser(t)
+
+		code, _, _ := ts.get(t, "/workspace/1/task/create?parent=99")
+
+		assert.Equal(t, code, http.StatusNotFound)
+	})
 }
 
 func TestTaskCreatePost(t *testing.T) {
@@ -192,6 +325,12 @@ func TestTaskCreatePost(t *testing.T) {
 		title     string
 		content   string
 		priority  string
+		dueDate   string
+		dueTime   string
+		parentId  string
+		assignee  string
+		label     string
+		workspace string
 		csrfToken string
 		wantCode  int
 	}{
@@ -203,6 +342,70 @@ func TestTaskCreatePost(t *testing.T) {
 			csrfToken: validCSRFToken,
 			wantCode:  http.StatusSeeOther,
 		},
+		{
+			name:      "Valid Submission with due date",
+			title:     "Test Task",
+			content:   "Test Content",
+			priority:  "LOW",
+			dueDate:   "2024-03-17",
+			dueTime:   "15:30",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusSeeOther,
+		},
+		{
+			name:      "Valid subtask",
+			title:     "Test Task",
+			content:   "Test Content",
+			priority:  "LOW",
+			parentId:  "1",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusSeeOther,
+		},
+		{
+			name:      "Invalid parent",
+			title:     "Test Task",
+			content:   "Test Content",
+			priority:  "LOW",
+			parentId:  "7",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Assignee outside the workspace",
+			title:     "Test Task",
+			content:   "Test Content",
+			priority:  "LOW",
+			assignee:  "3",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Label outside the workspace",
+			title:     "Test Task",
+			content:   "Test Content",
+			priority:  "LOW",
+			label:     "9",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid due date",
+			title:     "Test Task",
+			content:   "Test Content",
+			priority:  "LOW",
+			dueDate:   "17/03/2024",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Due time without due date",
+			title:     "Test Task",
+			content:   "Test Content",
+			priority:  "LOW",
+			dueTime:   "15:30",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
 		{
 			name:      "Invalid Submission without Title",
 			title:     "",
@@ -211,6 +414,15 @@ func TestTaskCreatePost(t *testing.T) {
 			csrfToken: validCSRFToken,
 			wantCode:  http.StatusUnprocessableEntity,
 		},
+		{
+			name:      "Viewer",
+			title:     "Test Task",
+			content:   "Test Content",
+			priority:  "LOW",
+			workspace: "7",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusForbidden,
+		},
 		{
 			name:      "Invalid Submission without Content",
 			title:     "Test Task",
@@ -227,6 +439,20 @@ func TestTaskCreatePost(t *testing.T) {
 			form.Add("title", tt.title)
 			form.Add("content", tt.content)
 			form.Add("priority", tt.priority)
+			form.Add("due_date", tt.dueDate)
+			form.Add("due_time", tt.dueTime)
+			form.Add("parent_id", tt.parentId)
+			if tt.assignee == "" {
+				tt.assignee = "1"
+			}
+			form.Add("assignee_ids", tt.assignee)
+			if tt.label != "" {
+				form.Add("label_ids", tt.label)
+			}
+			if tt.workspace == "" {
+				tt.workspace = "1"
+			}
+			form.Add("workspace_id", tt.workspace)
 			form.Add("csrf_token", tt.csrfToken)
 
 			code, _, _ := ts.postForm(t, "/task/create", form)
@@ -272,6 +498,15 @@ func TestTaskUpdate(t *testing.T) {
 
 		assert.Equal(t, code, http.StatusOK)
 		assert.StringContains(t, body, titleInput)
+		assert.StringContains(t, body, `id="label-1"
+      checked`)
+	})
+
+	t.Run("Viewer", func(t *testing.T) {
+		ts.loginUser(t)
+		code, _, _ := ts.get(t, "/task/update/6")
+
+		assert.Equal(t, code, http.StatusForbidden)
 	})
 }
 
@@ -291,6 +526,8 @@ func TestTaskUpdatePost(t *testing.T) {
 		title     string
 		content   string
 		priority  string
+		status    string
+		parentId  string
 		csrfToken string
 		wantCode  int
 		urlPath   string
@@ -304,6 +541,36 @@ func TestTaskUpdatePost(t *testing.T) {
 			wantCode:  http.StatusSeeOther,
 			urlPath:   "/task/update/1",
 		},
+		{
+			name:      "Unchanged parent",
+			title:     "Test Task",
+			content:   "Test Content",
+			priority:  "LOW",
+			parentId:  "0",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusSeeOther,
+			urlPath:   "/task/update/1",
+		},
+		{
+			name:      "Changed parent",
+			title:     "Test Task",
+			content:   "Test Content",
+			priority:  "LOW",
+			parentId:  "5",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+			urlPath:   "/task/update/1",
+		},
+		{
+			name:      "Status outside the workflow",
+			title:     "Test Task",
+			content:   "Test Content",
+			priority:  "LOW",
+			status:    "Blocked",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+			urlPath:   "/task/update/1",
+		},
 		{
 			name:      "Invalid Submission without Title",
 			title:     "",
@@ -348,6 +615,12 @@ func TestTaskUpdatePost(t *testing.T) {
 			form.Add("title", tt.title)
 			form.Add("content", tt.content)
 			form.Add("priority", tt.priority)
+			form.Add("status", tt.status)
+			form.Add("assignee_ids", "1")
+			form.Add("assignee_ids", "2")
+			if tt.parentId != "" {
+				form.Add("parent_id", tt.parentId)
+			}
 			form.Add("csrf_token", tt.csrfToken)
 
 			code, _, _ := ts.postForm(t, tt.urlPath, form)
@@ -395,7 +668,7 @@ func TestTaskDeletePost(t *testing.T) {
 	assert.Equal(t, code, http.StatusSeeOther)
 }
 
-func TestWorkspaceViewAll(t *testing.T) {
+func TestCommentCreatePost(t *testing.T) {
 	app := newTestApplication(t)
 
 	ts := newTestServer(t, app.routes())
@@ -403,107 +676,213 @@ func TestWorkspaceViewAll(t *testing.T) {
 
 	ts.loginUser(t)
 
-	code, _, body := ts.get(t, "/workspace/view")
-	wantTitle := "Workspaces View"
-	firstTestTaskTitle := "First Workspace"
-	secondTestTaskTitle := "Second Workspace"
+	_, _, body := ts.get(t, "/user/login")
+	validCSRFToken := extractCSRFToken(t, body)
 
-	assert.Equal(t, code, http.StatusOK)
-	assert.StringContains(t, body, wantTitle)
-	assert.StringContains(t, body, firstTestTaskTitle)
-	assert.StringContains(t, body, secondTestTaskTitle)
+	tests := []struct {
+		name     string
+		urlPath  string
+		content  string
+		parentId string
+		wantCode int
+	}{
+		{
+			name:     "Valid Submission",
+			urlPath:  "/task/1/comments/create",
+			content:  "Test Comment",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Valid Reply",
+			urlPath:  "/task/1/comments/create",
+			content:  "Test Reply",
+			parentId: "1",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Invalid Submission without Content",
+			urlPath:  "/task/1/comments/create",
+			content:  "",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Non-existent Parent",
+			urlPath:  "/task/1/comments/create",
+			content:  "Test Reply",
+			parentId: "9",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Not a workspace member",
+			urlPath:  "/task/2/comments/create",
+			content:  "Test Comment",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Invalid Task Id",
+			urlPath:  "/task/-1/comments/create",
+			content:  "Test Comment",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("content", tt.content)
+			form.Add("parent_id", tt.parentId)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+		})
+	}
 }
 
-func TestWorkspaceView(t *testing.T) {
+func TestChecklistItemPosts(t *testing.T) {
 	app := newTestApplication(t)
-
 	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
 	defer ts.Close()
 
-	t.Run("Unauthenticated", func(t *testing.T) {
-		code, headers, _ := ts.get(t, "/workspace/view/1")
-
-		assert.Equal(t, code, http.StatusSeeOther)
-		assert.Equal(t, headers.Get("Location"), "/user/login")
-	})
+	_, _, body := ts.get(t, "/user/login")
 
-	ts.loginUser(t)
+	validCSRFToken := extractCSRFToken(t, body)
 
 	tests := []struct {
-		name            string
-		urlPath         string
-		wantCode        int
-		wantTitle       string
-		wantDescription string
+		name         string
+		urlPath      string
+		form         url.Values
+		wantCode     int
+		wantLocation string
 	}{
 		{
-			name:            "Valid ID",
-			urlPath:         "/workspace/view/1",
-			wantCode:        http.StatusOK,
-			wantTitle:       "First Workspace",
-			wantDescription: "First workspace Description",
+			name:         "Create",
+			urlPath:      "/task/1/checklist/create",
+			form:         url.Values{"content": {"Ship it"}},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/task/view/1#checklist",
 		},
 		{
-			name:     "Non-existent ID",
-			urlPath:  "/workspace/view/3",
-			wantCode: http.StatusNotFound,
+			name:         "Create blank",
+			urlPath:      "/task/1/checklist/create",
+			form:         url.Values{"content": {""}},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/task/view/1#checklist",
 		},
 		{
-			name:     "Negative ID",
-			urlPath:  "/workspace/view/-1",
-			wantCode: http.StatusNotFound,
+			name:         "Toggle",
+			urlPath:      "/task/1/checklist/2/toggle",
+			form:         url.Values{},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/task/view/1#checklist",
 		},
 		{
-			name:     "Decimal ID",
-			urlPath:  "/workspace/view/1.23",
+			name:     "Toggle item of another task",
+			urlPath:  "/task/1/checklist/3/toggle",
+			form:     url.Values{},
 			wantCode: http.StatusNotFound,
 		},
 		{
-			name:     "String ID",
-			urlPath:  "/workspace/view/foo",
-			wantCode: http.StatusNotFound,
+			name:         "Delete",
+			urlPath:      "/task/1/checklist/1/delete",
+			form:         url.Values{},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/task/view/1#checklist",
 		},
 		{
-			name:     "Empty ID",
-			urlPath:  "/workspace/view/",
+			name:     "Delete unknown item",
+			urlPath:  "/task/1/checklist/9/delete",
+			form:     url.Values{},
 			wantCode: http.StatusNotFound,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			code, _, body := ts.get(t, tt.urlPath)
+			tt.form.Add("csrf_token", validCSRFToken)
 
-			assert.Equal(t, code, tt.wantCode)
-
-			if tt.wantTitle != "" {
-				assert.StringContains(t, body, tt.wantTitle)
-			}
+			code, headers, _ := ts.postForm(t, tt.urlPath, tt.form)
 
-			if tt.wantDescription != "" {
-				assert.StringContains(t, body, tt.wantDescription)
-			}
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
 		})
 	}
 }
 
-func TestWorkspaceCreate(t *testing.T) {
+func TestDependencyPosts(t *testing.T) {
 	app := newTestApplication(t)
-
 	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
 	defer ts.Close()
 
-	ts.loginUser(t)
+	_, _, body := ts.get(t, "/user/login")
 
-	code, _, body := ts.get(t, "/workspace/create")
-	titleInput := `<input type="text" class="form-control " id="title" name="title"
-        placeholder="Enter workspace title" value="">`
+	validCSRFToken := extractCSRFToken(t, body)
 
-	assert.Equal(t, code, http.StatusOK)
-	assert.StringContains(t, body, titleInput)
+	tests := []struct {
+		name         string
+		urlPath      string
+		form         url.Values
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Create",
+			urlPath:      "/task/1/dependencies/create",
+			form:         url.Values{"blocked_by_id": {"2"}},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/task/view/1#dependencies",
+		},
+		{
+			name:         "Create cycle",
+			urlPath:      "/task/1/dependencies/create",
+			form:         url.Values{"blocked_by_id": {"1"}},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/task/view/1#dependencies",
+		},
+		{
+			name:         "Create with unknown task",
+			urlPath:      "/task/1/dependencies/create",
+			form:         url.Values{"blocked_by_id": {"9"}},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/task/view/1#dependencies",
+		},
+		{
+			name:     "Create on not member task",
+			urlPath:  "/task/2/dependencies/create",
+			form:     url.Values{"blocked_by_id": {"1"}},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:         "Delete",
+			urlPath:      "/task/1/dependencies/2/delete",
+			form:         url.Values{},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/task/view/1#dependencies",
+		},
+		{
+			name:     "Delete unknown dependency",
+			urlPath:  "/task/1/dependencies/9/delete",
+			form:     url.Values{},
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.form.Add("csrf_token", validCSRFToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, tt.form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+		})
+	}
 }
 
-func TestWorkspaceCreatePost(t *testing.T) {
+func TestCommentUpdatePost(t *testing.T) {
 	app := newTestApplication(t)
 
 	ts := newTestServer(t, app.routes())
@@ -515,66 +894,51 @@ func TestWorkspaceCreatePost(t *testing.T) {
 	validCSRFToken := extractCSRFToken(t, body)
 
 	tests := []struct {
-		name        string
-		title       string
-		description string
-		csrfToken   string
-		wantCode    int
+		name     string
+		urlPath  string
+		content  string
+		wantCode int
 	}{
 		{
-			name:        "Valid Submission",
-			title:       "Test Workspace",
-			description: "Test workspace description",
-			csrfToken:   validCSRFToken,
-			wantCode:    http.StatusSeeOther,
+			name:     "Valid Submission",
+			urlPath:  "/task/1/comments/1/update",
+			content:  "Updated Comment",
+			wantCode: http.StatusSeeOther,
 		},
 		{
-			name:        "Invalid Submission without Title",
-			title:       "",
-			description: "Test workspace description",
-			csrfToken:   validCSRFToken,
-			wantCode:    http.StatusUnprocessableEntity,
+			name:     "Invalid Submission without Content",
+			urlPath:  "/task/1/comments/1/update",
+			content:  "",
+			wantCode: http.StatusUnprocessableEntity,
 		},
 		{
-			name:        "Invalid Submission without description",
-			title:       "Test Task",
-			description: "",
-			csrfToken:   validCSRFToken,
-			wantCode:    http.StatusUnprocessableEntity,
+			name:     "Not the comment author",
+			urlPath:  "/task/1/comments/2/update",
+			content:  "Updated Comment",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent Comment",
+			urlPath:  "/task/1/comments/9/update",
+			content:  "Updated Comment",
+			wantCode: http.StatusNotFound,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			form := url.Values{}
-			form.Add("title", tt.title)
-			form.Add("description", tt.description)
-			form.Add("csrf_token", tt.csrfToken)
+			form.Add("content", tt.content)
+			form.Add("csrf_token", validCSRFToken)
 
-			code, _, _ := ts.postForm(t, "/workspace/create", form)
+			code, _, _ := ts.postForm(t, tt.urlPath, form)
 
 			assert.Equal(t, code, tt.wantCode)
 		})
 	}
 }
 
-func TestWorkspaceUpdate(t *testing.T) {
-	app := newTestApplication(t)
-
-	ts := newTestServer(t, app.routes())
-	defer ts.Close()
-
-	ts.loginUser(t)
-
-	code, _, body := ts.get(t, "/workspace/update/1")
-	titleInput := `<input type="text" class="form-control " id="title" name="title"
-        placeholder="Enter workspace title" value="First Workspace">`
-
-	assert.Equal(t, code, http.StatusOK)
-	assert.StringContains(t, body, titleInput)
-}
-
-func TestWorkspaceUpdatePost(t *testing.T) {
+func TestCommentDeletePost(t *testing.T) {
 	app := newTestApplication(t)
 
 	ts := newTestServer(t, app.routes())
@@ -586,92 +950,73 @@ func TestWorkspaceUpdatePost(t *testing.T) {
 	validCSRFToken := extractCSRFToken(t, body)
 
 	tests := []struct {
-		name        string
-		title       string
-		description string
-		csrfToken   string
-		wantCode    int
+		name     string
+		urlPath  string
+		wantCode int
 	}{
 		{
-			name:        "Valid Submission",
-			title:       "Test Workspace",
-			description: "Test workspace description",
-			csrfToken:   validCSRFToken,
-			wantCode:    http.StatusSeeOther,
+			name:     "Own Comment",
+			urlPath:  "/task/1/comments/1/delete",
+			wantCode: http.StatusSeeOther,
 		},
 		{
-			name:        "Invalid Submission without Title",
-			title:       "",
-			description: "Test workspace description",
-			csrfToken:   validCSRFToken,
-			wantCode:    http.StatusUnprocessableEntity,
+			name:     "Admin deletes another user's Comment",
+			urlPath:  "/task/1/comments/2/delete",
+			wantCode: http.StatusSeeOther,
 		},
 		{
-			name:        "Invalid Submission without description",
-			title:       "Test Task",
-			description: "",
-			csrfToken:   validCSRFToken,
-			wantCode:    http.StatusUnprocessableEntity,
+			name:     "Non-existent Comment",
+			urlPath:  "/task/1/comments/9/delete",
+			wantCode: http.StatusNotFound,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			form := url.Values{}
-			form.Add("title", tt.title)
-			form.Add("description", tt.description)
-			form.Add("csrf_token", tt.csrfToken)
+			form.Add("csrf_token", validCSRFToken)
 
-			code, _, _ := ts.postForm(t, "/workspace/update/1", form)
+			code, _, _ := ts.postForm(t, tt.urlPath, form)
 
 			assert.Equal(t, code, tt.wantCode)
 		})
 	}
 }
 
-func TestWorkspaceDeletePost(t *testing.T) {
+func TestWorkspaceViewAll(t *testing.T) {
 	app := newTestApplication(t)
 
 	ts := newTestServer(t, app.routes())
 	defer ts.Close()
 
-	t.Run("Unauthenticated", func(t *testing.T) {
-		_, _, body := ts.get(t, "/user/login")
-		validCSRFToken := extractCSRFToken(t, body)
-
-		form := url.Values{}
-		form.Add("csrf_token", validCSRFToken)
-
-		code, headers, _ := ts.postForm(t, "/workspace/delete/1", form)
-
-		assert.Equal(t, code, http.StatusSeeOther)
-		assert.Equal(t, headers.Get("Location"), "/user/login")
-	})
-
-	t.Run("Authenticated", func(t *testing.T) {
-		ts.loginUser(t)
-
-		_, _, body := ts.get(t, "/user/login")
-		validCSRFToken := extractCSRFToken(t, body)
-
-		form := url.Values{}
-		form.Add("csrf_token", validCSRFToken)
+	ts.loginUser(t)
 
-		code, _, _ := ts.postForm(t, "/workspace/delete/1", form)
+	code, _, body := ts.get(t, "/workspace/view")
+	wantTitle := "Workspaces View"
+	firstTestTaskTitle := "First Workspace"
+	secondTestTaskTitle := "Second Workspace"
 
-		assert.Equal(t, code, http.StatusSeeOther)
-	})
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, wantTitle)
+	assert.StringContains(t, body, firstTestTaskTitle)
+	assert.StringContains(t, body, secondTestTaskTitle)
+	assert.StringContains(t, body, "4 of 6 workspaces left to create")
+	assert.StringContains(t, body, "You can join 1 more of 6 workspaces")
+	assert.StringContains(t, body, "Trashed Workspace")
+	assert.StringNotContains(t, body, "Archived Workspace")
 
+	_, _, body = ts.get(t, "/workspace/view?archived=true")
+	assert.StringContains(t, body, "Archived Workspace")
 }
 
-func TestWorkspaceAddUserView(t *testing.T) {
+func TestWorkspaceView(t *testing.T) {
 	app := newTestApplication(t)
 
 	ts := newTestServer(t, app.routes())
 	defer ts.Close()
 
 	t.Run("Unauthenticated", func(t *testing.T) {
-		code, headers, _ := ts.get(t, "/workspace/1/user/add")
+		code, headers, _ := ts.get(t, "/workspace/view/1")
 
 		assert.Equal(t, code, http.StatusSeeOther)
 		assert.Equal(t, headers.Get("Location"), "/user/login")
@@ -680,51 +1025,105 @@ func TestWorkspaceAddUserView(t *testing.T) {
 	ts.loginUser(t)
 
 	tests := []struct {
-		name      string
-		urlPath   string
-		wantCode  int
-		wantTitle string
+		name            string
+		urlPath         string
+		wantCode        int
+		wantTitle       string
+		wantDescription string
 	}{
 		{
-			name:      "Valid ID",
-			urlPath:   "/workspace/1/user/add",
-			wantCode:  http.StatusOK,
-			wantTitle: "Users in this Workspace",
+			name:            "Valid ID",
+			urlPath:         "/workspace/view/1",
+			wantCode:        http.StatusOK,
+			wantTitle:       "First Workspace",
+			wantDescription: "First workspace Description",
+		},
+		{
+			name:            "Archived workspace",
+			urlPath:         "/workspace/view/11",
+			wantCode:        http.StatusOK,
+			wantTitle:       "Archived Workspace",
+			wantDescription: "This workspace is archived and read-only.",
 		},
 		{
 			name:     "Non-existent ID",
-			urlPath:  "/workspace/5/user/add",
-			wantCode: http.StatusForbidden,
+			urlPath:  "/workspace/view/3",
+			wantCode: http.StatusNotFound,
 		},
 		{
 			name:     "Negative ID",
-			urlPath:  "/workspace/-1/user/add",
+			urlPath:  "/workspace/view/-1",
 			wantCode: http.StatusNotFound,
 		},
 		{
 			name:     "Decimal ID",
-			urlPath:  "/workspace/1.23/user/add",
+			urlPath:  "/workspace/view/1.23",
 			wantCode: http.StatusNotFound,
 		},
 		{
 			name:     "String ID",
-			urlPath:  "/workspace/a/user/add",
+			urlPath:  "/workspace/view/foo",
 			wantCode: http.StatusNotFound,
 		},
 		{
 			name:     "Empty ID",
-			urlPath:  "/workspace/user/add",
+			urlPath:  "/workspace/view/",
 			wantCode: http.StatusNotFound,
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantTitle != "" {
+				assert.StringContains(t, body, tt.wantTitle)
+			}
+
+			if tt.wantDescription != "" {
+				assert.StringContains(t, body, tt.wantDescription)
+			}
+		})
+	}
+}
+
+func TestWorkspaceBoard(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/workspace/view/1/board")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	ts.loginUser(t)
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody []string
+	}{
+		{
+			name:     "Valid ID",
+			urlPath:  "/workspace/view/1/board?title=Test&priority=LOW",
+			wantCode: http.StatusOK,
+			wantBody: []string{"First Workspace Board", `data-status="In Progress"`, `data-task-id="1"`, "Overdue"},
+		},
 		{
-			name:     "User Not Found",
-			urlPath:  "/workspace/1/user/add?email=invalid-user@mail.com",
-			wantCode: http.StatusSeeOther,
+			name:     "Not member workspace",
+			urlPath:  "/workspace/view/2/board",
+			wantCode: http.StatusNotFound,
 		},
 		{
-			name:     "User exceeds workspace limit",
-			urlPath:  "/workspace/1/user/add?email=testmctesterson@mail.com",
-			wantCode: http.StatusSeeOther,
+			name:     "Invalid ID",
+			urlPath:  "/workspace/view/foo/board",
+			wantCode: http.StatusNotFound,
 		},
 	}
 
@@ -734,14 +1133,14 @@ func TestWorkspaceAddUserView(t *testing.T) {
 
 			assert.Equal(t, code, tt.wantCode)
 
-			if tt.wantTitle != "" {
-				assert.StringContains(t, body, tt.wantTitle)
+			for _, want := range tt.wantBody {
+				assert.StringContains(t, body, want)
 			}
 		})
 	}
 }
 
-func TestWorkspaceAddUserPost(t *testing.T) {
+func TestTaskStatusUpdatePost(t *testing.T) {
 	app := newTestApplication(t)
 	ts := newTestServer(t, app.routes())
 	ts.loginUser(t)
@@ -754,299 +1153,2992 @@ func TestWorkspaceAddUserPost(t *testing.T) {
 	tests := []struct {
 		name     string
 		urlPath  string
-		userId   string
+		status   string
 		wantCode int
+		wantBody string
 	}{
 		{
-			name:     "Valid Post",
-			urlPath:  "/workspace/1/user/add",
-			userId:   "2",
-			wantCode: http.StatusSeeOther,
+			name:     "Valid status",
+			urlPath:  "/task/1/status/update",
+			status:   "In Progress",
+			wantCode: http.StatusOK,
+			wantBody: `"task":{"id":1`,
 		},
 		{
-			name:     "Not owned workspace id",
-			urlPath:  "/workspace/2/user/add",
-			userId:   "2",
-			wantCode: http.StatusForbidden,
+			name:     "Status outside the workflow",
+			urlPath:  "/task/1/status/update",
+			status:   "Blocked",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"status":"This status is not part of the workspace workflow"`,
 		},
 		{
-			name:     "Same id as the admin",
-			urlPath:  "/workspace/1/user/add",
-			userId:   "1",
-			wantCode: http.StatusBadRequest,
+			name:     "Disallowed transition",
+			urlPath:  "/task/1/status/update",
+			status:   "Archived",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"status":"The workspace workflow doesn't allow this status change"`,
+		},
+		{
+			name:     "Open subtasks",
+			urlPath:  "/task/1/status/update",
+			status:   "Shipped",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"status":"Every subtask has to be finished before this task can be completed"`,
+		},
+		{
+			name:     "Blocked task",
+			urlPath:  "/task/1/status/update",
+			status:   "Started",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"status":"This task is blocked by tasks that are still open"`,
+		},
+		{
+			name:     "Not member task",
+			urlPath:  "/task/2/status/update",
+			status:   "Completed",
+			wantCode: http.StatusNotFound,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			form := url.Values{}
-			form.Add("userID", tt.userId)
+			form.Add("status", tt.status)
 			form.Add("csrf_token", validCSRFToken)
 
-			code, _, _ := ts.postForm(t, tt.urlPath, form)
+			code, _, body := ts.postForm(t, tt.urlPath, form)
 
 			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
 		})
 	}
 }
 
-func TestWorkspaceRemoveUserPost(t *testing.T) {
+func TestWorkspaceStatuses(t *testing.T) {
 	app := newTestApplication(t)
 	ts := newTestServer(t, app.routes())
 	ts.loginUser(t)
 	defer ts.Close()
 
-	_, _, body := ts.get(t, "/user/login")
-
-	validCSRFToken := extractCSRFToken(t, body)
-
-	t.Run("Removed user and redirect", func(t *testing.T) {
-		form := url.Values{}
-		form.Add("userID", "1")
-		form.Add("csrf_token", validCSRFToken)
-
-		code, _, _ := ts.postForm(t, "/workspace/1/user/remove/1", form)
-
-		assert.Equal(t, code, http.StatusSeeOther)
-	})
-
-	t.Run("Not found user", func(t *testing.T) {
-		form := url.Values{}
-		form.Add("userID", "1")
-		form.Add("csrf_token", validCSRFToken)
-
-		code, _, _ := ts.postForm(t, "/workspace/1/user/remove/2", form)
-
-		assert.Equal(t, code, http.StatusNotFound)
-	})
-}
-
-func TestUserRegisterHandler(t *testing.T) {
-	app := newTestApplication(t)
-
-	ts := newTestServer(t, app.routes())
-	defer ts.Close()
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Admin",
+			urlPath:  "/workspace/1/statuses/edit",
+			wantCode: http.StatusOK,
+			wantBody: `id="transition-2-3" checked`,
+		},
+		{
+			name:     "Not admin",
+			urlPath:  "/workspace/2/statuses/edit",
+			wantCode: http.StatusForbidden,
+		},
+	}
 
-	code, _, body := ts.get(t, "/user/register")
-	formTitle := `<h2 class="mb-4 text-center">Register</h2>`
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
 
-	assert.Equal(t, code, http.StatusOK)
-	assert.StringContains(t, body, formTitle)
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
 }
 
-func TestUserRegisterPost(t *testing.T) {
+func TestWorkspaceStatusPosts(t *testing.T) {
 	app := newTestApplication(t)
-
 	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
 	defer ts.Close()
 
 	_, _, body := ts.get(t, "/user/login")
+
 	validCSRFToken := extractCSRFToken(t, body)
 
 	tests := []struct {
-		name      string
-		firstName string
-		lastName  string
-		email     string
-		password  string
-		csrfToken string
-		wantCode  int
+		name     string
+		urlPath  string
+		form     url.Values
+		wantCode int
+		wantBody string
 	}{
 		{
-			name:      "Valid Submission",
-			firstName: "Test",
-			lastName:  "McTester",
-			email:     "test@mail.com",
-			password:  "pa$$word",
-			csrfToken: validCSRFToken,
-			wantCode:  http.StatusSeeOther,
+			name:     "Create",
+			urlPath:  "/workspace/1/statuses/create",
+			form:     url.Values{"name": {"Review"}},
+			wantCode: http.StatusSeeOther,
 		},
 		{
-			name:      "Invalid submission without Username",
-			firstName: "",
-			lastName:  "McTester",
-			email:     "test@mail.com",
-			password:  "pa$$word",
-			csrfToken: validCSRFToken,
-			wantCode:  http.StatusUnprocessableEntity,
+			name:     "Create duplicate",
+			urlPath:  "/workspace/1/statuses/create",
+			form:     url.Values{"name": {"To Do"}},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This workspace already has a status with that name",
 		},
 		{
-			name:      "Invalid Username",
-			firstName: "TestTestTestTestTestTest",
-			lastName:  "McTester",
-			email:     "test@mail.com",
-			password:  "pa$$word",
-			csrfToken: validCSRFToken,
-			wantCode:  http.StatusUnprocessableEntity,
+			name:     "Create blank",
+			urlPath:  "/workspace/1/statuses/create",
+			form:     url.Values{"name": {""}},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field cannot be blank",
 		},
 		{
-			name:      "Invalid Lastname",
-			firstName: "Test",
-			lastName:  "McTesterMcTesterMcTesterMcTesterMcTesterMcTester",
-			email:     "test@mail.com",
-			password:  "pa$$word",
-			csrfToken: validCSRFToken,
-			wantCode:  http.StatusUnprocessableEntity,
+			name:     "Update",
+			urlPath:  "/workspace/1/statuses/1/update",
+			form:     url.Values{"name": {"Backlog"}, "allowed_to": {"2"}},
+			wantCode: http.StatusSeeOther,
 		},
 		{
-			name:      "Invalid submission without LastName",
-			firstName: "TestTestTestTestTestTest",
-			lastName:  "",
-			email:     "test@mail.com",
-			password:  "pa$$word",
-			csrfToken: validCSRFToken,
-			wantCode:  http.StatusUnprocessableEntity,
+			name:     "Update duplicate",
+			urlPath:  "/workspace/1/statuses/1/update",
+			form:     url.Values{"name": {"In Progress"}},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This workspace already has a status with that name",
 		},
 		{
-			name:      "Invalid Email",
-			firstName: "Test",
-			lastName:  "McTester",
-			email:     "testmail.com",
-			password:  "pa$$word",
-			csrfToken: validCSRFToken,
-			wantCode:  http.StatusUnprocessableEntity,
+			name:     "Update unknown status",
+			urlPath:  "/workspace/1/statuses/9/update",
+			form:     url.Values{"name": {"Backlog"}},
+			wantCode: http.StatusNotFound,
 		},
 		{
-			name:      "Invalid submission without Email",
-			firstName: "Test",
-			lastName:  "McTester",
-			email:     "",
-			password:  "pa$$word",
-			csrfToken: validCSRFToken,
-			wantCode:  http.StatusUnprocessableEntity,
+			name:     "Move",
+			urlPath:  "/workspace/1/statuses/2/move",
+			form:     url.Values{"direction": {"up"}},
+			wantCode: http.StatusSeeOther,
 		},
 		{
-			name:      "Invalid Password",
-			firstName: "Test",
-			lastName:  "McTester",
-			email:     "test@mail.com",
-			password:  "pa$$",
-			csrfToken: validCSRFToken,
-			wantCode:  http.StatusUnprocessableEntity,
+			name:     "Delete",
+			urlPath:  "/workspace/1/statuses/2/delete",
+			form:     url.Values{},
+			wantCode: http.StatusSeeOther,
 		},
 		{
-			name:      "Invalid submission without Password",
-			firstName: "Test",
-			lastName:  "McTester",
-			email:     "test@mail.com",
-			password:  "",
-			csrfToken: validCSRFToken,
-			wantCode:  http.StatusUnprocessableEntity,
+			name:     "Delete in use",
+			urlPath:  "/workspace/1/statuses/1/delete",
+			form:     url.Values{},
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Not admin",
+			urlPath:  "/workspace/2/statuses/create",
+			form:     url.Values{"name": {"Review"}},
+			wantCode: http.StatusForbidden,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			form := url.Values{}
-			form.Add("firstName", tt.firstName)
-			form.Add("lastName", tt.lastName)
-			form.Add("email", tt.email)
-			form.Add("password", tt.password)
-			form.Add("csrf_token", tt.csrfToken)
+			tt.form.Add("csrf_token", validCSRFToken)
 
-			code, _, _ := ts.postForm(t, "/user/register", form)
+			code, _, body := ts.postForm(t, tt.urlPath, tt.form)
 
 			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
 		})
 	}
-
 }
 
-func TestUserLoginHandler(t *testing.T) {
+func TestWorkspaceLabels(t *testing.T) {
 	app := newTestApplication(t)
-
 	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
 	defer ts.Close()
 
-	code, _, body := ts.get(t, "/user/login")
-	formTitle := `<h2 class="mb-4 text-center">Login</h2>`
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Admin",
+			urlPath:  "/workspace/1/labels/edit",
+			wantCode: http.StatusOK,
+			wantBody: `<option value="red" selected>red</option>`,
+		},
+		{
+			name:     "Not admin",
+			urlPath:  "/workspace/2/labels/edit",
+			wantCode: http.StatusForbidden,
+		},
+	}
 
-	assert.Equal(t, code, http.StatusOK)
-	assert.StringContains(t, body, formTitle)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
 }
 
-func TestUserLoginPost(t *testing.T) {
+func TestWorkspaceLabelPosts(t *testing.T) {
 	app := newTestApplication(t)
-
 	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
 	defer ts.Close()
 
 	_, _, body := ts.get(t, "/user/login")
+
 	validCSRFToken := extractCSRFToken(t, body)
 
 	tests := []struct {
-		name      string
-		email     string
-		password  string
-		csrfToken string
-		wantCode  int
+		name     string
+		urlPath  string
+		form     url.Values
+		wantCode int
+		wantBody string
 	}{
 		{
-			name:      "Valid Submission",
-			email:     "alice@example.com",
-			password:  "pa$$word",
-			csrfToken: validCSRFToken,
-			wantCode:  http.StatusSeeOther,
+			name:     "Create",
+			urlPath:  "/workspace/1/labels/create",
+			form:     url.Values{"name": {"Docs"}, "color": {"blue"}},
+			wantCode: http.StatusSeeOther,
 		},
 		{
-			name:      "Invalid Email",
-			email:     "testmail.com",
-			password:  "pa$$word",
-			csrfToken: validCSRFToken,
-			wantCode:  http.StatusUnprocessableEntity,
+			name:     "Create duplicate",
+			urlPath:  "/workspace/1/labels/create",
+			form:     url.Values{"name": {"Bug"}, "color": {"blue"}},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This workspace already has a label with that name",
 		},
 		{
-			name:      "Invalid submission without Email",
-			email:     "",
-			password:  "pa$$word",
-			csrfToken: validCSRFToken,
-			wantCode:  http.StatusUnprocessableEntity,
+			name:     "Create invalid color",
+			urlPath:  "/workspace/1/labels/create",
+			form:     url.Values{"name": {"Docs"}, "color": {"#ff0000"}},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field must be one of the listed colors",
 		},
 		{
-			name:      "Invalid submission without Password",
-			email:     "test@mail.com",
-			password:  "",
-			csrfToken: validCSRFToken,
-			wantCode:  http.StatusUnprocessableEntity,
+			name:     "Update",
+			urlPath:  "/workspace/1/labels/1/update",
+			form:     url.Values{"name": {"Defect"}, "color": {"orange"}},
+			wantCode: http.StatusSeeOther,
 		},
 		{
-			name:      "Invalid user",
-			email:     "test@mail.com",
-			password:  "p4$$word",
-			csrfToken: validCSRFToken,
-			wantCode:  http.StatusUnprocessableEntity,
+			name:     "Update duplicate",
+			urlPath:  "/workspace/1/labels/1/update",
+			form:     url.Values{"name": {"Feature"}, "color": {"red"}},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This workspace already has a label with that name",
+		},
+		{
+			name:     "Update unknown label",
+			urlPath:  "/workspace/1/labels/9/update",
+			form:     url.Values{"name": {"Defect"}, "color": {"red"}},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Delete",
+			urlPath:  "/workspace/1/labels/2/delete",
+			form:     url.Values{},
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Not admin",
+			urlPath:  "/workspace/2/labels/create",
+			form:     url.Values{"name": {"Docs"}, "color": {"blue"}},
+			wantCode: http.StatusForbidden,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			form := url.Values{}
-			form.Add("email", tt.email)
-			form.Add("password", tt.password)
-			form.Add("csrf_token", tt.csrfToken)
+			tt.form.Add("csrf_token", validCSRFToken)
 
-			code, _, _ := ts.postForm(t, "/user/login", form)
+			code, _, body := ts.postForm(t, tt.urlPath, tt.form)
 
 			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
 		})
 	}
 }
 
-func TestUserLogoutPost(t *testing.T) {
+func TestWorkspaceCreate(t *testing.T) {
 	app := newTestApplication(t)
 
 	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
 	ts.loginUser(t)
 
+	code, _, body := ts.get(t, "/workspace/create")
+	titleInput := `<input type="text" class="form-control " id="title" name="title"
+        placeholder="Enter workspace title" value="">`
+
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, titleInput)
+}
+
+func TestWorkspaceCreatePost(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
 	defer ts.Close()
 
+	ts.loginUser(t)
+
 	_, _, body := ts.get(t, "/user/login")
 	validCSRFToken := extractCSRFToken(t, body)
 
-	form := url.Values{}
-	form.Add("csrf_token", validCSRFToken)
-
-	code, _, _ := ts.postForm(t, "/user/logout", form)
-
-	assert.Equal(t, code, http.StatusSeeOther)
+	tests := []struct {
+		name        string
+		title       string
+		description string
+		csrfToken   string
+		wantCode    int
+		wantBody    string
+	}{
+		{
+			name:        "Valid Submission",
+			title:       "Test Workspace",
+			description: "Test workspace description",
+			csrfToken:   validCSRFToken,
+			wantCode:    http.StatusSeeOther,
+		},
+		{
+			name:        "Over the workspace limit",
+			title:       "Over The Limit",
+			description: "Test workspace description",
+			csrfToken:   validCSRFToken,
+			wantCode:    http.StatusUnprocessableEntity,
+			wantBody:    "You have reached the maximum number of workspaces you can own",
+		},
+		{
+			name:        "Invalid Submission without Title",
+			title:       "",
+			description: "Test workspace description",
+			csrfToken:   validCSRFToken,
+			wantCode:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Invalid Submission without description",
+			title:       "Test Task",
+			description: "",
+			csrfToken:   validCSRFToken,
+			wantCode:    http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("title", tt.title)
+			form.Add("description", tt.description)
+			form.Add("csrf_token", tt.csrfToken)
+
+			code, _, body := ts.postForm(t, "/workspace/create", form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestWorkspaceUpdate(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.loginUser(t)
+
+	code, _, body := ts.get(t, "/workspace/update/1")
+	titleInput := `<input type="text" class="form-control " id="title" name="title"
+        placeholder="Enter workspace title" value="First Workspace">`
+
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, titleInput)
+}
+
+func TestWorkspaceUpdatePost(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.loginUser(t)
+
+	_, _, body := ts.get(t, "/user/login")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name        string
+		title       string
+		description string
+		csrfToken   string
+		wantCode    int
+	}{
+		{
+			name:        "Valid Submission",
+			title:       "Test Workspace",
+			description: "Test workspace description",
+			csrfToken:   validCSRFToken,
+			wantCode:    http.StatusSeeOther,
+		},
+		{
+			name:        "Invalid Submission without Title",
+			title:       "",
+			description: "Test workspace description",
+			csrfToken:   validCSRFToken,
+			wantCode:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Invalid Submission without description",
+			title:       "Test Task",
+			description: "",
+			csrfToken:   validCSRFToken,
+			wantCode:    http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("title", tt.title)
+			form.Add("description", tt.description)
+			form.Add("csrf_token", tt.csrfToken)
+
+			code, _, _ := ts.postForm(t, "/workspace/update/1", form)
+
+			assert.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestWorkspaceDeletePost(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, _, body := ts.get(t, "/user/login")
+		validCSRFToken := extractCSRFToken(t, body)
+
+		form := url.Values{}
+		form.Add("csrf_token", validCSRFToken)
+
+		code, headers, _ := ts.postForm(t, "/workspace/delete/1", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	t.Run("Authenticated", func(t *testing.T) {
+		ts.loginUser(t)
+
+		_, _, body := ts.get(t, "/user/login")
+		validCSRFToken := extractCSRFToken(t, body)
+
+		form := url.Values{}
+		form.Add("csrf_token", validCSRFToken)
+
+		code, _, _ := ts.postForm(t, "/workspace/delete/1", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+	})
+
+	t.Run("Viewer", func(t *testing.T) {
+		ts.loginUser(t)
+
+		_, _, body := ts.get(t, "/user/login")
+		validCSRFToken := extractCSRFToken(t, body)
+
+		form := url.Values{}
+		form.Add("csrf_token", validCSRFToken)
+
+		code, _, _ := ts.postForm(t, "/workspace/delete/7", form)
+
+		assert.Equal(t, code, http.StatusForbidden)
+	})
+}
+
+func TestWorkspaceAddUserView(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/workspace/1/user/add")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	ts.loginUser(t)
+
+	tests := []struct {
+		name      string
+		urlPath   string
+		wantCode  int
+		wantTitle string
+	}{
+		{
+			name:      "Valid ID",
+			urlPath:   "/workspace/1/user/add",
+			wantCode:  http.StatusOK,
+			wantTitle: "Users in this Workspace",
+		},
+		{
+			name:      "Owner actions",
+			urlPath:   "/workspace/1/user/add",
+			wantCode:  http.StatusOK,
+			wantTitle: `<form action="/workspace/1/user/transfer/2" method="POST">`,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/workspace/5/user/add",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Negative ID",
+			urlPath:  "/workspace/-1/user/add",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Decimal ID",
+			urlPath:  "/workspace/1.23/user/add",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "String ID",
+			urlPath:  "/workspace/a/user/add",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Empty ID",
+			urlPath:  "/workspace/user/add",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "User Not Found",
+			urlPath:  "/workspace/1/user/add?email=invalid-user@mail.com",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:      "Found user",
+			urlPath:   "/workspace/1/user/add?email=testmctesterson@mail.com",
+			wantCode:  http.StatusOK,
+			wantTitle: "Send Invitation",
+		},
+		{
+			name:      "Pending invitations",
+			urlPath:   "/workspace/1/user/add",
+			wantCode:  http.StatusOK,
+			wantTitle: `<form action="/workspace/1/invitations/3/delete" method="POST">`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantTitle != "" {
+				assert.StringContains(t, body, tt.wantTitle)
+			}
+		})
+	}
+}
+
+func TestWorkspaceAddUserPost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name      string
+		urlPath   string
+		email     string
+		role      string
+		wantCode  int
+		wantFlash string
+	}{
+		{
+			name:      "Valid Post",
+			urlPath:   "/workspace/1/user/add",
+			email:     "new@mail.com",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "Invitation sent",
+		},
+		{
+			name:      "Already invited",
+			urlPath:   "/workspace/1/user/add",
+			email:     "pete@mail.com",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "This user already has a pending invitation",
+		},
+		{
+			name:      "Over the workspace limit",
+			urlPath:   "/workspace/1/user/add",
+			email:     "full@mail.com",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "This user can&#39;t join any more workspaces",
+		},
+		{
+			name:     "Not owned workspace id",
+			urlPath:  "/workspace/2/user/add",
+			email:    "pete@mail.com",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:      "Viewer role",
+			urlPath:   "/workspace/1/user/add",
+			email:     "new@mail.com",
+			role:      "VIEWER",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "Invitation sent",
+		},
+		{
+			name:     "Owner role",
+			urlPath:  "/workspace/1/user/add",
+			email:    "new@mail.com",
+			role:     "OWNER",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:      "Not eligible",
+			urlPath:   "/workspace/1/user/add",
+			email:     "unverified@example.com",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "User not found, not verified yet or already added",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("email", tt.email)
+			form.Add("role", tt.role)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantFlash != "" {
+				_, _, body := ts.get(t, "/workspace/1/user/add")
+				assert.StringContains(t, body, tt.wantFlash)
+			}
+		})
+	}
+}
+
+func TestWorkspaceInvitationDeletePost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+	}{
+		{
+			name:     "Revoke",
+			urlPath:  "/workspace/1/invitations/3/delete",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Unknown invitation",
+			urlPath:  "/workspace/1/invitations/9/delete",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Not owned workspace id",
+			urlPath:  "/workspace/2/invitations/3/delete",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestInvitationsView(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/invitations")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	ts.loginUser(t)
+
+	code, _, body := ts.get(t, "/invitations")
+
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "Invited Workspace")
+	assert.StringContains(t, body, `<form action="/invitations/1/accept" method="POST">`)
+}
+
+func TestInvitationPosts(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Accept",
+			urlPath:      "/invitations/1/accept",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/workspace/view/2",
+		},
+		{
+			name:         "Accept over the workspace limit",
+			urlPath:      "/invitations/5/accept",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/invitations",
+		},
+		{
+			name:     "Accept unknown invitation",
+			urlPath:  "/invitations/9/accept",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:         "Decline",
+			urlPath:      "/invitations/1/decline",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/invitations",
+		},
+		{
+			name:     "Decline unknown invitation",
+			urlPath:  "/invitations/9/decline",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Invalid ID",
+			urlPath:  "/invitations/a/accept",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+		})
+	}
+}
+
+func TestWorkspaceInviteLinkPosts(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		email    string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Create",
+			urlPath:  "/workspace/1/invite-links/create",
+			wantCode: http.StatusOK,
+			wantBody: "https://localhost:4000/invite/new-token</code>",
+		},
+		{
+			name:     "Create for an email",
+			urlPath:  "/workspace/1/invite-links/create",
+			email:    "newcomer@mail.com",
+			wantCode: http.StatusOK,
+			wantBody: "<td>newcomer@mail.com</td>",
+		},
+		{
+			name:     "Create with invalid email",
+			urlPath:  "/workspace/1/invite-links/create",
+			email:    "not-an-email",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Create in not owned workspace",
+			urlPath:  "/workspace/2/invite-links/create",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Revoke",
+			urlPath:  "/workspace/1/invite-links/1/delete",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Revoke unknown link",
+			urlPath:  "/workspace/1/invite-links/9/delete",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("email", tt.email)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, body := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestInviteLinkView(t *testing.T) {
+	t.Run("Unknown token", func(t *testing.T) {
+		app := newTestApplication(t)
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		code, _, _ := ts.get(t, "/invite/unknown-token")
+
+		assert.Equal(t, code, http.StatusNotFound)
+	})
+
+	t.Run("Join after login", func(t *testing.T) {
+		app := newTestApplication(t)
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		code, headers, _ := ts.get(t, "/invite/valid-token")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+
+		_, _, body := ts.get(t, "/user/login")
+		assert.StringContains(t, body, "Log in or register to join Linked Workspace")
+
+		form := url.Values{}
+		form.Add("email", "alice@example.com")
+		form.Add("password", "pa$$word")
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, headers, _ = ts.postForm(t, "/user/login", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/invite/valid-token")
+
+		code, _, body = ts.get(t, "/invite/valid-token")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "Join Linked Workspace")
+
+		form = url.Values{}
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, headers, _ = ts.postForm(t, "/invite/valid-token", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/workspace/view/2")
+	})
+
+	t.Run("Join after register", func(t *testing.T) {
+		app := newTestApplication(t)
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.get(t, "/invite/valid-token")
+
+		_, _, body := ts.get(t, "/user/register")
+
+		form := url.Values{}
+		form.Add("firstName", "Alice")
+		form.Add("lastName", "Jones")
+		form.Add("email", "alice@example.com")
+		form.Add("password", "pa$$word")
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, headers, _ := ts.postForm(t, "/user/register", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+
+		_, _, body = ts.get(t, "/user/login")
+		assert.StringContains(t, body, "Check your email to verify your address, then log in to open your new workspace.")
+
+		form = url.Values{}
+		form.Add("email", "alice@example.com")
+		form.Add("password", "pa$$word")
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, headers, _ = ts.postForm(t, "/user/login", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/invite/valid-token")
+	})
+
+	t.Run("Join without a CSRF token", func(t *testing.T) {
+		app := newTestApplication(t)
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.loginUser(t)
+
+		code, _, _ := ts.postForm(t, "/invite/valid-token", url.Values{})
+
+		assert.Equal(t, code, http.StatusBadRequest)
+	})
+
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.loginUser(t)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		wantLocation string
+		wantFlash    string
+	}{
+		{
+			name:         "Join while logged in",
+			urlPath:      "/invite/valid-token",
+			wantLocation: "/workspace/view/2",
+		},
+		{
+			name:         "Over the workspace limit",
+			urlPath:      "/invite/full-token",
+			wantLocation: "/workspace/view",
+			wantFlash:    "You have reached the maximum number of workspaces you can be a member of",
+		},
+		{
+			name:         "Link for another email",
+			urlPath:      "/invite/other-email-token",
+			wantLocation: "/workspace/view",
+			wantFlash:    "This invite link was sent to another email address",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, http.StatusOK)
+			assert.StringContains(t, body, "Join Linked Workspace")
+
+			form := url.Values{}
+			form.Add("csrf_token", extractCSRFToken(t, body))
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, http.StatusSeeOther)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+
+			if tt.wantFlash != "" {
+				_, _, body := ts.get(t, "/workspace/view")
+				assert.StringContains(t, body, tt.wantFlash)
+			}
+		})
+	}
+}
+
+func TestWorkspaceRemoveUserPost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	t.Run("Removed user and redirect", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("userID", "1")
+		form.Add("csrf_token", validCSRFToken)
+
+		code, _, _ := ts.postForm(t, "/workspace/1/user/remove/1", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+	})
+
+	t.Run("Not found user", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("userID", "1")
+		form.Add("csrf_token", validCSRFToken)
+
+		code, _, _ := ts.postForm(t, "/workspace/1/user/remove/2", form)
+
+		assert.Equal(t, code, http.StatusNotFound)
+	})
+
+	t.Run("Invalid user id", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("csrf_token", validCSRFToken)
+
+		code, _, _ := ts.postForm(t, "/workspace/1/user/remove/abc", form)
+
+		assert.Equal(t, code, http.StatusBadRequest)
+	})
+}
+
+func TestWorkspaceLeavePost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+		wantFlash    string
+	}{
+		{
+			name:         "Leave",
+			urlPath:      "/workspace/7/user/leave",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/workspace/view",
+			wantFlash:    "You left Viewer Workspace, 2 of your tasks were reassigned to the owner",
+		},
+		{
+			name:         "Owner",
+			urlPath:      "/workspace/1/user/leave",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/workspace/view/1",
+			wantFlash:    "Transfer ownership to another member before leaving this workspace",
+		},
+		{
+			name:     "Not a member",
+			urlPath:  "/workspace/2/user/leave",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Archived workspace",
+			urlPath:  "/workspace/11/user/leave",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantLocation != "" {
+				assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+			}
+
+			if tt.wantFlash != "" {
+				_, _, body := ts.get(t, "/workspace/view")
+				assert.StringContains(t, body, tt.wantFlash)
+			}
+		})
+	}
+}
+
+func TestWorkspaceUserRolePost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name      string
+		urlPath   string
+		role      string
+		wantCode  int
+		wantFlash string
+	}{
+		{
+			name:      "Promote",
+			urlPath:   "/workspace/1/user/role/2",
+			role:      "ADMIN",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "Role updated",
+		},
+		{
+			name:      "Demote owner",
+			urlPath:   "/workspace/1/user/role/1",
+			role:      "EDITOR",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "The owner&#39;s role can only change by transferring ownership",
+		},
+		{
+			name:     "Owner role",
+			urlPath:  "/workspace/1/user/role/2",
+			role:     "OWNER",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Not a member",
+			urlPath:  "/workspace/1/user/role/3",
+			role:     "VIEWER",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Viewer",
+			urlPath:  "/workspace/7/user/role/2",
+			role:     "ADMIN",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("role", tt.role)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantFlash != "" {
+				_, _, body := ts.get(t, "/workspace/1/user/add")
+				assert.StringContains(t, body, tt.wantFlash)
+			}
+		})
+	}
+}
+
+func TestWorkspaceTransferOwnershipPost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name      string
+		urlPath   string
+		wantCode  int
+		wantFlash string
+	}{
+		{
+			name:      "Transfer",
+			urlPath:   "/workspace/1/user/transfer/2",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "Ownership transferred, you are now an admin of this workspace",
+		},
+		{
+			name:      "Workspace limit",
+			urlPath:   "/workspace/1/user/transfer/4",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "This user can&#39;t own any more workspaces",
+		},
+		{
+			name:     "To yourself",
+			urlPath:  "/workspace/1/user/transfer/1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Not a member",
+			urlPath:  "/workspace/1/user/transfer/3",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Not the owner",
+			urlPath:  "/workspace/7/user/transfer/2",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantFlash != "" {
+				_, _, body := ts.get(t, "/workspace/1/user/add")
+				assert.StringContains(t, body, tt.wantFlash)
+			}
+		})
+	}
+}
+
+func TestUserRegisterHandler(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, _, body := ts.get(t, "/user/register")
+	formTitle := `<h2 class="mb-4 text-center">Register</h2>`
+
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, formTitle)
+}
+
+func TestUserRegisterPost(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name      string
+		firstName string
+		lastName  string
+		email     string
+		password  string
+		csrfToken string
+		wantCode  int
+	}{
+		{
+			name:      "Valid Submission",
+			firstName: "Test",
+			lastName:  "McTester",
+			email:     "test@mail.com",
+			password:  "pa$$word",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusSeeOther,
+		},
+		{
+			name:      "Duplicate email",
+			firstName: "Test",
+			lastName:  "McTester",
+			email:     "dupe@example.com",
+			password:  "pa$$word",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid submission without Username",
+			firstName: "",
+			lastName:  "McTester",
+			email:     "test@mail.com",
+			password:  "pa$$word",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid Username",
+			firstName: "TestTestTestTestTestTest",
+			lastName:  "McTester",
+			email:     "test@mail.com",
+			password:  "pa$$word",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid Lastname",
+			firstName: "Test",
+			lastName:  "McTesterMcTesterMcTesterMcTesterMcTesterMcTester",
+			email:     "test@mail.com",
+			password:  "pa$$word",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid submission without LastName",
+			firstName: "TestTestTestTestTestTest",
+			lastName:  "",
+			email:     "test@mail.com",
+			password:  "pa$$word",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid Email",
+			firstName: "Test",
+			lastName:  "McTester",
+			email:     "testmail.com",
+			password:  "pa$$word",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid submission without Email",
+			firstName: "Test",
+			lastName:  "McTester",
+			email:     "",
+			password:  "pa$$word",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid Password",
+			firstName: "Test",
+			lastName:  "McTester",
+			email:     "test@mail.com",
+			password:  "pa$$",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid submission without Password",
+			firstName: "Test",
+			lastName:  "McTester",
+			email:     "test@mail.com",
+			password:  "",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("firstName", tt.firstName)
+			form.Add("lastName", tt.lastName)
+			form.Add("email", tt.email)
+			form.Add("password", tt.password)
+			form.Add("csrf_token", tt.csrfToken)
+
+			code, _, _ := ts.postForm(t, "/user/register", form)
+
+			assert.Equal(t, code, tt.wantCode)
+		})
+	}
+
+}
+
+func TestUserLoginHandler(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, _, body := ts.get(t, "/user/login")
+	formTitle := `<h2 class="mb-4 text-center">Login</h2>`
+
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, formTitle)
+}
+
+func TestUserLoginPost(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name      string
+		email     string
+		password  string
+		csrfToken string
+		wantCode  int
+		wantBody  string
+	}{
+		{
+			name:      "Valid Submission",
+			email:     "alice@example.com",
+			password:  "pa$$word",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusSeeOther,
+		},
+		{
+			name:      "Invalid Email",
+			email:     "testmail.com",
+			password:  "pa$$word",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid submission without Email",
+			email:     "",
+			password:  "pa$$word",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid submission without Password",
+			email:     "test@mail.com",
+			password:  "",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid user",
+			email:     "test@mail.com",
+			password:  "p4$$word",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Unverified email",
+			email:     "unverified@example.com",
+			password:  "pa$$word",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusUnprocessableEntity,
+			wantBody:  `<a href="/user/verify/resend">Resend the verification email</a>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("email", tt.email)
+			form.Add("password", tt.password)
+			form.Add("csrf_token", tt.csrfToken)
+
+			code, _, body := ts.postForm(t, "/user/login", form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestUserVerify(t *testing.T) {
+	app := newTestApplication(t)
+
+	mailDir := t.TempDir()
+	app.mailer = mailer.New(&mailer.FileTransport{Dir: mailDir}, "Task Manager <no-reply@taskmanager.local>")
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Register sends a verification email", func(t *testing.T) {
+		_, _, body := ts.get(t, "/user/register")
+
+		form := url.Values{}
+		form.Add("firstName", "Alice")
+		form.Add("lastName", "Jones")
+		form.Add("email", "alice@example.com")
+		form.Add("password", "pa$$word")
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, _, _ := ts.postForm(t, "/user/register", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+
+		_, _, body = ts.get(t, "/user/login")
+		assert.StringContains(t, body, "Check your email to verify your address before logging in.")
+
+		files, err := os.ReadDir(mailDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, len(files), 1)
+
+		content, err := os.ReadFile(filepath.Join(mailDir, files[0].Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.StringContains(t, string(content), "To: alice@example.com")
+		assert.StringContains(t, string(content), "https://localhost:4000/user/verify?token=VALIDUSERTOKEN")
+	})
+
+	t.Run("Missing token", func(t *testing.T) {
+		code, _, _ := ts.get(t, "/user/verify")
+
+		assert.Equal(t, code, http.StatusNotFound)
+	})
+
+	code, _, body := ts.get(t, "/user/verify?token=VALIDUSERTOKEN")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, `<input type="hidden" name="token" value="VALIDUSERTOKEN">`)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		field        string
+		value        string
+		wantCode     int
+		wantLocation string
+		wantFlash    string
+		wantMails    int
+	}{
+		{
+			name:         "Verify",
+			urlPath:      "/user/verify",
+			field:        "token",
+			value:        "VALIDUSERTOKEN",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/user/login",
+			wantFlash:    "Your email address has been verified. Please log in.",
+			wantMails:    1,
+		},
+		{
+			name:         "Verify with an invalid token",
+			urlPath:      "/user/verify",
+			field:        "token",
+			value:        "USEDTOKEN",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/user/verify/resend",
+			wantFlash:    "This verification link is invalid or has expired",
+			wantMails:    1,
+		},
+		{
+			name:         "Resend to an unverified address",
+			urlPath:      "/user/verify/resend",
+			field:        "email",
+			value:        "unverified@example.com",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/user/login",
+			wantFlash:    "If that address is waiting for verification, we&#39;ve sent it a new link",
+			wantMails:    2,
+		},
+		{
+			name:         "Resend to a verified address",
+			urlPath:      "/user/verify/resend",
+			field:        "email",
+			value:        "testmctesterson@mail.com",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/user/login",
+			wantMails:    2,
+		},
+		{
+			name:      "Resend to an invalid address",
+			urlPath:   "/user/verify/resend",
+			field:     "email",
+			value:     "nobody",
+			wantCode:  http.StatusUnprocessableEntity,
+			wantMails: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add(tt.field, tt.value)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantLocation != "" {
+				assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+			}
+
+			if tt.wantFlash != "" {
+				_, _, body := ts.get(t, tt.wantLocation)
+				assert.StringContains(t, body, tt.wantFlash)
+			}
+
+			files, err := os.ReadDir(mailDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, len(files), tt.wantMails)
+		})
+	}
+}
+
+func TestUserPasswordForgotPost(t *testing.T) {
+	app := newTestApplication(t)
+
+	mailDir := t.TempDir()
+	app.mailer = mailer.New(&mailer.FileTransport{Dir: mailDir}, "Task Manager <no-reply@taskmanager.local>")
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, _, body := ts.get(t, "/user/password/forgot")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "Send Reset Link")
+
+	tests := []struct {
+		name      string
+		email     string
+		wantCode  int
+		wantMails int
+	}{
+		{
+			name:      "Known email",
+			email:     "testmctesterson@mail.com",
+			wantCode:  http.StatusSeeOther,
+			wantMails: 1,
+		},
+		{
+			name:      "Unknown email",
+			email:     "nobody@mail.com",
+			wantCode:  http.StatusSeeOther,
+			wantMails: 1,
+		},
+		{
+			name:      "Invalid email",
+			email:     "nobody",
+			wantCode:  http.StatusUnprocessableEntity,
+			wantMails: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("email", tt.email)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, headers, _ := ts.postForm(t, "/user/password/forgot", form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			files, err := os.ReadDir(mailDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, len(files), tt.wantMails)
+
+			if code == http.StatusSeeOther {
+				assert.Equal(t, headers.Get("Location"), "/user/login")
+
+				_, _, body := ts.get(t, "/user/login")
+				assert.StringContains(t, body, "If an account uses that email, we&#39;ve sent it a link to reset the password")
+			}
+		})
+	}
+
+	files, err := os.ReadDir(mailDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(mailDir, files[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.StringContains(t, string(content), "To: testmctesterson@mail.com")
+	assert.StringContains(t, string(content), "https://localhost:4000/user/password/reset?token=VALIDUSERTOKEN")
+}
+
+func TestUserPasswordResetPost(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, _, _ := ts.get(t, "/user/password/reset")
+	assert.Equal(t, code, http.StatusNotFound)
+
+	storeSession(t, app, "other-browser", map[string]interface{}{"authenticatedUserID": 1})
+
+	code, _, body := ts.get(t, "/user/password/reset?token=VALIDUSERTOKEN")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, `<input type="hidden" name="token" value="VALIDUSERTOKEN">`)
+
+	tests := []struct {
+		name            string
+		token           string
+		password        string
+		confirmPassword string
+		wantCode        int
+		wantBody        string
+	}{
+		{
+			name:            "Valid Submission",
+			token:           "VALIDUSERTOKEN",
+			password:        "new-pa$$word",
+			confirmPassword: "new-pa$$word",
+			wantCode:        http.StatusSeeOther,
+		},
+		{
+			name:            "Invalid token",
+			token:           "USEDTOKEN",
+			password:        "new-pa$$word",
+			confirmPassword: "new-pa$$word",
+			wantCode:        http.StatusUnprocessableEntity,
+			wantBody:        "This reset link is invalid or has expired",
+		},
+		{
+			name:            "Short password",
+			token:           "VALIDUSERTOKEN",
+			password:        "new",
+			confirmPassword: "new",
+			wantCode:        http.StatusUnprocessableEntity,
+			wantBody:        "This field cannot be less than 6 characters long",
+		},
+		{
+			name:            "Passwords don't match",
+			token:           "VALIDUSERTOKEN",
+			password:        "new-pa$$word",
+			confirmPassword: "other-pa$$word",
+			wantCode:        http.StatusUnprocessableEntity,
+			wantBody:        "Passwords don&#39;t match",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("token", tt.token)
+			form.Add("password", tt.password)
+			form.Add("confirm_password", tt.confirmPassword)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, headers, body := ts.postForm(t, "/user/password/reset", form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+
+			if code == http.StatusSeeOther {
+				assert.Equal(t, headers.Get("Location"), "/user/login")
+			}
+		})
+	}
+
+	_, found, err := app.sessionManager.Store.Find("other-browser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, found, false)
+}
+
+func TestUserLogoutPost(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	form := url.Values{}
+	form.Add("csrf_token", validCSRFToken)
+
+	code, _, _ := ts.postForm(t, "/user/logout", form)
+
+	assert.Equal(t, code, http.StatusSeeOther)
+}
+
+func TestAccountTokens(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, header, _ := ts.get(t, "/account/tokens")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/user/login")
+	})
+
+	t.Run("Authenticated", func(t *testing.T) {
+		ts.loginUser(t)
+
+		code, _, body := ts.get(t, "/account/tokens")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "CI Token")
+	})
+}
+
+func TestAccountTokenCreatePost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/account/tokens")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name      string
+		tokenName string
+		wantCode  int
+		wantBody  string
+	}{
+		{
+			name:      "Valid token name",
+			tokenName: "Deploy",
+			wantCode:  http.StatusOK,
+			wantBody:  "NEWTOKENPLAINTEXT",
+		},
+		{
+			name:      "Blank token name",
+			tokenName: "",
+			wantCode:  http.StatusUnprocessableEntity,
+			wantBody:  "This field cannot be blank",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("name", tt.tokenName)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, body := ts.postForm(t, "/account/tokens/create", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestAccountTokenDeletePost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/account/tokens")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+	}{
+		{
+			name:     "Revoke own token",
+			urlPath:  "/account/tokens/1/delete",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Revoke unknown token",
+			urlPath:  "/account/tokens/2/delete",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Invalid token id",
+			urlPath:  "/account/tokens/foo/delete",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestWorkspaceTrash(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Valid ID",
+			urlPath:  "/workspace/view/1/trash",
+			wantCode: http.StatusOK,
+			wantBody: "Trashed Test Task",
+		},
+		{
+			name:     "Viewer",
+			urlPath:  "/workspace/view/7/trash",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Not a member",
+			urlPath:  "/workspace/view/2/trash",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+				assert.StringContains(t, body, "stay here for 30 days")
+			}
+		})
+	}
+}
+
+func TestTaskTrashPost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name      string
+		urlPath   string
+		wantCode  int
+		wantFlash string
+	}{
+		{
+			name:      "Delete",
+			urlPath:   "/workspace/1/task/delete/1",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "Task moved to the trash",
+		},
+		{
+			name:      "Restore",
+			urlPath:   "/workspace/1/trash/8/restore",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "Task restored",
+		},
+		{
+			name:      "Restore subtask of a deleted task",
+			urlPath:   "/workspace/1/trash/5/restore",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "Restore the parent task first",
+		},
+		{
+			name:     "Restore task not in the trash",
+			urlPath:  "/workspace/1/trash/2/restore",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Restore as viewer",
+			urlPath:  "/workspace/7/trash/8/restore",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:      "Purge",
+			urlPath:   "/workspace/1/trash/8/purge",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "Task permanently deleted",
+		},
+		{
+			name:     "Purge task not in the trash",
+			urlPath:  "/workspace/1/trash/2/purge",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Purge as viewer",
+			urlPath:  "/workspace/7/trash/8/purge",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantFlash != "" {
+				_, _, body := ts.get(t, "/workspace/view/1/trash")
+				assert.StringContains(t, body, tt.wantFlash)
+			}
+		})
+	}
+}
+
+func TestWorkspaceTrashPost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+		wantFlash    string
+	}{
+		{
+			name:         "Delete",
+			urlPath:      "/workspace/delete/1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/workspace/view",
+			wantFlash:    "Workspace moved to the trash",
+		},
+		{
+			name:         "Restore",
+			urlPath:      "/workspace/restore/9",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/workspace/view/9",
+			wantFlash:    "Workspace restored",
+		},
+		{
+			name:         "Restore over the workspace limit",
+			urlPath:      "/workspace/restore/10",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/workspace/view",
+			wantFlash:    "You have reached the maximum number of workspaces you can own",
+		},
+		{
+			name:     "Restore workspace not in the trash",
+			urlPath:  "/workspace/restore/1",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:         "Purge",
+			urlPath:      "/workspace/purge/9",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/workspace/view",
+			wantFlash:    "Workspace permanently deleted",
+		},
+		{
+			name:     "Purge workspace not in the trash",
+			urlPath:  "/workspace/purge/1",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantLocation != "" {
+				assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+			}
+
+			if tt.wantFlash != "" {
+				_, _, body := ts.get(t, "/workspace/view")
+				assert.StringContains(t, body, tt.wantFlash)
+			}
+		})
+	}
+}
+
+func TestTaskArchivePost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+		wantFlash    string
+	}{
+		{
+			name:         "Archive",
+			urlPath:      "/task/archive/12",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/task/view/12",
+			wantFlash:    "Task archived",
+		},
+		{
+			name:         "Archive unfinished task",
+			urlPath:      "/task/archive/1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/task/view/1",
+			wantFlash:    "Only finished tasks can be archived",
+		},
+		{
+			name:         "Unarchive",
+			urlPath:      "/task/unarchive/12",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/task/view/12",
+			wantFlash:    "Task unarchived",
+		},
+		{
+			name:     "Archive as viewer",
+			urlPath:  "/task/archive/6",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Archive in an archived workspace",
+			urlPath:  "/task/unarchive/11",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:         "Archive finished tasks",
+			urlPath:      "/workspace/1/tasks/archive",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/workspace/view/1/tasks",
+			wantFlash:    "2 finished tasks archived",
+		},
+		{
+			name:     "Archive finished tasks as viewer",
+			urlPath:  "/workspace/7/tasks/archive",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantLocation != "" {
+				assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+			}
+
+			if tt.wantFlash != "" {
+				_, _, body := ts.get(t, tt.wantLocation)
+				assert.StringContains(t, body, tt.wantFlash)
+			}
+		})
+	}
+}
+
+func TestWorkspaceArchivePost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name      string
+		urlPath   string
+		wantCode  int
+		wantFlash string
+	}{
+		{
+			name:      "Archive",
+			urlPath:   "/workspace/archive/1",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "Workspace archived",
+		},
+		{
+			name:      "Unarchive",
+			urlPath:   "/workspace/unarchive/11",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "Workspace unarchived",
+		},
+		{
+			name:     "Archive as viewer",
+			urlPath:  "/workspace/archive/7",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Archive as non-member",
+			urlPath:  "/workspace/archive/2",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Edit an archived workspace",
+			urlPath:  "/workspace/update/11",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantFlash != "" {
+				_, _, body := ts.get(t, headers.Get("Location"))
+				assert.StringContains(t, body, tt.wantFlash)
+			}
+		})
+	}
+}
+
+func TestTaskWatchPost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+		wantFlash    string
+	}{
+		{
+			name:         "Watch",
+			urlPath:      "/task/watch/1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/task/view/1",
+			wantFlash:    "You are now watching this task",
+		},
+		{
+			name:         "Watch as viewer",
+			urlPath:      "/task/watch/6",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/task/view/6",
+			wantFlash:    "You are now watching this task",
+		},
+		{
+			name:         "Unwatch",
+			urlPath:      "/task/unwatch/1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/task/view/1",
+			wantFlash:    "You are no longer watching this task",
+		},
+		{
+			name:     "Watch not member",
+			urlPath:  "/task/watch/2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Watch in an archived workspace",
+			urlPath:  "/task/watch/11",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Unwatch in an archived workspace",
+			urlPath:  "/task/unwatch/11",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantLocation != "" {
+				assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+			}
+
+			if tt.wantFlash != "" {
+				_, _, body := ts.get(t, tt.wantLocation)
+				assert.StringContains(t, body, tt.wantFlash)
+			}
+		})
+	}
+}
+func TestAccountSettings(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, header, _ := ts.get(t, "/account/settings")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/user/login")
+	})
+
+	t.Run("Authenticated", func(t *testing.T) {
+		ts.loginUser(t)
+
+		code, _, body := ts.get(t, "/account/settings")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, `name="firstName" value="Test"`)
+		assert.StringContains(t, body, "<strong>testmctesterson@mail.com</strong>")
+	})
+}
+func TestAccountSettingsPost(t *testing.T) {
+	app := newTestApplication(t)
+
+	mailDir := t.TempDir()
+	app.mailer = mailer.New(&mailer.FileTransport{Dir: mailDir}, "Task Manager <no-reply@taskmanager.local>")
+
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/account/settings")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name      string
+		urlPath   string
+		fields    map[string]string
+		wantCode  int
+		wantBody  string
+		wantFlash string
+		wantMails int
+	}{
+		{
+			name:      "Update profile",
+			urlPath:   "/account/profile",
+			fields:    map[string]string{"firstName": "Tess", "lastName": "McTester"},
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "Profile updated successfully!",
+		},
+		{
+			name:     "Blank first name",
+			urlPath:  "/account/profile",
+			fields:   map[string]string{"firstName": "", "lastName": "McTester"},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field cannot be blank",
+		},
+		{
+			name:      "Change password",
+			urlPath:   "/account/password",
+			fields:    map[string]string{"current_password": "pa$$word", "new_password": "n3wpa$$", "confirm_password": "n3wpa$$"},
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "Your password has been changed",
+		},
+		{
+			name:     "Wrong current password",
+			urlPath:  "/account/password",
+			fields:   map[string]string{"current_password": "wrong", "new_password": "n3wpa$$", "confirm_password": "n3wpa$$"},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "Current password is incorrect",
+		},
+		{
+			name:     "Mismatched new password",
+			urlPath:  "/account/password",
+			fields:   map[string]string{"current_password": "pa$$word", "new_password": "n3wpa$$", "confirm_password": "other1"},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "Passwords don&#39;t match",
+		},
+		{
+			name:      "Change email",
+			urlPath:   "/account/email",
+			fields:    map[string]string{"email": "tess@example.com", "email_password": "pa$$word"},
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "We&#39;ve sent a confirmation link to your new email address.",
+			wantMails: 1,
+		},
+		{
+			name:      "Email already in use",
+			urlPath:   "/account/email",
+			fields:    map[string]string{"email": "dupe@example.com", "email_password": "pa$$word"},
+			wantCode:  http.StatusUnprocessableEntity,
+			wantBody:  "Email address is already in use",
+			wantMails: 1,
+		},
+		{
+			name:      "Email change with wrong password",
+			urlPath:   "/account/email",
+			fields:    map[string]string{"email": "tess@example.com", "email_password": "wrong"},
+			wantCode:  http.StatusUnprocessableEntity,
+			wantBody:  "Password is incorrect",
+			wantMails: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			for field, value := range tt.fields {
+				form.Add(field, value)
+			}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, headers, body := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+
+			if tt.wantFlash != "" {
+				assert.Equal(t, headers.Get("Location"), "/account/settings")
+
+				_, _, body := ts.get(t, "/account/settings")
+				assert.StringContains(t, body, tt.wantFlash)
+			}
+
+			files, err := os.ReadDir(mailDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, len(files), tt.wantMails)
+
+			if tt.wantMails > 0 {
+				content, err := os.ReadFile(filepath.Join(mailDir, files[0].Name()))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				assert.StringContains(t, string(content), "To: tess@example.com")
+				assert.StringContains(t, string(content), "https://localhost:4000/account/email/confirm?token=VALIDUSERTOKEN")
+			}
+		})
+	}
+}
+func TestAccountPasswordEndsOtherSessions(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	ts.loginUser(t)
+	defer ts.Close()
+
+	storeSession(t, app, "other-browser", map[string]interface{}{"authenticatedUserID": 1})
+	storeSession(t, app, "pending-two-factor", map[string]interface{}{"twoFactorUserID": 1})
+	storeSession(t, app, "other-user", map[string]interface{}{"authenticatedUserID": 2})
+
+	_, _, body := ts.get(t, "/account/settings")
+
+	form := url.Values{}
+	form.Add("current_password", "pa$$word")
+	form.Add("new_password", "n3wpa$$")
+	form.Add("confirm_password", "n3wpa$$")
+	form.Add("csrf_token", extractCSRFToken(t, body))
+
+	code, _, _ := ts.postForm(t, "/account/password", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	for token, want := range map[string]bool{"other-browser": false, "pending-two-factor": false, "other-user": true} {
+		_, found, err := app.sessionManager.Store.Find(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, found, want)
+	}
+
+	code, _, _ = ts.get(t, "/account/settings")
+	assert.Equal(t, code, http.StatusOK)
+}
+
+func TestAccountEmailConfirm(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Missing token", func(t *testing.T) {
+		code, _, _ := ts.get(t, "/account/email/confirm")
+
+		assert.Equal(t, code, http.StatusNotFound)
+	})
+
+	code, _, body := ts.get(t, "/account/email/confirm?token=VALIDUSERTOKEN")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, `<input type="hidden" name="token" value="VALIDUSERTOKEN">`)
+
+	tests := []struct {
+		name      string
+		token     string
+		wantFlash string
+	}{
+		{
+			name:      "Valid token",
+			token:     "VALIDUSERTOKEN",
+			wantFlash: "Your email address has been updated",
+		},
+		{
+			name:      "Invalid token",
+			token:     "USEDTOKEN",
+			wantFlash: "This confirmation link is invalid or has expired",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("token", tt.token)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, headers, _ := ts.postForm(t, "/account/email/confirm", form)
+
+			assert.Equal(t, code, http.StatusSeeOther)
+			assert.Equal(t, headers.Get("Location"), "/user/login")
+
+			_, _, body := ts.get(t, "/user/login")
+			assert.StringContains(t, body, tt.wantFlash)
+		})
+	}
+}
+func TestUserLoginTwoFactor(t *testing.T) {
+	app := newTestApplication(t)
+
+	login := func(t *testing.T, ts *testServer) string {
+		_, _, body := ts.get(t, "/user/login")
+		csrfToken := extractCSRFToken(t, body)
+
+		form := url.Values{}
+		form.Add("email", "twofactor@example.com")
+		form.Add("password", "pa$$word")
+		form.Add("csrf_token", csrfToken)
+
+		code, headers, _ := ts.postForm(t, "/user/login", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login/2fa")
+
+		return csrfToken
+	}
+
+	submitCode := func(t *testing.T, ts *testServer, csrfToken, code string) (int, http.Header, string) {
+		form := url.Values{}
+		form.Add("code", code)
+		form.Add("csrf_token", csrfToken)
+
+		return ts.postForm(t, "/user/login/2fa", form)
+	}
+
+	t.Run("No pending login", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		code, headers, _ := ts.get(t, "/user/login/2fa")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	tests := []struct {
+		name     string
+		code     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Valid code",
+			code:     "123456",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Recovery code",
+			code:     "abcde-fghij",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Invalid code",
+			code:     "654321",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "Invalid authentication code",
+		},
+		{
+			name:     "Blank code",
+			code:     "",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field cannot be blank",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			csrfToken := login(t, ts)
+
+			code, headers, _ := ts.get(t, "/account/settings")
+
+			assert.Equal(t, code, http.StatusSeeOther)
+			assert.Equal(t, headers.Get("Location"), "/user/login")
+
+			code, _, body := ts.get(t, "/user/login/2fa")
+
+			assert.Equal(t, code, http.StatusOK)
+			assert.StringContains(t, body, "Enter the 6-digit code from your authenticator app")
+
+			code, headers, body = submitCode(t, ts, csrfToken, tt.code)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+				return
+			}
+
+			events := app.authEvents.(*mocks.AuthEventModel).Events
+			assert.Equal(t, events[len(events)-1], models.EventLoginSucceeded)
+
+			assert.Equal(t, headers.Get("Location"), "/workspace/view")
+
+			code, _, _ = ts.get(t, "/account/settings")
+			assert.Equal(t, code, http.StatusOK)
+		})
+	}
+
+	t.Run("Too many invalid codes", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		csrfToken := login(t, ts)
+
+		for i := 1; i < twoFactorLoginMaxAttempts; i++ {
+			code, _, _ := submitCode(t, ts, csrfToken, "654321")
+			assert.Equal(t, code, http.StatusUnprocessableEntity)
+		}
+
+		code, headers, _ := submitCode(t, ts, csrfToken, "654321")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+
+		_, _, body := ts.get(t, "/user/login")
+		assert.StringContains(t, body, "Too many invalid codes. Please log in again.")
+
+		code, _, _ = submitCode(t, ts, csrfToken, "123456")
+		assert.Equal(t, code, http.StatusSeeOther)
+
+		code, _, _ = ts.get(t, "/account/settings")
+		assert.Equal(t, code, http.StatusSeeOther)
+	})
+}
+func TestAccountTwoFactor(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, header, _ := ts.get(t, "/account/2fa")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/user/login")
+	})
+
+	ts.loginUser(t)
+
+	code, _, body := ts.get(t, "/account/2fa")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, `<img src="/account/2fa/qr"`)
+	assert.StringContains(t, body, "<code>JBSWY3DPEHPK3PXP</code>")
+
+	t.Run("QR code", func(t *testing.T) {
+		code, headers, body := ts.get(t, "/account/2fa/qr")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.Equal(t, headers.Get("Content-Type"), "image/png")
+		assert.StringContains(t, body, "PNG")
+	})
+
+	tests := []struct {
+		name      string
+		urlPath   string
+		field     string
+		value     string
+		wantCode  int
+		wantBody  string
+		wantFlash string
+	}{
+		{
+			name:     "Set up",
+			urlPath:  "/account/2fa/setup",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Enable",
+			urlPath:  "/account/2fa/enable",
+			field:    "code",
+			value:    "123456",
+			wantCode: http.StatusOK,
+			wantBody: "<li><code>abcde-fghij</code></li>",
+		},
+		{
+			name:     "Enable with an invalid code",
+			urlPath:  "/account/2fa/enable",
+			field:    "code",
+			value:    "654321",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "Invalid authentication code",
+		},
+		{
+			name:      "Disable",
+			urlPath:   "/account/2fa/disable",
+			field:     "password",
+			value:     "pa$$word",
+			wantCode:  http.StatusSeeOther,
+			wantFlash: "Two-factor authentication has been disabled",
+		},
+		{
+			name:     "Disable with a wrong password",
+			urlPath:  "/account/2fa/disable",
+			field:    "password",
+			value:    "wrong",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			if tt.field != "" {
+				form.Add(tt.field, tt.value)
+			}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, headers, body := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+
+			if tt.wantCode == http.StatusSeeOther {
+				assert.Equal(t, headers.Get("Location"), "/account/2fa")
+			}
+
+			if tt.wantFlash != "" {
+				_, _, body := ts.get(t, "/account/2fa")
+				assert.StringContains(t, body, tt.wantFlash)
+			}
+		})
+	}
+}
+func TestUserLoginThrottle(t *testing.T) {
+	tests := []struct {
+		name           string
+		email          string
+		password       string
+		wantCode       int
+		wantBody       string
+		wantRetryAfter string
+		wantEvents     []string
+	}{
+		{
+			name:       "Valid credentials",
+			email:      "alice@example.com",
+			password:   "pa$$word",
+			wantCode:   http.StatusSeeOther,
+			wantEvents: []string{models.EventLoginSucceeded},
+		},
+		{
+			name:       "Wrong password",
+			email:      "alice@example.com",
+			password:   "wrong",
+			wantCode:   http.StatusUnprocessableEntity,
+			wantBody:   "Email or password is incorrect",
+			wantEvents: []string{models.EventLoginFailed},
+		},
+		{
+			name:       "Unverified email",
+			email:      "unverified@example.com",
+			password:   "pa$$word",
+			wantCode:   http.StatusUnprocessableEntity,
+			wantBody:   "Please verify your email address before logging in",
+			wantEvents: []string{models.EventLoginUnverified},
+		},
+		{
+			name:       "Two-factor authentication needed",
+			email:      "twofactor@example.com",
+			password:   "pa$$word",
+			wantCode:   http.StatusSeeOther,
+			wantEvents: []string{models.EventTwoFactorNeeded},
+		},
+		{
+			name:           "Throttled",
+			email:          "throttled@example.com",
+			password:       "pa$$word",
+			wantCode:       http.StatusTooManyRequests,
+			wantBody:       "Too many failed login attempts. Please wait 4 seconds before trying again.",
+			wantRetryAfter: "4",
+			wantEvents:     []string{models.EventLoginThrottled},
+		},
+		{
+			name:           "Locked account",
+			email:          "locked@example.com",
+			password:       "pa$$word",
+			wantCode:       http.StatusTooManyRequests,
+			wantBody:       "Too many failed login attempts. This account is locked for the next 15 minutes.",
+			wantRetryAfter: "900",
+			wantEvents:     []string{models.EventLoginThrottled},
+		},
+		{
+			name:           "Failure that locks the account",
+			email:          "lockme@example.com",
+			password:       "wrong",
+			wantCode:       http.StatusTooManyRequests,
+			wantBody:       "This account is locked for the next 15 minutes.",
+			wantRetryAfter: "900",
+			wantEvents:     []string{models.EventLoginFailed, models.EventAccountLocked},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			authEvents := &mocks.AuthEventModel{}
+			app.authEvents = authEvents
+
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			_, _, body := ts.get(t, "/user/login")
+
+			form := url.Values{}
+			form.Add("email", tt.email)
+			form.Add("password", tt.password)
+			form.Add("csrf_token", extractCSRFToken(t, body))
+
+			code, headers, body := ts.postForm(t, "/user/login", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Retry-After"), tt.wantRetryAfter)
+			assert.Equal(t, strings.Join(authEvents.Events, ","), strings.Join(tt.wantEvents, ","))
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+func TestUserSignUpThrottle(t *testing.T) {
+	tests := []struct {
+		name       string
+		email      string
+		signupWait time.Duration
+		wantCode   int
+		wantBody   string
+		wantEvents []string
+	}{
+		{
+			name:       "Allowed",
+			email:      "bob@example.com",
+			wantCode:   http.StatusSeeOther,
+			wantEvents: []string{models.EventSignup},
+		},
+		{
+			name:       "Duplicate email",
+			email:      "dupe@example.com",
+			wantCode:   http.StatusUnprocessableEntity,
+			wantBody:   "Email address is already in use",
+			wantEvents: []string{models.EventSignupFailed},
+		},
+		{
+			name:       "Throttled",
+			email:      "bob@example.com",
+			signupWait: 20 * time.Minute,
+			wantCode:   http.StatusTooManyRequests,
+			wantBody:   "Too many accounts have been created from your network. Please try again in 20 minutes.",
+			wantEvents: []string{models.EventSignupThrottled},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			authEvents := &mocks.AuthEventModel{SignupWait: tt.signupWait}
+			app.authEvents = authEvents
+
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			_, _, body := ts.get(t, "/user/register")
+
+			form := url.Values{}
+			form.Add("firstName", "Bob")
+			form.Add("lastName", "Jones")
+			form.Add("email", tt.email)
+			form.Add("password", "pa$$word")
+			form.Add("csrf_token", extractCSRFToken(t, body))
+
+			code, _, body := ts.postForm(t, "/user/register", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, strings.Join(authEvents.Events, ","), strings.Join(tt.wantEvents, ","))
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
 }
@@ -2,13 +2,19 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"runtime/debug"
+	"strings"
 	"time"
 
 	"github.com/andres085/task_manager/internal/models"
+	"github.com/andres085/task_manager/internal/validator"
 	"github.com/go-playground/form/v4"
 	"github.com/justinas/nosurf"
 )
@@ -48,6 +54,27 @@ func (app *application) render(w http.ResponseWriter, r *http.Request, status in
 	buf.WriteTo(w)
 }
 
+// redeemInviteLink joins the user to the workspace of an invite link. It returns 0 and
+// leaves a flash message when the link can't be used.
+func (app *application) redeemInviteLink(r *http.Request, token string, userId int) (int, error) {
+	workspaceId, err := app.inviteLinks.Redeem(token, userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.sessionManager.Put(r.Context(), "flash", "This invite link has expired or was revoked")
+		case errors.Is(err, models.ErrInviteEmailMismatch):
+			app.sessionManager.Put(r.Context(), "flash", "This invite link was sent to another email address")
+		case errors.Is(err, models.ErrWorkspaceLimit):
+			app.sessionManager.Put(r.Context(), "flash", "You have reached the maximum number of workspaces you can be a member of")
+		default:
+			return 0, err
+		}
+		return 0, nil
+	}
+
+	return workspaceId, nil
+}
+
 func (app *application) isAuthenticated(r *http.Request) bool {
 	isAuthenticated, ok := r.Context().Value(isAuthenticatedContextKey).(bool)
 	if !ok {
@@ -57,6 +84,85 @@ func (app *application) isAuthenticated(r *http.Request) bool {
 	return isAuthenticated
 }
 
+func (app *application) authenticatedUserID(r *http.Request) int {
+	if id, ok := r.Context().Value(userIDContextKey).(int); ok {
+		return id
+	}
+
+	return app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+}
+
+// endOtherSessions logs the user out of every session but the current one, including logins that
+// are still waiting for their second factor.
+func (app *application) endOtherSessions(r *http.Request, userId int) error {
+	current := app.sessionManager.Token(r.Context())
+
+	return app.sessionManager.Iterate(r.Context(), func(ctx context.Context) error {
+		if app.sessionManager.Token(ctx) == current {
+			return nil
+		}
+
+		if app.sessionManager.GetInt(ctx, "authenticatedUserID") != userId && app.sessionManager.GetInt(ctx, "twoFactorUserID") != userId {
+			return nil
+		}
+
+		return app.sessionManager.Destroy(ctx)
+	})
+}
+
+// pendingTwoFactorUserID returns the user who passed the password step of the login and still
+// has to enter a code, or 0 if there is none or it has expired.
+func (app *application) pendingTwoFactorUserID(r *http.Request) int {
+	id := app.sessionManager.GetInt(r.Context(), "twoFactorUserID")
+	if id == 0 {
+		return 0
+	}
+
+	if time.Now().Unix() > app.sessionManager.GetInt64(r.Context(), "twoFactorExpires") {
+		app.clearTwoFactorLogin(r)
+		return 0
+	}
+
+	return id
+}
+
+func (app *application) clearTwoFactorLogin(r *http.Request) {
+	app.sessionManager.Remove(r.Context(), "twoFactorUserID")
+	app.sessionManager.Remove(r.Context(), "twoFactorEmail")
+	app.sessionManager.Remove(r.Context(), "twoFactorExpires")
+	app.sessionManager.Remove(r.Context(), "twoFactorAttempts")
+}
+
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return ip
+}
+
+// auditAuthEvent records a login or sign up event in the audit log, which the login and sign up
+// rate limits are worked out from, and in the application log.
+func (app *application) auditAuthEvent(r *http.Request, event, email string) error {
+	ip := clientIP(r)
+
+	app.logger.Info("auth event", "event", event, "email", email, "ip", ip)
+
+	return app.authEvents.Insert(event, email, ip)
+}
+
+// resolveLoginAttempt records the outcome of a login attempt started with BeginLoginAttempt.
+func (app *application) resolveLoginAttempt(r *http.Request, id int, event, email string) error {
+	app.logger.Info("auth event", "event", event, "email", email, "ip", clientIP(r))
+
+	return app.authEvents.ResolveLoginAttempt(id, event)
+}
+
+func hasBearerToken(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
 func (app *application) newTemplateData(r *http.Request) templateData {
 	return templateData{
 		CurrentYear:     time.Now().Year(),
@@ -86,21 +192,242 @@ func (app *application) decodePostForm(r *http.Request, dst any) error {
 	return nil
 }
 
-func (app *application) getFormsDefaultUser(workspaceId int) (models.UserWithRole, []models.UserWithRole, error) {
-	workspaceUsers, err := app.users.GetWorkspaceUsers(workspaceId)
+func (app *application) newTaskViewData(r *http.Request, task models.Task, perm permission) (templateData, error) {
+	comments, err := app.comments.GetAll(task.ID)
+	if err != nil {
+		return templateData{}, err
+	}
+
+	history, err := app.tasks.GetHistory(task.ID)
+	if err != nil {
+		return templateData{}, err
+	}
+
+	subtasks, err := app.tasks.GetSubtasks(task.ID)
+	if err != nil {
+		return templateData{}, err
+	}
+
+	checklist, err := app.checklists.GetAll(task.ID)
+	if err != nil {
+		return templateData{}, err
+	}
+
+	blockers, err := app.dependencies.GetBlockers(task.ID)
+	if err != nil {
+		return templateData{}, err
+	}
+
+	blocking, err := app.dependencies.GetBlocking(task.ID)
+	if err != nil {
+		return templateData{}, err
+	}
+
+	workspaceTasks, err := app.tasks.GetAll(task.WorkspaceId, 0, 0, models.TaskFilter{})
 	if err != nil {
-		return models.UserWithRole{}, nil, err
+		return templateData{}, err
 	}
 
-	var adminUser models.UserWithRole
-	regularUsers := make([]models.UserWithRole, len(workspaceUsers)-1)
+	linked := map[int]bool{task.ID: true}
+	for _, t := range blockers {
+		linked[t.ID] = true
+	}
+
+	var blockerOptions []models.Task
+	for _, t := range workspaceTasks {
+		if !linked[t.ID] {
+			blockerOptions = append(blockerOptions, t)
+		}
+	}
 
-	for i, user := range workspaceUsers {
-		if user.Role == "ADMIN" {
-			adminUser = user
-			regularUsers = append(workspaceUsers[:i], workspaceUsers[i+1:]...)
+	var parentTask *models.Task
+	if task.ParentId != nil {
+		parent, err := app.tasks.Get(*task.ParentId)
+		if err != nil {
+			return templateData{}, err
 		}
+		parentTask = &parent
+	}
+
+	data := app.newTemplateData(r)
+	data.Task = task
+	data.setPermission(perm)
+	data.Comments = comments
+	data.TaskHistory = history
+	data.Subtasks = subtasks
+	data.Checklist = checklist
+	data.Blockers = blockers
+	data.Blocking = blocking
+	data.BlockerOptions = blockerOptions
+	data.ParentTask = parentTask
+	data.CurrentUserID = r.Context().Value(userIDContextKey).(int)
+
+	return data, nil
+}
+
+type envelope map[string]any
+
+func (app *application) sendVerificationEmail(user *models.User) error {
+	token, err := app.userTokens.Insert(user.ID, models.ScopeVerification, models.VerificationTTL)
+	if err != nil {
+		return err
 	}
 
-	return adminUser, regularUsers, nil
+	data := map[string]any{
+		"FirstName": user.FirstName,
+		"URL":       fmt.Sprintf("%s/user/verify?token=%s", app.baseURL, token),
+		"Expires":   humanDuration(models.VerificationTTL),
+	}
+
+	return app.mailer.Send(user.Email, "verify_email.tmpl", data)
+}
+
+// groupTasks splits tasks that are already ordered by workspace and status into one group per
+// workspace with a column per status.
+func groupTasks(tasks []models.Task) []taskGroup {
+	var groups []taskGroup
+
+	for _, task := range tasks {
+		if len(groups) == 0 || groups[len(groups)-1].WorkspaceID != task.WorkspaceId {
+			groups = append(groups, taskGroup{WorkspaceID: task.WorkspaceId, WorkspaceTitle: task.WorkspaceTitle})
+		}
+
+		group := &groups[len(groups)-1]
+		if len(group.Columns) == 0 || group.Columns[len(group.Columns)-1].Status.Name != task.Status {
+			group.Columns = append(group.Columns, boardColumn{Status: models.Status{Name: task.Status}})
+		}
+
+		column := &group.Columns[len(group.Columns)-1]
+		column.Tasks = append(column.Tasks, task)
+	}
+
+	return groups
+}
+
+func (app *application) writeJSON(w http.ResponseWriter, status int, data envelope) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(js)
+
+	return nil
+}
+
+func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, 1_048_576)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err != nil {
+		var syntaxError *json.SyntaxError
+		var unmarshalTypeError *json.UnmarshalTypeError
+		var maxBytesError *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &syntaxError):
+			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return errors.New("body contains badly-formed JSON")
+		case errors.As(err, &unmarshalTypeError):
+			return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+		case errors.Is(err, io.EOF):
+			return errors.New("body must not be empty")
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
+			return fmt.Errorf("body contains unknown key %s", fieldName)
+		case errors.As(err, &maxBytesError):
+			return fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+		default:
+			return err
+		}
+	}
+
+	err = dec.Decode(&struct{}{})
+	if !errors.Is(err, io.EOF) {
+		return errors.New("body must only contain a single JSON value")
+	}
+
+	return nil
+}
+
+func (app *application) errorJSON(w http.ResponseWriter, r *http.Request, status int, message string) {
+	err := app.writeJSON(w, status, envelope{"error": message})
+	if err != nil {
+		app.logger.Error(err.Error(), "method", r.Method, "uri", r.URL.RequestURI())
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (app *application) serverErrorJSON(w http.ResponseWriter, r *http.Request, err error) {
+	var (
+		method = r.Method
+		uri    = r.URL.RequestURI()
+		trace  = string(debug.Stack())
+	)
+
+	app.logger.Error(err.Error(), "method", method, "uri", uri, "trace", trace)
+	app.errorJSON(w, r, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+}
+
+func (app *application) invalidAuthenticationTokenJSON(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	app.errorJSON(w, r, http.StatusUnauthorized, "invalid or missing authentication token")
+}
+
+func (app *application) failedValidationJSON(w http.ResponseWriter, r *http.Request, v validator.Validator) {
+	body := envelope{
+		"error":  "the request contains invalid fields",
+		"fields": v.FieldErrors,
+	}
+
+	if len(v.NonFieldErrors) > 0 {
+		body["non_field_errors"] = v.NonFieldErrors
+	}
+
+	err := app.writeJSON(w, http.StatusUnprocessableEntity, body)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+func (data *templateData) setPermission(perm permission) {
+	data.Role = perm.Role
+	data.Archived = perm.Archived
+	data.IsAdmin = perm.can(models.ActionManageWorkspace)
+	data.CanEdit = perm.can(models.ActionEditTasks)
+	data.CanDeleteWorkspace = perm.can(models.ActionDeleteWorkspace)
+	data.CanArchive = perm.can(models.ActionArchiveWorkspace)
+}
+
+// purgeTrash permanently deletes, every interval, the tasks and workspaces that have been in
+// the trash for longer than the retention period.
+func (app *application) purgeTrash(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		before := time.Now().Add(-app.trashRetention)
+
+		tasks, err := app.tasks.PurgeDeleted(before)
+		if err != nil {
+			app.logger.Error(err.Error())
+		}
+
+		workspaces, err := app.workspaces.PurgeDeleted(before)
+		if err != nil {
+			app.logger.Error(err.Error())
+		}
+
+		if tasks > 0 || workspaces > 0 {
+			app.logger.Info("purged trash", "tasks", tasks, "workspaces", workspaces)
+		}
+
+		<-ticker.C
+	}
 }
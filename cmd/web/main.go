@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"database/sql"
 	"flag"
+	"fmt"
 	"html/template"
 	"log/slog"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/alexedwards/scs/mysqlstore"
 	"github.com/alexedwards/scs/v2"
+	"github.com/andres085/task_manager/internal/mailer"
 	"github.com/andres085/task_manager/internal/models"
 	"github.com/go-playground/form/v4"
 	_ "github.com/go-sql-driver/mysql"
@@ -22,6 +24,21 @@ type application struct {
 	tasks          models.TaskModelInterface
 	workspaces     models.WorkspaceModelInterface
 	users          models.UserModelInterface
+	comments       models.CommentModelInterface
+	apiTokens      models.APITokenModelInterface
+	statuses       models.StatusModelInterface
+	labels         models.LabelModelInterface
+	checklists     models.ChecklistModelInterface
+	dependencies   models.DependencyModelInterface
+	invitations    models.InvitationModelInterface
+	inviteLinks    models.InviteLinkModelInterface
+	limits         models.LimitModelInterface
+	userTokens     models.UserTokenModelInterface
+	twoFactor      models.TwoFactorModelInterface
+	authEvents     models.AuthEventModelInterface
+	mailer         *mailer.Mailer
+	baseURL        string
+	trashRetention time.Duration
 	templateCache  map[string]*template.Template
 	formDecoder    *form.Decoder
 	sessionManager *scs.SessionManager
@@ -31,10 +48,35 @@ func main() {
 
 	addr := flag.String("addr", ":4000", "HTTP network address")
 	dsn := flag.String("dsn", "myuser:mypassword@/task_manager?parseTime=true", "MySQL data source name")
+	flag.IntVar(&models.DefaultLimits.OwnedWorkspaces, "max-owned-workspaces", models.DefaultLimits.OwnedWorkspaces, "Workspaces a user can own unless overridden in user_limits")
+	flag.IntVar(&models.DefaultLimits.MemberWorkspaces, "max-member-workspaces", models.DefaultLimits.MemberWorkspaces, "Workspaces a user can be invited to unless overridden in user_limits")
+	trashRetention := flag.Duration("trash-retention", 30*24*time.Hour, "How long deleted tasks and workspaces stay in the trash")
+	baseURL := flag.String("base-url", "https://localhost:4000", "Public URL used for links in emails and invite links")
+	mailTransport := flag.String("mailer", "log", "How emails are delivered: log, file or smtp")
+	mailDir := flag.String("mail-dir", "./tmp/mail", "Directory the file mailer writes emails to")
+	mailSender := flag.String("mail-sender", "Task Manager <no-reply@taskmanager.local>", "From address of outgoing emails")
+	smtpHost := flag.String("smtp-host", "localhost", "SMTP server host")
+	smtpPort := flag.Int("smtp-port", 587, "SMTP server port")
+	smtpUsername := flag.String("smtp-username", "", "SMTP username")
+	smtpPassword := flag.String("smtp-password", "", "SMTP password")
 	flag.Parse()
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
+	var transport mailer.Transport
+
+	switch *mailTransport {
+	case "log":
+		transport = &mailer.LogTransport{Logger: logger}
+	case "file":
+		transport = &mailer.FileTransport{Dir: *mailDir}
+	case "smtp":
+		transport = &mailer.SMTPTransport{Host: *smtpHost, Port: *smtpPort, Username: *smtpUsername, Password: *smtpPassword}
+	default:
+		logger.Error(fmt.Sprintf("unknown mailer %q", *mailTransport))
+		os.Exit(1)
+	}
+
 	db, err := openDB(*dsn)
 	if err != nil {
 		logger.Error(err.Error())
@@ -61,6 +103,21 @@ func main() {
 		tasks:          &models.TaskModel{DB: db},
 		workspaces:     &models.WorkspaceModel{DB: db},
 		users:          &models.UserModel{DB: db},
+		comments:       &models.CommentModel{DB: db},
+		apiTokens:      &models.APITokenModel{DB: db},
+		statuses:       &models.StatusModel{DB: db},
+		labels:         &models.LabelModel{DB: db},
+		checklists:     &models.ChecklistModel{DB: db},
+		dependencies:   &models.DependencyModel{DB: db},
+		invitations:    &models.InvitationModel{DB: db},
+		inviteLinks:    &models.InviteLinkModel{DB: db},
+		limits:         &models.LimitModel{DB: db},
+		userTokens:     &models.UserTokenModel{DB: db},
+		twoFactor:      &models.TwoFactorModel{DB: db},
+		authEvents:     &models.AuthEventModel{DB: db},
+		mailer:         mailer.New(transport, *mailSender),
+		baseURL:        *baseURL,
+		trashRetention: *trashRetention,
 		templateCache:  templateCache,
 		formDecoder:    formDecoder,
 		sessionManager: sessionManager,
@@ -80,6 +137,8 @@ func main() {
 		WriteTimeout: 10 * time.Second,
 	}
 
+	go app.purgeTrash(time.Hour)
+
 	logger.Info("starting server", "addr", *addr)
 
 	err = srv.ListenAndServeTLS("./tls/cert.pem", "./tls/key.pem")
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/andres085/task_manager/internal/models"
 	"github.com/justinas/nosurf"
@@ -68,7 +69,33 @@ func (app *application) requireAuthentication(next http.Handler) http.Handler {
 	})
 }
 
+func (app *application) requireAPIAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.isAuthenticated(r) {
+			app.errorJSON(w, r, http.StatusUnauthorized, "you must be authenticated to access this resource")
+			return
+		}
+
+		w.Header().Add("Cache-Control", "no-store")
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func noSurf(next http.Handler) http.Handler {
+	return newCSRFHandler(next)
+}
+
+// noSurfAPI is noSurf for the /api/v1 routes. Requests carrying a bearer token
+// aren't sent by the browser on its own, so they don't need a CSRF token.
+func noSurfAPI(next http.Handler) http.Handler {
+	csrfHandler := newCSRFHandler(next)
+	csrfHandler.ExemptFunc(hasBearerToken)
+
+	return csrfHandler
+}
+
+func newCSRFHandler(next http.Handler) *nosurf.CSRFHandler {
 	csrfHandler := nosurf.New(next)
 	csrfHandler.SetBaseCookie(http.Cookie{
 		HttpOnly: true,
@@ -79,118 +106,131 @@ func noSurf(next http.Handler) http.Handler {
 	return csrfHandler
 }
 
-func (app *application) authenticate(next http.Handler) http.Handler {
+// authenticateAPI accepts a bearer token in place of the session on the /api/v1 routes.
+func (app *application) authenticateAPI(next http.Handler) http.Handler {
+	sessionAuthentication := app.authenticate(next)
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		id := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
-		if id == 0 {
-			next.ServeHTTP(w, r)
+		authorizationHeader := r.Header.Get("Authorization")
+		if authorizationHeader == "" {
+			sessionAuthentication.ServeHTTP(w, r)
 			return
 		}
 
-		exists, err := app.users.Exists(id)
-		if err != nil {
-			app.serverError(w, r, err)
+		token, found := strings.CutPrefix(authorizationHeader, "Bearer ")
+		if !found || token == "" {
+			app.invalidAuthenticationTokenJSON(w, r)
 			return
 		}
 
-		if exists {
-			ctx := context.WithValue(r.Context(), isAuthenticatedContextKey, true)
-			ctx = context.WithValue(ctx, userIDContextKey, id)
-			r = r.WithContext(ctx)
+		userId, err := app.apiTokens.GetUserID(token)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) {
+				app.invalidAuthenticationTokenJSON(w, r)
+			} else {
+				app.serverError(w, r, err)
+			}
+			return
 		}
 
-		next.ServeHTTP(w, r)
+		ctx := context.WithValue(r.Context(), isAuthenticatedContextKey, true)
+		ctx = context.WithValue(ctx, userIDContextKey, userId)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-func (app *application) checkWorkspaceMembership(next http.Handler) http.Handler {
+func (app *application) authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		userId := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
-		if userId == 0 {
+		id := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+		if id == 0 {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		workspaceId, err := strconv.Atoi(r.PathValue("id"))
-		if err != nil || workspaceId < 1 {
-			http.NotFound(w, r)
-			return
-		}
-
-		isOwner, err := app.workspaces.ValidateOwnership(userId, workspaceId)
+		exists, err := app.users.Exists(id)
 		if err != nil {
 			app.serverError(w, r, err)
 			return
 		}
 
-		if !isOwner {
-			http.NotFound(w, r)
-			return
+		if exists {
+			ctx := context.WithValue(r.Context(), isAuthenticatedContextKey, true)
+			ctx = context.WithValue(ctx, userIDContextKey, id)
+			r = r.WithContext(ctx)
 		}
+
 		next.ServeHTTP(w, r)
 	})
 }
 
-func (app *application) checkWorkspaceAdmin(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		userId := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
-		if userId == 0 {
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		workspaceId, err := strconv.Atoi(r.PathValue("id"))
-		if err != nil || workspaceId < 1 {
-			http.NotFound(w, r)
-			return
-		}
+// requireWorkspacePermission checks the action against the user's role in the {id} workspace.
+// Non-members get a 404 on read-only and personal routes so workspace ids can't be probed.
+func (app *application) requireWorkspacePermission(action models.Action) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userId := app.authenticatedUserID(r)
+			if userId == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-		isAdmin, err := app.workspaces.ValidateAdmin(userId, workspaceId)
-		if err != nil {
-			if errors.Is(err, models.ErrNoRecord) {
+			workspaceId, err := strconv.Atoi(r.PathValue("id"))
+			if err != nil || workspaceId < 1 {
 				http.NotFound(w, r)
-			} else {
-				app.serverError(w, r, err)
+				return
 			}
-			return
-		}
 
-		if !isAdmin {
-			http.Error(w, "Forbidden", http.StatusForbidden)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
-}
+			perm, err := app.workspacePermission(userId, workspaceId, action)
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
 
-func (app *application) checkTaskAdmin(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		userId := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
-		if userId == 0 {
+			if !perm.Allowed {
+				if !perm.Member && (action == models.ActionView || action == models.ActionParticipate) {
+					http.NotFound(w, r)
+				} else {
+					http.Error(w, "Forbidden", http.StatusForbidden)
+				}
+				return
+			}
 			next.ServeHTTP(w, r)
-			return
-		}
+		})
+	}
+}
 
-		taskId, err := strconv.Atoi(r.PathValue("id"))
-		if err != nil || taskId < 1 {
-			http.NotFound(w, r)
-			return
-		}
+// requireTaskPermission checks the action against the user's role in the workspace of the {id} task.
+func (app *application) requireTaskPermission(action models.Action) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userId := app.authenticatedUserID(r)
+			if userId == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-		isOwner, err := app.tasks.ValidateAdmin(userId, taskId)
-		if err != nil {
-			if errors.Is(err, models.ErrNoRecord) {
+			taskId, err := strconv.Atoi(r.PathValue("id"))
+			if err != nil || taskId < 1 {
 				http.NotFound(w, r)
-			} else {
+				return
+			}
+
+			perm, err := app.taskPermission(userId, taskId, action)
+			if err != nil {
 				app.serverError(w, r, err)
+				return
 			}
-			return
-		}
 
-		if !isOwner {
-			http.NotFound(w, r)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+			if !perm.Member {
+				http.NotFound(w, r)
+				return
+			}
+
+			if !perm.Allowed {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
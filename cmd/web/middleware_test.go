@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/andres085/task_manager/internal/assert"
+	"github.com/andres085/task_manager/internal/models"
 	"github.com/andres085/task_manager/internal/models/mocks"
 )
 
@@ -154,6 +155,27 @@ func TestRequireAuthentication(t *testing.T) {
 		assert.Equal(t, rr.Body.String(), "Authenticated")
 		assert.Equal(t, rr.Header().Get("Cache-Control"), "no-store")
 	})
+
+	t.Run("Bearer token on an HTML route", func(t *testing.T) {
+		app := newTestApplication(t)
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		r, err := http.NewRequest(http.MethodGet, ts.URL+"/workspace/view/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Header.Set("Authorization", "Bearer VALIDTOKEN")
+
+		rs, err := ts.Client().Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rs.Body.Close()
+
+		assert.Equal(t, rs.StatusCode, http.StatusSeeOther)
+		assert.Equal(t, rs.Header.Get("Location"), "/user/login")
+	})
 }
 
 func TestNosurf(t *testing.T) {
@@ -278,7 +300,7 @@ func TestWorkspaceOwnership(t *testing.T) {
 			w.Write([]byte("OK"))
 		})
 
-		app.checkWorkspaceMembership(next).ServeHTTP(rr, req)
+		app.requireWorkspacePermission(models.ActionView)(next).ServeHTTP(rr, req)
 
 		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
 	})
@@ -305,7 +327,7 @@ func TestWorkspaceOwnership(t *testing.T) {
 			w.Write([]byte("Workspace View"))
 		})
 
-		app.checkWorkspaceMembership(next).ServeHTTP(rr, req)
+		app.requireWorkspacePermission(models.ActionView)(next).ServeHTTP(rr, req)
 		assert.Equal(t, rr.Result().StatusCode, http.StatusNotFound)
 	})
 
@@ -332,7 +354,7 @@ func TestWorkspaceOwnership(t *testing.T) {
 			w.Write([]byte("Workspace View"))
 		})
 
-		app.checkWorkspaceMembership(next).ServeHTTP(rr, req)
+		app.requireWorkspacePermission(models.ActionView)(next).ServeHTTP(rr, req)
 		assert.Equal(t, rr.Result().StatusCode, http.StatusNotFound)
 	})
 
@@ -359,7 +381,7 @@ func TestWorkspaceOwnership(t *testing.T) {
 			w.Write([]byte("Workspace View"))
 		})
 
-		app.checkWorkspaceMembership(next).ServeHTTP(rr, req)
+		app.requireWorkspacePermission(models.ActionView)(next).ServeHTTP(rr, req)
 		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
 	})
 }
@@ -385,7 +407,7 @@ func TestWorkspaceAdmin(t *testing.T) {
 			w.Write([]byte("OK"))
 		})
 
-		app.checkWorkspaceAdmin(next).ServeHTTP(rr, req)
+		app.requireWorkspacePermission(models.ActionManageWorkspace)(next).ServeHTTP(rr, req)
 
 		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
 	})
@@ -412,7 +434,7 @@ func TestWorkspaceAdmin(t *testing.T) {
 			w.Write([]byte("Workspace View"))
 		})
 
-		app.checkWorkspaceAdmin(next).ServeHTTP(rr, req)
+		app.requireWorkspacePermission(models.ActionManageWorkspace)(next).ServeHTTP(rr, req)
 		assert.Equal(t, rr.Result().StatusCode, http.StatusNotFound)
 	})
 
@@ -439,7 +461,7 @@ func TestWorkspaceAdmin(t *testing.T) {
 			w.Write([]byte("Workspace View"))
 		})
 
-		app.checkWorkspaceAdmin(next).ServeHTTP(rr, req)
+		app.requireWorkspacePermission(models.ActionManageWorkspace)(next).ServeHTTP(rr, req)
 		assert.Equal(t, rr.Result().StatusCode, http.StatusForbidden)
 	})
 
@@ -466,7 +488,34 @@ func TestWorkspaceAdmin(t *testing.T) {
 			w.Write([]byte("Workspace View"))
 		})
 
-		app.checkWorkspaceAdmin(next).ServeHTTP(rr, req)
+		app.requireWorkspacePermission(models.ActionManageWorkspace)(next).ServeHTTP(rr, req)
 		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
 	})
+
+	t.Run("Viewer", func(t *testing.T) {
+		app := newTestApplication(t)
+		rr := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodGet, "/workspace/update/7", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, err := app.sessionManager.Load(req.Context(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		app.sessionManager.Put(ctx, "authenticatedUserID", 1)
+
+		req.SetPathValue("id", "7")
+		req = req.WithContext(ctx)
+
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("Workspace Update"))
+		})
+
+		app.requireWorkspacePermission(models.ActionManageWorkspace)(next).ServeHTTP(rr, req)
+		assert.Equal(t, rr.Result().StatusCode, http.StatusForbidden)
+	})
 }
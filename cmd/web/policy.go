@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/andres085/task_manager/internal/models"
+)
+
+type permission struct {
+	Role     string
+	Member   bool
+	Archived bool
+	Allowed  bool
+}
+
+// Every authorization decision goes through policy: the user's membership is looked up once
+// and checked against the permission matrix in models.Can. Archived workspaces are read-only,
+// so only the actions in models.CanArchived stay allowed there.
+func policy(m models.Membership, err error, action models.Action) (permission, error) {
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return permission{}, nil
+		}
+		return permission{}, err
+	}
+
+	perm := permission{Role: m.Role, Member: true, Archived: m.Archived}
+	perm.Allowed = perm.can(action)
+	return perm, nil
+}
+
+func (p permission) can(action models.Action) bool {
+	return models.Can(p.Role, action) && (!p.Archived || models.CanArchived(action))
+}
+
+func (app *application) workspacePermission(userId, workspaceId int, action models.Action) (permission, error) {
+	membership, err := app.workspaces.GetMembership(userId, workspaceId)
+	return policy(membership, err, action)
+}
+
+func (app *application) taskPermission(userId, taskId int, action models.Action) (permission, error) {
+	membership, err := app.tasks.GetMembership(userId, taskId)
+	return policy(membership, err, action)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"net/http"
 
+	"github.com/andres085/task_manager/internal/models"
 	"github.com/andres085/task_manager/ui"
 	"github.com/justinas/alice"
 )
@@ -14,39 +15,155 @@ func (app *application) routes() http.Handler {
 
 	dynamic := alice.New(app.sessionManager.LoadAndSave, noSurf, app.authenticate)
 	protected := dynamic.Append(app.requireAuthentication)
-	workspaceMembership := protected.Append(app.checkWorkspaceMembership)
-	workspaceAdminPermission := protected.Append(app.checkWorkspaceAdmin)
-	taskAdminPermission := protected.Append(app.checkTaskAdmin)
+	workspaceViewer := protected.Append(app.requireWorkspacePermission(models.ActionView))
+	workspaceParticipant := protected.Append(app.requireWorkspacePermission(models.ActionParticipate))
+	workspaceEditor := protected.Append(app.requireWorkspacePermission(models.ActionEditTasks))
+	workspaceAdmin := protected.Append(app.requireWorkspacePermission(models.ActionManageWorkspace))
+	workspaceOwner := protected.Append(app.requireWorkspacePermission(models.ActionDeleteWorkspace))
+	workspaceTransfer := protected.Append(app.requireWorkspacePermission(models.ActionTransferOwnership))
+	workspaceArchiver := protected.Append(app.requireWorkspacePermission(models.ActionArchiveWorkspace))
+	workspaceTrash := protected.Append(app.requireWorkspacePermission(models.ActionDeleteTasks))
+	taskParticipant := protected.Append(app.requireTaskPermission(models.ActionParticipate))
+	taskEditor := protected.Append(app.requireTaskPermission(models.ActionEditTasks))
+	taskAdmin := protected.Append(app.requireTaskPermission(models.ActionDeleteTasks))
+	api := alice.New(app.sessionManager.LoadAndSave, noSurfAPI, app.authenticateAPI, app.requireAPIAuthentication)
 
 	mux.HandleFunc("GET /ping", app.ping)
 
 	mux.Handle("GET /{$}", dynamic.ThenFunc(app.home))
 
+	mux.Handle("GET /tasks", protected.ThenFunc(app.taskDashboard))
 	mux.Handle("GET /task/view/{id}", protected.ThenFunc(app.taskView))
-	mux.Handle("GET /task/update/{id}", protected.ThenFunc(app.taskUpdate))
-	mux.Handle("GET /workspace/{id}/task/create", workspaceMembership.ThenFunc(app.taskCreate))
+	mux.Handle("GET /task/update/{id}", taskEditor.ThenFunc(app.taskUpdate))
+	mux.Handle("GET /workspace/{id}/task/create", workspaceEditor.ThenFunc(app.taskCreate))
 	mux.Handle("POST /task/create", protected.ThenFunc(app.taskCreatePost))
-	mux.Handle("POST /task/update/{id}", protected.ThenFunc(app.taskUpdatePost))
-	mux.Handle("POST /workspace/{workspaceId}/task/delete/{id}", taskAdminPermission.ThenFunc(app.taskDelete))
+	mux.Handle("POST /task/update/{id}", taskEditor.ThenFunc(app.taskUpdatePost))
+	mux.Handle("POST /workspace/{workspaceId}/task/delete/{id}", taskAdmin.ThenFunc(app.taskDelete))
+
+	mux.Handle("POST /task/{id}/status/update", taskEditor.ThenFunc(app.taskStatusUpdatePost))
+	mux.Handle("POST /task/archive/{id}", taskEditor.ThenFunc(app.taskArchivePost))
+	mux.Handle("POST /task/unarchive/{id}", taskEditor.ThenFunc(app.taskUnarchivePost))
+	mux.Handle("POST /task/watch/{id}", taskParticipant.ThenFunc(app.taskWatchPost))
+	mux.Handle("POST /task/unwatch/{id}", taskParticipant.ThenFunc(app.taskUnwatchPost))
+
+	mux.Handle("POST /task/{id}/comments/create", taskEditor.ThenFunc(app.commentCreatePost))
+	mux.Handle("POST /task/{id}/comments/{commentId}/update", taskEditor.ThenFunc(app.commentUpdatePost))
+	mux.Handle("POST /task/{id}/comments/{commentId}/delete", taskEditor.ThenFunc(app.commentDeletePost))
+	mux.Handle("POST /task/{id}/checklist/create", taskEditor.ThenFunc(app.checklistItemCreatePost))
+	mux.Handle("POST /task/{id}/checklist/{itemId}/toggle", taskEditor.ThenFunc(app.checklistItemTogglePost))
+	mux.Handle("POST /task/{id}/checklist/{itemId}/delete", taskEditor.ThenFunc(app.checklistItemDeletePost))
+	mux.Handle("POST /task/{id}/dependencies/create", taskEditor.ThenFunc(app.dependencyCreatePost))
+	mux.Handle("POST /task/{id}/dependencies/{blockerId}/delete", taskEditor.ThenFunc(app.dependencyDeletePost))
 
 	mux.Handle("GET /workspace/view", protected.ThenFunc(app.workspaceViewAll))
-	mux.Handle("GET /workspace/view/{id}", workspaceMembership.ThenFunc(app.workspaceView))
+	mux.Handle("GET /workspace/view/{id}", workspaceViewer.ThenFunc(app.workspaceView))
 	mux.Handle("GET /workspace/view/{id}/tasks", protected.ThenFunc(app.taskViewAll))
+	mux.Handle("GET /workspace/view/{id}/board", workspaceViewer.ThenFunc(app.workspaceBoard))
+	mux.Handle("GET /workspace/view/{id}/trash", workspaceTrash.ThenFunc(app.workspaceTrash))
 	mux.Handle("GET /workspace/create", protected.ThenFunc(app.workspaceCreate))
-	mux.Handle("GET /workspace/update/{id}", workspaceAdminPermission.ThenFunc(app.workspaceUpdate))
-	mux.Handle("GET /workspace/{id}/user/add", workspaceAdminPermission.ThenFunc(app.workspaceAddUser))
+	mux.Handle("GET /workspace/update/{id}", workspaceAdmin.ThenFunc(app.workspaceUpdate))
+	mux.Handle("GET /workspace/{id}/user/add", workspaceAdmin.ThenFunc(app.workspaceAddUser))
 	mux.Handle("POST /workspace/create", protected.ThenFunc(app.workspaceCreatePost))
-	mux.Handle("POST /workspace/update/{id}", workspaceAdminPermission.ThenFunc(app.workspaceUpdatePost))
-	mux.Handle("POST /workspace/delete/{id}", workspaceAdminPermission.ThenFunc(app.workspaceDelete))
-	mux.Handle("POST /workspace/{id}/user/add", workspaceAdminPermission.ThenFunc(app.workspaceAddUserPost))
-	mux.Handle("POST /workspace/{id}/user/remove/{userId}", workspaceAdminPermission.ThenFunc(app.workspaceRemoveUserPost))
+	mux.Handle("POST /workspace/update/{id}", workspaceAdmin.ThenFunc(app.workspaceUpdatePost))
+	mux.Handle("POST /workspace/delete/{id}", workspaceOwner.ThenFunc(app.workspaceDelete))
+	mux.Handle("POST /workspace/archive/{id}", workspaceArchiver.ThenFunc(app.workspaceArchivePost))
+	mux.Handle("POST /workspace/unarchive/{id}", workspaceArchiver.ThenFunc(app.workspaceUnarchivePost))
+	mux.Handle("POST /workspace/restore/{id}", protected.ThenFunc(app.workspaceRestorePost))
+	mux.Handle("POST /workspace/purge/{id}", protected.ThenFunc(app.workspacePurgePost))
+	mux.Handle("POST /workspace/{id}/tasks/archive", workspaceEditor.ThenFunc(app.workspaceArchiveTasksPost))
+	mux.Handle("POST /workspace/{id}/trash/{taskId}/restore", workspaceTrash.ThenFunc(app.taskRestorePost))
+	mux.Handle("POST /workspace/{id}/trash/{taskId}/purge", workspaceTrash.ThenFunc(app.taskPurgePost))
+	mux.Handle("POST /workspace/{id}/user/add", workspaceAdmin.ThenFunc(app.workspaceAddUserPost))
+	mux.Handle("POST /workspace/{id}/user/remove/{userId}", workspaceAdmin.ThenFunc(app.workspaceRemoveUserPost))
+	mux.Handle("POST /workspace/{id}/user/leave", workspaceParticipant.ThenFunc(app.workspaceLeavePost))
+	mux.Handle("POST /workspace/{id}/user/role/{userId}", workspaceAdmin.ThenFunc(app.workspaceUserRolePost))
+	mux.Handle("POST /workspace/{id}/user/transfer/{userId}", workspaceTransfer.ThenFunc(app.workspaceTransferOwnershipPost))
+	mux.Handle("POST /workspace/{id}/invitations/{invitationId}/delete", workspaceAdmin.ThenFunc(app.workspaceInvitationDeletePost))
+	mux.Handle("POST /workspace/{id}/invite-links/create", workspaceAdmin.ThenFunc(app.workspaceInviteLinkCreatePost))
+	mux.Handle("POST /workspace/{id}/invite-links/{linkId}/delete", workspaceAdmin.ThenFunc(app.workspaceInviteLinkDeletePost))
+	mux.Handle("GET /workspace/{id}/statuses/edit", workspaceAdmin.ThenFunc(app.workspaceStatuses))
+	mux.Handle("POST /workspace/{id}/statuses/create", workspaceAdmin.ThenFunc(app.workspaceStatusCreatePost))
+	mux.Handle("POST /workspace/{id}/statuses/{statusId}/update", workspaceAdmin.ThenFunc(app.workspaceStatusUpdatePost))
+	mux.Handle("POST /workspace/{id}/statuses/{statusId}/move", workspaceAdmin.ThenFunc(app.workspaceStatusMovePost))
+	mux.Handle("POST /workspace/{id}/statuses/{statusId}/delete", workspaceAdmin.ThenFunc(app.workspaceStatusDeletePost))
+	mux.Handle("GET /workspace/{id}/labels/edit", workspaceAdmin.ThenFunc(app.workspaceLabels))
+	mux.Handle("POST /workspace/{id}/labels/create", workspaceAdmin.ThenFunc(app.workspaceLabelCreatePost))
+	mux.Handle("POST /workspace/{id}/labels/{labelId}/update", workspaceAdmin.ThenFunc(app.workspaceLabelUpdatePost))
+	mux.Handle("POST /workspace/{id}/labels/{labelId}/delete", workspaceAdmin.ThenFunc(app.workspaceLabelDeletePost))
 
 	mux.Handle("GET /user/register", dynamic.ThenFunc(app.userSignUp))
 	mux.Handle("POST /user/register", dynamic.ThenFunc(app.userSignUpPost))
 	mux.Handle("GET /user/login", dynamic.ThenFunc(app.userLogin))
 	mux.Handle("POST /user/login", dynamic.ThenFunc(app.userLoginPost))
+	mux.Handle("GET /user/login/2fa", dynamic.ThenFunc(app.userLoginTwoFactor))
+	mux.Handle("POST /user/login/2fa", dynamic.ThenFunc(app.userLoginTwoFactorPost))
+	mux.Handle("GET /user/verify", dynamic.ThenFunc(app.userVerify))
+	mux.Handle("POST /user/verify", dynamic.ThenFunc(app.userVerifyPost))
+	mux.Handle("GET /user/verify/resend", dynamic.ThenFunc(app.userVerifyResend))
+	mux.Handle("POST /user/verify/resend", dynamic.ThenFunc(app.userVerifyResendPost))
+	mux.Handle("GET /user/password/forgot", dynamic.ThenFunc(app.userPasswordForgot))
+	mux.Handle("POST /user/password/forgot", dynamic.ThenFunc(app.userPasswordForgotPost))
+	mux.Handle("GET /user/password/reset", dynamic.ThenFunc(app.userPasswordReset))
+	mux.Handle("POST /user/password/reset", dynamic.ThenFunc(app.userPasswordResetPost))
 	mux.Handle("POST /user/logout", protected.ThenFunc(app.userLogoutPost))
 
+	mux.Handle("GET /invite/{token}", dynamic.ThenFunc(app.inviteLinkView))
+	mux.Handle("POST /invite/{token}", protected.ThenFunc(app.inviteLinkJoinPost))
+	mux.Handle("GET /invitations", protected.ThenFunc(app.invitationsView))
+	mux.Handle("POST /invitations/{id}/accept", protected.ThenFunc(app.invitationAcceptPost))
+	mux.Handle("POST /invitations/{id}/decline", protected.ThenFunc(app.invitationDeclinePost))
+
+	mux.Handle("GET /account/settings", protected.ThenFunc(app.accountSettings))
+	mux.Handle("POST /account/profile", protected.ThenFunc(app.accountProfilePost))
+	mux.Handle("POST /account/email", protected.ThenFunc(app.accountEmailPost))
+	mux.Handle("GET /account/email/confirm", dynamic.ThenFunc(app.accountEmailConfirm))
+	mux.Handle("POST /account/email/confirm", dynamic.ThenFunc(app.accountEmailConfirmPost))
+	mux.Handle("POST /account/password", protected.ThenFunc(app.accountPasswordPost))
+
+	mux.Handle("GET /account/2fa", protected.ThenFunc(app.accountTwoFactor))
+	mux.Handle("POST /account/2fa/setup", protected.ThenFunc(app.accountTwoFactorSetupPost))
+	mux.Handle("GET /account/2fa/qr", protected.ThenFunc(app.accountTwoFactorQR))
+	mux.Handle("POST /account/2fa/enable", protected.ThenFunc(app.accountTwoFactorEnablePost))
+	mux.Handle("POST /account/2fa/disable", protected.ThenFunc(app.accountTwoFactorDisablePost))
+
+	mux.Handle("GET /account/tokens", protected.ThenFunc(app.accountTokens))
+	mux.Handle("POST /account/tokens/create", protected.ThenFunc(app.accountTokenCreatePost))
+	mux.Handle("POST /account/tokens/{id}/delete", protected.ThenFunc(app.accountTokenDeletePost))
+
+	mux.Handle("GET /api/v1/workspaces", api.ThenFunc(app.apiWorkspaceList))
+	mux.Handle("POST /api/v1/workspaces", api.ThenFunc(app.apiWorkspaceCreate))
+	mux.Handle("GET /api/v1/workspaces/{id}", api.ThenFunc(app.apiWorkspaceGet))
+	mux.Handle("PUT /api/v1/workspaces/{id}", api.ThenFunc(app.apiWorkspaceUpdate))
+	mux.Handle("DELETE /api/v1/workspaces/{id}", api.ThenFunc(app.apiWorkspaceDelete))
+	mux.Handle("POST /api/v1/workspaces/{id}/restore", api.ThenFunc(app.apiWorkspaceRestore))
+	mux.Handle("POST /api/v1/workspaces/{id}/archive", api.ThenFunc(app.apiWorkspaceArchive))
+	mux.Handle("POST /api/v1/workspaces/{id}/unarchive", api.ThenFunc(app.apiWorkspaceUnarchive))
+	mux.Handle("GET /api/v1/workspaces/{id}/trash", api.ThenFunc(app.apiWorkspaceTrash))
+	mux.Handle("POST /api/v1/workspaces/{id}/trash/{taskId}/restore", api.ThenFunc(app.apiTaskRestore))
+	mux.Handle("DELETE /api/v1/workspaces/{id}/trash/{taskId}", api.ThenFunc(app.apiTaskPurge))
+	mux.Handle("GET /api/v1/workspaces/{id}/users", api.ThenFunc(app.apiWorkspaceUsers))
+	mux.Handle("POST /api/v1/workspaces/{id}/users", api.ThenFunc(app.apiWorkspaceAddUser))
+	mux.Handle("DELETE /api/v1/workspaces/{id}/users/{userId}", api.ThenFunc(app.apiWorkspaceRemoveUser))
+	mux.Handle("PUT /api/v1/workspaces/{id}/users/{userId}", api.ThenFunc(app.apiWorkspaceUpdateUserRole))
+	mux.Handle("POST /api/v1/workspaces/{id}/users/{userId}/transfer", api.ThenFunc(app.apiWorkspaceTransferOwnership))
+	mux.Handle("POST /api/v1/workspaces/{id}/leave", api.ThenFunc(app.apiWorkspaceLeave))
+	mux.Handle("GET /api/v1/workspaces/{id}/statuses", api.ThenFunc(app.apiWorkspaceStatuses))
+	mux.Handle("GET /api/v1/workspaces/{id}/labels", api.ThenFunc(app.apiWorkspaceLabels))
+	mux.Handle("GET /api/v1/workspaces/{id}/tasks", api.ThenFunc(app.apiTaskList))
+	mux.Handle("POST /api/v1/workspaces/{id}/tasks", api.ThenFunc(app.apiTaskCreate))
+	mux.Handle("POST /api/v1/workspaces/{id}/tasks/archive", api.ThenFunc(app.apiWorkspaceArchiveTasks))
+	mux.Handle("GET /api/v1/invitations", api.ThenFunc(app.apiInvitationList))
+	mux.Handle("POST /api/v1/invitations/{id}/accept", api.ThenFunc(app.apiInvitationAccept))
+	mux.Handle("POST /api/v1/invitations/{id}/decline", api.ThenFunc(app.apiInvitationDecline))
+	mux.Handle("GET /api/v1/tasks", api.ThenFunc(app.apiTaskAssigned))
+	mux.Handle("GET /api/v1/tasks/{id}", api.ThenFunc(app.apiTaskGet))
+	mux.Handle("PUT /api/v1/tasks/{id}", api.ThenFunc(app.apiTaskUpdate))
+	mux.Handle("DELETE /api/v1/tasks/{id}", api.ThenFunc(app.apiTaskDelete))
+	mux.Handle("POST /api/v1/tasks/{id}/archive", api.ThenFunc(app.apiTaskArchive))
+	mux.Handle("POST /api/v1/tasks/{id}/unarchive", api.ThenFunc(app.apiTaskUnarchive))
+	mux.Handle("POST /api/v1/tasks/{id}/watch", api.ThenFunc(app.apiTaskWatch))
+	mux.Handle("DELETE /api/v1/tasks/{id}/watch", api.ThenFunc(app.apiTaskUnwatch))
+
 	standard := alice.New(app.recoverPanic, app.logRequest, commonHeaders)
 
 	return standard.Then(mux)
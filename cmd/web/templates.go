@@ -1,10 +1,12 @@
 package main
 
 import (
+	"fmt"
 	"html/template"
 	"io/fs"
 	"net/http"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"time"
 
@@ -12,28 +14,73 @@ import (
 	"github.com/andres085/task_manager/ui"
 )
 
+type boardColumn struct {
+	Status models.Status
+	Tasks  []models.Task
+}
+
+type taskGroup struct {
+	WorkspaceID    int
+	WorkspaceTitle string
+	Columns        []boardColumn
+}
+
 type templateData struct {
-	CurrentYear       int
-	Task              models.Task
-	Tasks             []models.Task
-	Workspace         models.Workspace
-	OwnedWorkspaces   []models.Workspace
-	InvitedWorkspaces []models.Workspace
-	User              *models.User
-	WorkspaceUsers    []models.UserWithRole
-	Form              any
-	Flash             string
-	IsAuthenticated   bool
-	CSRFToken         string
-	Limit             int
-	CurrentPage       int
-	TotalPages        int
-	WorkspaceLimit    bool
-	IsAdmin           bool
-	TaskOwner         *models.User
-	Filter            string
-	PriorityFilter    string
-	StatusFilter      string
+	CurrentYear        int
+	Task               models.Task
+	Tasks              []models.Task
+	Workspace          models.Workspace
+	OwnedWorkspaces    []models.Workspace
+	InvitedWorkspaces  []models.Workspace
+	TrashedWorkspaces  []models.Workspace
+	User               *models.User
+	WorkspaceUsers     []models.UserWithRole
+	Form               any
+	Flash              string
+	IsAuthenticated    bool
+	CSRFToken          string
+	Limit              int
+	CurrentPage        int
+	TotalPages         int
+	WorkspaceLimit     bool
+	Quota              models.Quota
+	IsAdmin            bool
+	Role               string
+	CanEdit            bool
+	CanDeleteWorkspace bool
+	CanArchive         bool
+	Archived           bool
+	IncludeArchived    bool
+	AssignedFilter     bool
+	WatchingFilter     bool
+	Comments           []models.Comment
+	TaskHistory        []models.TaskEvent
+	CurrentUserID      int
+	Filter             string
+	PriorityFilter     string
+	StatusFilter       string
+	DueFilter          string
+	APITokens          []models.APIToken
+	NewAPIToken        string
+	TwoFactor          *models.TwoFactor
+	RecoveryCodes      []string
+	BoardColumns       []boardColumn
+	TaskGroups         []taskGroup
+	StatusNames        []string
+	Statuses           []models.Status
+	Labels             []models.Label
+	LabelFilter        []int
+	Subtasks           []models.Task
+	Checklist          []models.ChecklistItem
+	Blockers           []models.Task
+	Invitations        []models.Invitation
+	InviteLink         *models.InviteLink
+	InviteLinks        []models.InviteLink
+	NewInviteLink      string
+	Blocking           []models.Task
+	BlockerOptions     []models.Task
+	ParentTask         *models.Task
+	TrashRetention     time.Duration
 }
 
 func humanDate(t time.Time) string {
@@ -44,6 +91,74 @@ func humanDate(t time.Time) string {
 	return t.UTC().Format("02 Jan 2006 at 15:04")
 }
 
+func humanDueDate(date *time.Time, dueTime *string) string {
+	if date == nil {
+		return ""
+	}
+
+	due := date.Format("02 Jan 2006")
+	if dueTime != nil && len(*dueTime) >= 5 {
+		due += " at " + (*dueTime)[:5]
+	}
+
+	return due
+}
+
+func humanDuration(d time.Duration) string {
+	if d >= 24*time.Hour && d%(24*time.Hour) == 0 {
+		days := int(d / (24 * time.Hour))
+		if days == 1 {
+			return "1 day"
+		}
+		return fmt.Sprintf("%d days", days)
+	}
+
+	if d < time.Minute {
+		seconds := int((d + time.Second - 1) / time.Second)
+		if seconds <= 1 {
+			return "1 second"
+		}
+		return fmt.Sprintf("%d seconds", seconds)
+	}
+
+	if d < 30*time.Minute {
+		minutes := int((d + time.Minute - 1) / time.Minute)
+		if minutes == 1 {
+			return "1 minute"
+		}
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+
+	hours := int(d.Round(time.Hour) / time.Hour)
+	if hours == 1 {
+		return "1 hour"
+	}
+	return fmt.Sprintf("%d hours", hours)
+}
+
+func statusBadge(statuses []models.Status, name string) string {
+	for i, status := range statuses {
+		if status.Name != name {
+			continue
+		}
+
+		switch {
+		case status.IsDone:
+			return "bg-success"
+		case i == 0:
+			return "bg-secondary"
+		default:
+			return "bg-info text-dark"
+		}
+	}
+
+	return "bg-light text-dark"
+}
+
+func containsID(ids []int, id int) bool {
+	return slices.Contains(ids, id)
+}
+
 func iterPages(total int) []int {
 	var pages []int
 	for i := 1; i <= total; i++ {
@@ -61,10 +176,15 @@ func sub(a, b int) int {
 }
 
 var functions = template.FuncMap{
-	"humanDate": humanDate,
-	"iterPages": iterPages,
-	"add":       add,
-	"sub":       sub,
+	"humanDate":     humanDate,
+	"humanDueDate":  humanDueDate,
+	"humanDuration": humanDuration,
+	"statusBadge":   statusBadge,
+	"containsID":    containsID,
+	"labelColors":   func() []string { return models.LabelColors },
+	"iterPages":     iterPages,
+	"add":           add,
+	"sub":           sub,
 }
 
 func newTemplateCache() (map[string]*template.Template, error) {
@@ -82,6 +202,9 @@ func newTemplateCache() (map[string]*template.Template, error) {
 			"html/base.html",
 			"html/partials/nav.html",
 			"html/partials/confirmation_modal.html",
+			"html/partials/labels.html",
+			"html/partials/assignees.html",
+			"html/partials/progress.html",
 			page,
 		}
 
@@ -111,3 +234,22 @@ func getPaginationParams(r *http.Request, defaultLimit int) (int, int, int) {
 
 	return limit, page, offset
 }
+
+func getLabelFilter(r *http.Request) []int {
+	var labels []int
+
+	for _, value := range r.URL.Query()["label"] {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 || slices.Contains(labels, id) {
+			continue
+		}
+		labels = append(labels, id)
+	}
+
+	return labels
+}
+
+func getBoolParam(r *http.Request, name string) bool {
+	value, err := strconv.ParseBool(r.URL.Query().Get(name))
+	return err == nil && value
+}
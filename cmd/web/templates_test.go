@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/andres085/task_manager/internal/assert"
+	"github.com/andres085/task_manager/internal/models"
 )
 
 func TestHumanDate(t *testing.T) {
@@ -38,3 +39,130 @@ func TestHumanDate(t *testing.T) {
 		})
 	}
 }
+
+func TestHumanDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		want string
+	}{
+		{
+			name: "Days",
+			d:    30 * 24 * time.Hour,
+			want: "30 days",
+		},
+		{
+			name: "One day",
+			d:    24 * time.Hour,
+			want: "1 day",
+		},
+		{
+			name: "Hours",
+			d:    36 * time.Hour,
+			want: "36 hours",
+		},
+		{
+			name: "One hour",
+			d:    50 * time.Minute,
+			want: "1 hour",
+		},
+		{
+			name: "Minutes",
+			d:    14*time.Minute + 20*time.Second,
+			want: "15 minutes",
+		},
+		{
+			name: "Seconds",
+			d:    1500 * time.Millisecond,
+			want: "2 seconds",
+		},
+		{
+			name: "Under a second",
+			d:    300 * time.Millisecond,
+			want: "1 second",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, humanDuration(tt.d), tt.want)
+		})
+	}
+}
+
+func TestHumanDueDate(t *testing.T) {
+	date := time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)
+	dueTime := "15:30:00"
+
+	tests := []struct {
+		name    string
+		date    *time.Time
+		dueTime *string
+		want    string
+	}{
+		{
+			name: "Date only",
+			date: &date,
+			want: "17 Mar 2024",
+		},
+		{
+			name:    "Date and time",
+			date:    &date,
+			dueTime: &dueTime,
+			want:    "17 Mar 2024 at 15:30",
+		},
+		{
+			name: "Empty",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hd := humanDueDate(tt.date, tt.dueTime)
+
+			assert.Equal(t, hd, tt.want)
+		})
+	}
+}
+
+func TestStatusBadge(t *testing.T) {
+	statuses := []models.Status{
+		{ID: 1, Name: "Backlog"},
+		{ID: 2, Name: "Review"},
+		{ID: 3, Name: "Shipped", IsDone: true},
+	}
+
+	tests := []struct {
+		name   string
+		status string
+		want   string
+	}{
+		{
+			name:   "First status",
+			status: "Backlog",
+			want:   "bg-secondary",
+		},
+		{
+			name:   "In between status",
+			status: "Review",
+			want:   "bg-info text-dark",
+		},
+		{
+			name:   "Done status",
+			status: "Shipped",
+			want:   "bg-success",
+		},
+		{
+			name:   "Unknown status",
+			status: "Legacy",
+			want:   "bg-light text-dark",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, statusBadge(statuses, tt.status), tt.want)
+		})
+	}
+}
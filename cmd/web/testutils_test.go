@@ -10,10 +10,12 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/alexedwards/scs/v2"
+	"github.com/andres085/task_manager/internal/mailer"
 	"github.com/andres085/task_manager/internal/models/mocks"
 	"github.com/go-playground/form/v4"
 )
@@ -41,11 +43,28 @@ func newTestApplication(t *testing.T) *application {
 	sessionManager.Lifetime = 12 * time.Hour
 	sessionManager.Cookie.Secure = true
 
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
 	return &application{
-		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		logger:         logger,
 		tasks:          &mocks.TaskModel{},
 		workspaces:     &mocks.WorkspaceModel{},
 		users:          &mocks.UserModel{},
+		comments:       &mocks.CommentModel{},
+		apiTokens:      &mocks.APITokenModel{},
+		statuses:       &mocks.StatusModel{},
+		labels:         &mocks.LabelModel{},
+		checklists:     &mocks.ChecklistModel{},
+		dependencies:   &mocks.DependencyModel{},
+		invitations:    &mocks.InvitationModel{},
+		inviteLinks:    &mocks.InviteLinkModel{},
+		limits:         &mocks.LimitModel{},
+		userTokens:     &mocks.UserTokenModel{},
+		twoFactor:      &mocks.TwoFactorModel{},
+		authEvents:     &mocks.AuthEventModel{},
+		mailer:         mailer.New(&mailer.LogTransport{Logger: logger}, "Task Manager <no-reply@taskmanager.local>"),
+		baseURL:        "https://localhost:4000",
+		trashRetention: 30 * 24 * time.Hour,
 		templateCache:  templateCache,
 		formDecoder:    formDecoder,
 		sessionManager: sessionManager,
@@ -104,6 +123,45 @@ func (ts *testServer) postForm(t *testing.T, urlPath string, form url.Values) (i
 	return rs.StatusCode, rs.Header, string(body)
 }
 
+func (ts *testServer) doJSON(t *testing.T, method, urlPath, csrfToken, data string) (int, http.Header, string) {
+	req, err := http.NewRequest(method, ts.URL+urlPath, strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-CSRF-Token", csrfToken)
+
+	rs, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rs.Body.Close()
+	body, err := io.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body = bytes.TrimSpace(body)
+
+	return rs.StatusCode, rs.Header, string(body)
+}
+
+// storeSession saves a session for another browser straight into the session store.
+func storeSession(t *testing.T, app *application, token string, values map[string]interface{}) {
+	expiry := time.Now().Add(time.Hour)
+
+	b, err := app.sessionManager.Codec.Encode(expiry, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = app.sessionManager.Store.Commit(token, b, expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func (ts *testServer) loginUser(t *testing.T) {
 	_, _, body := ts.get(t, "/user/login")
 	csrfToken := extractCSRFToken(t, body)
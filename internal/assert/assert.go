@@ -21,6 +21,14 @@ func StringContains(t *testing.T, actual, expectedSubstring string) {
 	}
 }
 
+func StringNotContains(t *testing.T, actual, unexpectedSubstring string) {
+	t.Helper()
+
+	if strings.Contains(actual, unexpectedSubstring) {
+		t.Errorf("got: %q; expected not to contain: %q", actual, unexpectedSubstring)
+	}
+}
+
 func NilError(t *testing.T, actual error) {
 	t.Helper()
 
@@ -0,0 +1,75 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"strings"
+	"text/template"
+)
+
+//go:embed "templates"
+var templateFS embed.FS
+
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Transport delivers a rendered message. Swap it to change how mail leaves the application.
+type Transport interface {
+	Deliver(msg Message) error
+}
+
+type Mailer struct {
+	transport Transport
+	sender    string
+}
+
+func New(transport Transport, sender string) *Mailer {
+	return &Mailer{transport: transport, sender: sender}
+}
+
+// Send renders the "subject" and "body" blocks of templateFile with data and hands the result to the transport.
+func (m *Mailer) Send(recipient, templateFile string, data any) error {
+	tmpl, err := template.New("email").ParseFS(templateFS, "templates/"+templateFile)
+	if err != nil {
+		return err
+	}
+
+	subject := new(bytes.Buffer)
+	err = tmpl.ExecuteTemplate(subject, "subject", data)
+	if err != nil {
+		return err
+	}
+
+	body := new(bytes.Buffer)
+	err = tmpl.ExecuteTemplate(body, "body", data)
+	if err != nil {
+		return err
+	}
+
+	msg := Message{
+		From:    m.sender,
+		To:      recipient,
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimSpace(body.String()) + "\n",
+	}
+
+	return m.transport.Deliver(msg)
+}
+
+func (msg Message) bytes() []byte {
+	var b bytes.Buffer
+
+	b.WriteString("From: " + msg.From + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return b.Bytes()
+}
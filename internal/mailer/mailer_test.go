@@ -0,0 +1,73 @@
+package mailer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andres085/task_manager/internal/assert"
+)
+
+type recordingTransport struct {
+	messages []Message
+}
+
+func (t *recordingTransport) Deliver(msg Message) error {
+	t.messages = append(t.messages, msg)
+	return nil
+}
+
+func TestSend(t *testing.T) {
+	transport := &recordingTransport{}
+	m := New(transport, "Task Manager <no-reply@example.com>")
+
+	data := map[string]any{
+		"FirstName": "Alice",
+		"URL":       "https://example.com/user/password/reset?token=ABC",
+		"Expires":   "1 hour",
+	}
+
+	err := m.Send("alice@example.com", "password_reset.tmpl", data)
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(transport.messages), 1)
+
+	msg := transport.messages[0]
+	assert.Equal(t, msg.From, "Task Manager <no-reply@example.com>")
+	assert.Equal(t, msg.To, "alice@example.com")
+	assert.Equal(t, msg.Subject, "Reset your Task Manager password")
+	assert.StringContains(t, msg.Body, "Hi Alice,")
+	assert.StringContains(t, msg.Body, "https://example.com/user/password/reset?token=ABC")
+	assert.StringContains(t, msg.Body, "expires in 1 hour")
+
+	err = m.Send("alice@example.com", "missing.tmpl", data)
+	if err == nil {
+		t.Error("got: nil; expected an error for a missing template")
+	}
+}
+
+func TestFileTransport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	transport := &FileTransport{Dir: dir}
+
+	err := transport.Deliver(Message{From: "no-reply@example.com", To: "alice@example.com", Subject: "Hello", Body: "Hi there\n"})
+
+	assert.NilError(t, err)
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, len(files), 1)
+
+	content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.StringContains(t, files[0].Name(), "alice_at_example.com.eml")
+	assert.StringContains(t, string(content), "To: alice@example.com\r\n")
+	assert.StringContains(t, string(content), "Subject: Hello\r\n")
+	assert.StringContains(t, string(content), "\r\n\r\nHi there\r\n")
+}
@@ -0,0 +1,64 @@
+package mailer
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogTransport writes every message to the logger instead of sending it. Meant for development.
+type LogTransport struct {
+	Logger *slog.Logger
+}
+
+func (t *LogTransport) Deliver(msg Message) error {
+	t.Logger.Info("email", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// FileTransport saves every message as an .eml file in Dir.
+type FileTransport struct {
+	Dir string
+}
+
+func (t *FileTransport) Deliver(msg Message) error {
+	err := os.MkdirAll(t.Dir, 0o755)
+	if err != nil {
+		return err
+	}
+
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), recipient)
+
+	return os.WriteFile(filepath.Join(t.Dir, name), msg.bytes(), 0o600)
+}
+
+type SMTPTransport struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+func (t *SMTPTransport) Deliver(msg Message) error {
+	var auth smtp.Auth
+	if t.Username != "" {
+		auth = smtp.PlainAuth("", t.Username, t.Password, t.Host)
+	}
+
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+
+	return smtp.SendMail(addr, auth, from.Address, []string{msg.To}, msg.bytes())
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+type APIToken struct {
+	ID       int
+	UserId   int
+	Name     string
+	Created  time.Time
+	LastUsed *time.Time
+}
+
+type APITokenModelInterface interface {
+	Insert(userId int, name string) (string, error)
+	GetAll(userId int) ([]APIToken, error)
+	Delete(id, userId int) (int, error)
+	GetUserID(plaintext string) (int, error)
+}
+
+type APITokenModel struct {
+	DB *sql.DB
+}
+
+func (m *APITokenModel) Insert(userId int, name string) (string, error) {
+	plaintext, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	stmt := `INSERT INTO api_tokens (user_id, name, token_hash, created) VALUES (?, ?, ?, UTC_TIMESTAMP())`
+
+	_, err = m.DB.Exec(stmt, userId, name, hashToken(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return plaintext, nil
+}
+
+func (m *APITokenModel) GetAll(userId int) ([]APIToken, error) {
+	stmt := `SELECT id, user_id, name, created, last_used FROM api_tokens WHERE user_id = ? ORDER BY created DESC, id DESC`
+
+	rows, err := m.DB.Query(stmt, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var tokens []APIToken
+
+	for rows.Next() {
+		var t APIToken
+
+		err = rows.Scan(&t.ID, &t.UserId, &t.Name, &t.Created, &t.LastUsed)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (m *APITokenModel) Delete(id, userId int) (int, error) {
+	stmt := `DELETE FROM api_tokens WHERE id = ? AND user_id = ?`
+
+	result, err := m.DB.Exec(stmt, id, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	r, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(r), nil
+}
+
+func (m *APITokenModel) GetUserID(plaintext string) (int, error) {
+	var id, userId int
+
+	tokenHash := hashToken(plaintext)
+
+	stmt := `SELECT id, user_id FROM api_tokens WHERE token_hash = ?`
+
+	err := m.DB.QueryRow(stmt, tokenHash).Scan(&id, &userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNoRecord
+		}
+		return 0, err
+	}
+
+	_, err = m.DB.Exec(`UPDATE api_tokens SET last_used = UTC_TIMESTAMP() WHERE id = ?`, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return userId, nil
+}
+
+func generateToken() (string, error) {
+	randomBytes := make([]byte, 20)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes), nil
+}
+
+func hashToken(plaintext string) string {
+	hash := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(hash[:])
+}
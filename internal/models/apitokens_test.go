@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andres085/task_manager/internal/assert"
+)
+
+func TestAPITokenInsertMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := APITokenModel{db}
+
+	plaintext, err := m.Insert(2, "Deploy")
+	assert.NilError(t, err)
+	assert.Equal(t, len(plaintext), 32)
+
+	userId, err := m.GetUserID(plaintext)
+	assert.NilError(t, err)
+	assert.Equal(t, userId, 2)
+}
+
+func TestAPITokenGetUserIDMethod(t *testing.T) {
+	tests := []struct {
+		name       string
+		plaintext  string
+		wantUserId int
+		wantErr    error
+	}{
+		{
+			name:       "Valid token",
+			plaintext:  "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567",
+			wantUserId: 1,
+		},
+		{
+			name:      "Unknown token",
+			plaintext: "ABCDEFGHIJKLMNOPQRSTUVWXYZ234568",
+			wantErr:   ErrNoRecord,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			m := APITokenModel{db}
+
+			userId, err := m.GetUserID(tt.plaintext)
+
+			assert.Equal(t, userId, tt.wantUserId)
+			assert.Equal(t, errors.Is(err, tt.wantErr), true)
+		})
+	}
+}
+
+func TestAPITokenGetAllMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := APITokenModel{db}
+
+	tokens, err := m.GetAll(1)
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(tokens), 1)
+	assert.Equal(t, tokens[0].Name, "CI")
+}
+
+func TestAPITokenDeleteMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := APITokenModel{db}
+
+	row, err := m.Delete(1, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, row, 0)
+
+	row, err = m.Delete(1, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, row, 1)
+}
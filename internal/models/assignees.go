@@ -0,0 +1,181 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+)
+
+const (
+	relationAssignee = "ASSIGNEE"
+	relationWatcher  = "WATCHER"
+)
+
+// Watch adds the user to the task's watchers. Only members of the task's workspace can
+// watch it; watching twice is a no-op.
+func (m *TaskModel) Watch(taskId, userId int) error {
+	stmt := `INSERT IGNORE INTO task_users (task_id, user_id, relation)
+	SELECT t.id, uw.user_id, ? FROM tasks t JOIN users_workspaces uw ON uw.workspace_id = t.workspace_id
+	WHERE t.id = ? AND uw.user_id = ? AND t.deleted_at IS NULL`
+
+	result, err := m.DB.Exec(stmt, relationWatcher, taskId, userId)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		var watching bool
+
+		err = m.DB.QueryRow(`SELECT EXISTS (SELECT true FROM task_users WHERE task_id = ? AND user_id = ? AND relation = ?)`, taskId, userId, relationWatcher).Scan(&watching)
+		if err != nil {
+			return err
+		}
+
+		if !watching {
+			return ErrNoRecord
+		}
+	}
+
+	return nil
+}
+
+func (m *TaskModel) Unwatch(taskId, userId int) error {
+	_, err := m.DB.Exec(`DELETE FROM task_users WHERE task_id = ? AND user_id = ? AND relation = ?`, taskId, userId, relationWatcher)
+	return err
+}
+
+// checkAssignees drops duplicates and makes sure there is at least one assignee and that
+// every assignee is a member of the workspace.
+func checkAssignees(tx *sql.Tx, workspaceId int, userIds []int) ([]int, error) {
+	var unique []int
+	seen := make(map[int]bool)
+
+	for _, userId := range userIds {
+		if !seen[userId] {
+			seen[userId] = true
+			unique = append(unique, userId)
+		}
+	}
+
+	if len(unique) == 0 {
+		return nil, ErrInvalidAssignee
+	}
+
+	args := []interface{}{workspaceId}
+	for _, userId := range unique {
+		args = append(args, userId)
+	}
+
+	var members int
+
+	stmt := `SELECT COUNT(*) FROM users_workspaces WHERE workspace_id = ? AND user_id IN (` + placeholders(len(unique)) + `)`
+
+	err := tx.QueryRow(stmt, args...).Scan(&members)
+	if err != nil {
+		return nil, err
+	}
+
+	if members != len(unique) {
+		return nil, ErrInvalidAssignee
+	}
+
+	return unique, nil
+}
+
+func setAssignees(tx *sql.Tx, taskId int, userIds []int) error {
+	_, err := tx.Exec(`DELETE FROM task_users WHERE task_id = ? AND relation = ?`, taskId, relationAssignee)
+	if err != nil {
+		return err
+	}
+
+	for _, userId := range userIds {
+		_, err = tx.Exec(`INSERT INTO task_users (task_id, user_id, relation) VALUES (?, ?, ?)`, taskId, userId, relationAssignee)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// assigneeNames lists the assignees of a task the way they are written to the task history.
+func assigneeNames(tx *sql.Tx, taskId int) (string, error) {
+	stmt := `SELECT CONCAT(u.firstName, ' ', u.lastName) FROM task_users tu JOIN users u ON u.id = tu.user_id
+	WHERE tu.task_id = ? AND tu.relation = ? ORDER BY u.firstName, u.lastName, u.id`
+
+	rows, err := tx.Query(stmt, taskId, relationAssignee)
+	if err != nil {
+		return "", err
+	}
+
+	defer rows.Close()
+
+	var names []string
+
+	for rows.Next() {
+		var name string
+
+		err = rows.Scan(&name)
+		if err != nil {
+			return "", err
+		}
+
+		names = append(names, name)
+	}
+
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	return strings.Join(names, ", "), nil
+}
+
+func attachUsers(db *sql.DB, tasks []Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, len(tasks))
+	index := make(map[int]int, len(tasks))
+
+	for i, t := range tasks {
+		args[i] = t.ID
+		index[t.ID] = i
+		tasks[i].Assignees = []User{}
+		tasks[i].Watchers = []User{}
+	}
+
+	stmt := `SELECT tu.task_id, tu.relation, u.id, u.firstName, u.lastName, u.email, u.created FROM task_users tu
+	JOIN users u ON u.id = tu.user_id WHERE tu.task_id IN (` + placeholders(len(tasks)) + `) ORDER BY u.firstName, u.lastName, u.id`
+
+	rows, err := db.Query(stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskId int
+		var relation string
+		var u User
+
+		err = rows.Scan(&taskId, &relation, &u.ID, &u.FirstName, &u.LastName, &u.Email, &u.Created)
+		if err != nil {
+			return err
+		}
+
+		i := index[taskId]
+		if relation == relationWatcher {
+			tasks[i].Watchers = append(tasks[i].Watchers, u)
+		} else {
+			tasks[i].Assignees = append(tasks[i].Assignees, u)
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"database/sql"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	EventLoginFailed     = "LOGIN_FAILED"
+	EventTwoFactorFailed = "TWO_FACTOR_FAILED"
+	EventLoginSucceeded  = "LOGIN_SUCCEEDED"
+	EventLoginUnverified = "LOGIN_UNVERIFIED"
+	EventTwoFactorNeeded = "TWO_FACTOR_NEEDED"
+	EventLoginThrottled  = "LOGIN_THROTTLED"
+	EventAccountLocked   = "ACCOUNT_LOCKED"
+	EventSignup          = "SIGNUP"
+	EventSignupFailed    = "SIGNUP_FAILED"
+	EventSignupThrottled = "SIGNUP_THROTTLED"
+)
+
+const (
+	LoginWindow            = 15 * time.Minute
+	AccountFreeFailures    = 3
+	AccountLockoutFailures = 10
+	IPFreeFailures         = 10
+	MaxLoginDelay          = 5 * time.Minute
+
+	SignupWindow = time.Hour
+	SignupsPerIP = 5
+)
+
+type AuthEventModelInterface interface {
+	Insert(event, email, ip string) error
+	BeginLoginAttempt(event, email, ip string) (int, time.Duration, bool, error)
+	ResolveLoginAttempt(id int, event string) error
+	LoginThrottle(email, ip string) (time.Duration, bool, error)
+	SignupThrottle(ip string) (time.Duration, error)
+}
+
+// AuthEventModel keeps the audit log of logins and sign ups, which is also what the login and
+// sign up rate limits are worked out from.
+type AuthEventModel struct {
+	DB *sql.DB
+}
+
+func (m *AuthEventModel) Insert(event, email, ip string) error {
+	stmt := `INSERT INTO auth_events (event, email, ip, created) VALUES (?, ?, ?, UTC_TIMESTAMP())`
+
+	_, err := m.DB.Exec(stmt, event, normalizeEmail(email), ip)
+	return err
+}
+
+// BeginLoginAttempt records a login attempt under its failure event before any credentials are
+// checked and returns its id along with the throttle worked out from the events logged before it.
+// Attempts running at the same time therefore always count against each other, even when none of
+// them has finished yet. ResolveLoginAttempt replaces the event once the outcome is known.
+func (m *AuthEventModel) BeginLoginAttempt(event, email, ip string) (int, time.Duration, bool, error) {
+	stmt := `INSERT INTO auth_events (event, email, ip, created) VALUES (?, ?, ?, UTC_TIMESTAMP())`
+
+	result, err := m.DB.Exec(stmt, event, normalizeEmail(email), ip)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	wait, locked, err := m.loginThrottle(email, ip, int(id))
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	return int(id), wait, locked, nil
+}
+
+func (m *AuthEventModel) ResolveLoginAttempt(id int, event string) error {
+	_, err := m.DB.Exec(`UPDATE auth_events SET event = ? WHERE id = ?`, event, id)
+	return err
+}
+
+// LoginThrottle returns how long the next login attempt for email from ip has to wait. Failures
+// count against both the account and the address: past a few of them every new attempt waits
+// twice as long as the previous one, and an account with AccountLockoutFailures failures inside
+// LoginWindow is locked until the oldest of them leaves the window. A successful login clears
+// the account's failures but not the address's. Unknown emails are throttled the same way so the
+// lockout doesn't reveal which accounts exist.
+func (m *AuthEventModel) LoginThrottle(email, ip string) (time.Duration, bool, error) {
+	return m.loginThrottle(email, ip, math.MaxInt32)
+}
+
+// loginThrottle is LoginThrottle counting only the events logged before beforeId.
+func (m *AuthEventModel) loginThrottle(email, ip string, beforeId int) (time.Duration, bool, error) {
+	now := time.Now()
+
+	stmt := `SELECT created FROM auth_events
+	WHERE email = ? AND event IN (?, ?) AND created > DATE_SUB(UTC_TIMESTAMP(), INTERVAL ? SECOND) AND id < ?
+	AND id > COALESCE((SELECT MAX(id) FROM auth_events WHERE email = ? AND event = ? AND id < ?), 0)
+	ORDER BY id DESC LIMIT ?`
+
+	accountFailures, err := m.times(stmt, normalizeEmail(email), EventLoginFailed, EventTwoFactorFailed,
+		int(LoginWindow.Seconds()), beforeId, normalizeEmail(email), EventLoginSucceeded, beforeId, AccountLockoutFailures)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if len(accountFailures) >= AccountLockoutFailures {
+		wait := accountFailures[len(accountFailures)-1].Add(LoginWindow).Sub(now)
+		if wait > 0 {
+			return wait, true, nil
+		}
+	}
+
+	stmt = `SELECT created FROM auth_events
+	WHERE ip = ? AND event IN (?, ?) AND created > DATE_SUB(UTC_TIMESTAMP(), INTERVAL ? SECOND) AND id < ?
+	ORDER BY id DESC LIMIT ?`
+
+	ipFailures, err := m.times(stmt, ip, EventLoginFailed, EventTwoFactorFailed, int(LoginWindow.Seconds()), beforeId, IPFreeFailures+20)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// created only keeps whole seconds, so count every failure from the end of its second.
+	now = now.Add(-time.Second)
+
+	wait := max(backoff(accountFailures, AccountFreeFailures, now), backoff(ipFailures, IPFreeFailures, now))
+
+	return wait, false, nil
+}
+
+// SignupThrottle returns how long ip has to wait before it can try to create another account.
+// Attempts with an email that is already taken count too, so the form can't be used to check
+// addresses in bulk.
+func (m *AuthEventModel) SignupThrottle(ip string) (time.Duration, error) {
+	stmt := `SELECT created FROM auth_events
+	WHERE ip = ? AND event IN (?, ?) AND created > DATE_SUB(UTC_TIMESTAMP(), INTERVAL ? SECOND)
+	ORDER BY id DESC LIMIT ?`
+
+	signups, err := m.times(stmt, ip, EventSignup, EventSignupFailed, int(SignupWindow.Seconds()), SignupsPerIP)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(signups) < SignupsPerIP {
+		return 0, nil
+	}
+
+	return max(signups[len(signups)-1].Add(SignupWindow).Sub(time.Now()), 0), nil
+}
+
+func (m *AuthEventModel) times(stmt string, args ...any) ([]time.Time, error) {
+	rows, err := m.DB.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var times []time.Time
+
+	for rows.Next() {
+		var t time.Time
+
+		err = rows.Scan(&t)
+		if err != nil {
+			return nil, err
+		}
+
+		times = append(times, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return times, nil
+}
+
+// backoff returns what is left of the delay that follows the newest of failures, which doubles
+// with every failure past the free ones, starting at a second.
+func backoff(failures []time.Time, free int, now time.Time) time.Duration {
+	extra := len(failures) - free
+	if extra <= 0 {
+		return 0
+	}
+
+	delay := MaxLoginDelay
+	if extra <= 20 {
+		delay = min(time.Second<<(extra-1), MaxLoginDelay)
+	}
+
+	return max(failures[0].Add(delay).Sub(now), 0)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andres085/task_manager/internal/assert"
+)
+
+func TestAuthEventLoginThrottleMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := AuthEventModel{db}
+
+	fail := func(t *testing.T, email, ip string, n int) {
+		for i := 0; i < n; i++ {
+			err := m.Insert(EventLoginFailed, email, ip)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	wait, locked, err := m.LoginThrottle("alice@example.com", "192.0.2.1")
+	assert.NilError(t, err)
+	assert.Equal(t, wait, time.Duration(0))
+	assert.Equal(t, locked, false)
+
+	fail(t, "alice@example.com", "192.0.2.1", AccountFreeFailures)
+
+	wait, _, err = m.LoginThrottle("alice@example.com", "192.0.2.1")
+	assert.NilError(t, err)
+	assert.Equal(t, wait, time.Duration(0))
+
+	fail(t, "Alice@Example.com", "192.0.2.1", 1)
+
+	wait, locked, err = m.LoginThrottle("alice@example.com", "192.0.2.1")
+	assert.NilError(t, err)
+	assert.Equal(t, wait > 0 && wait <= 3*time.Second, true)
+	assert.Equal(t, locked, false)
+
+	err = m.Insert(EventLoginSucceeded, "alice@example.com", "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wait, _, err = m.LoginThrottle("alice@example.com", "192.0.2.1")
+	assert.NilError(t, err)
+	assert.Equal(t, wait, time.Duration(0))
+
+	for i := 0; i < AccountLockoutFailures; i++ {
+		fail(t, "bob@example.com", fmt.Sprintf("198.51.100.%d", i), 1)
+	}
+
+	wait, locked, err = m.LoginThrottle("bob@example.com", "192.0.2.99")
+	assert.NilError(t, err)
+	assert.Equal(t, wait > LoginWindow-time.Minute, true)
+	assert.Equal(t, locked, true)
+
+	for i := 0; i <= IPFreeFailures; i++ {
+		fail(t, fmt.Sprintf("user%d@example.com", i), "203.0.113.7", 1)
+	}
+
+	wait, locked, err = m.LoginThrottle("nobody@example.com", "203.0.113.7")
+	assert.NilError(t, err)
+	assert.Equal(t, wait > 0, true)
+	assert.Equal(t, locked, false)
+
+	wait, _, err = m.LoginThrottle("nobody@example.com", "203.0.113.8")
+	assert.NilError(t, err)
+	assert.Equal(t, wait, time.Duration(0))
+}
+
+func TestAuthEventLoginAttemptMethods(t *testing.T) {
+	db := newTestDB(t)
+
+	m := AuthEventModel{db}
+
+	var ids []int
+
+	// Attempts still in flight count as failures for the ones that come after them.
+	for i := 0; i <= AccountFreeFailures; i++ {
+		id, wait, _, err := m.BeginLoginAttempt(EventLoginFailed, "alice@example.com", "192.0.2.1")
+		assert.NilError(t, err)
+		assert.Equal(t, wait, time.Duration(0))
+
+		ids = append(ids, id)
+	}
+
+	id, wait, locked, err := m.BeginLoginAttempt(EventLoginFailed, "alice@example.com", "192.0.2.1")
+	assert.NilError(t, err)
+	assert.Equal(t, wait > 0, true)
+	assert.Equal(t, locked, false)
+
+	assert.NilError(t, m.ResolveLoginAttempt(id, EventLoginThrottled))
+
+	for _, id := range ids {
+		assert.NilError(t, m.ResolveLoginAttempt(id, EventLoginSucceeded))
+	}
+
+	wait, _, err = m.LoginThrottle("alice@example.com", "192.0.2.1")
+	assert.NilError(t, err)
+	assert.Equal(t, wait, time.Duration(0))
+}
+
+func TestAuthEventSignupThrottleMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := AuthEventModel{db}
+
+	for i := 0; i < SignupsPerIP; i++ {
+		wait, err := m.SignupThrottle("192.0.2.1")
+		assert.NilError(t, err)
+		assert.Equal(t, wait, time.Duration(0))
+
+		err = m.Insert(EventSignup, fmt.Sprintf("user%d@example.com", i), "192.0.2.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wait, err := m.SignupThrottle("192.0.2.1")
+	assert.NilError(t, err)
+	assert.Equal(t, wait > SignupWindow-time.Minute, true)
+
+	wait, err = m.SignupThrottle("192.0.2.2")
+	assert.NilError(t, err)
+	assert.Equal(t, wait, time.Duration(0))
+}
+
+func TestBackoff(t *testing.T) {
+	now := time.Now()
+
+	failures := func(n int) []time.Time {
+		times := make([]time.Time, n)
+		for i := range times {
+			times[i] = now
+		}
+		return times
+	}
+
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Duration
+	}{
+		{name: "Free failures", failures: 3, want: 0},
+		{name: "First delay", failures: 4, want: time.Second},
+		{name: "Doubles", failures: 6, want: 4 * time.Second},
+		{name: "Capped", failures: 40, want: MaxLoginDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, backoff(failures(tt.failures), 3, now), tt.want)
+		})
+	}
+}
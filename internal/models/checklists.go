@@ -0,0 +1,170 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type ChecklistItem struct {
+	ID      int       `json:"id"`
+	TaskId  int       `json:"task_id"`
+	Content string    `json:"content"`
+	Done    bool      `json:"done"`
+	Created time.Time `json:"created"`
+}
+
+type ChecklistModelInterface interface {
+	Insert(taskId int, content string) (int, error)
+	Get(id int) (ChecklistItem, error)
+	GetAll(taskId int) ([]ChecklistItem, error)
+	SetDone(id int, done bool) error
+	Delete(id int) error
+}
+
+type ChecklistModel struct {
+	DB *sql.DB
+}
+
+func (m *ChecklistModel) Insert(taskId int, content string) (int, error) {
+	stmt := `INSERT INTO checklist_items (task_id, content, done, created) VALUES (?, ?, FALSE, UTC_TIMESTAMP())`
+
+	result, err := m.DB.Exec(stmt, taskId, content)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (m *ChecklistModel) Get(id int) (ChecklistItem, error) {
+	stmt := `SELECT id, task_id, content, done, created FROM checklist_items WHERE id = ?`
+
+	var i ChecklistItem
+
+	err := m.DB.QueryRow(stmt, id).Scan(&i.ID, &i.TaskId, &i.Content, &i.Done, &i.Created)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ChecklistItem{}, ErrNoRecord
+		} else {
+			return ChecklistItem{}, err
+		}
+	}
+
+	return i, nil
+}
+
+func (m *ChecklistModel) GetAll(taskId int) ([]ChecklistItem, error) {
+	stmt := `SELECT id, task_id, content, done, created FROM checklist_items WHERE task_id = ? ORDER BY id`
+
+	rows, err := m.DB.Query(stmt, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var items []ChecklistItem
+
+	for rows.Next() {
+		var i ChecklistItem
+
+		err = rows.Scan(&i.ID, &i.TaskId, &i.Content, &i.Done, &i.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, i)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (m *ChecklistModel) SetDone(id int, done bool) error {
+	_, err := m.DB.Exec(`UPDATE checklist_items SET done = ? WHERE id = ?`, done, id)
+	return err
+}
+
+func (m *ChecklistModel) Delete(id int) error {
+	result, err := m.DB.Exec(`DELETE FROM checklist_items WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func attachProgress(db *sql.DB, tasks []Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, len(tasks))
+	for i, t := range tasks {
+		args[i] = t.ID
+	}
+
+	subtasks, err := countByTask(db, `SELECT parent_id, COUNT(*), COUNT(finished) FROM tasks WHERE deleted_at IS NULL AND parent_id IN (`+placeholders(len(tasks))+`) GROUP BY parent_id`, args)
+	if err != nil {
+		return err
+	}
+
+	checklist, err := countByTask(db, `SELECT task_id, COUNT(*), COUNT(CASE WHEN done THEN 1 END) FROM checklist_items WHERE task_id IN (`+placeholders(len(tasks))+`) GROUP BY task_id`, args)
+	if err != nil {
+		return err
+	}
+
+	for i, t := range tasks {
+		tasks[i].Progress = TaskProgress{
+			SubtasksTotal:  subtasks[t.ID][0],
+			SubtasksDone:   subtasks[t.ID][1],
+			ChecklistTotal: checklist[t.ID][0],
+			ChecklistDone:  checklist[t.ID][1],
+		}
+	}
+
+	return nil
+}
+
+// countByTask returns the total and done counts keyed by task id.
+func countByTask(db *sql.DB, stmt string, args []interface{}) (map[int][2]int, error) {
+	rows, err := db.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	counts := make(map[int][2]int)
+
+	for rows.Next() {
+		var taskId, total, done int
+
+		err = rows.Scan(&taskId, &total, &done)
+		if err != nil {
+			return nil, err
+		}
+
+		counts[taskId] = [2]int{total, done}
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/andres085/task_manager/internal/assert"
+)
+
+func TestChecklistInsertMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := ChecklistModel{db}
+
+	id, err := m.Insert(2, "Check the numbers")
+	assert.NilError(t, err)
+	assert.Equal(t, id, 3)
+
+	item, err := m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, item.TaskId, 2)
+	assert.Equal(t, item.Done, false)
+}
+
+func TestChecklistGetAllMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := ChecklistModel{db}
+
+	items, err := m.GetAll(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(items), 2)
+	assert.Equal(t, items[0].Content, "Write the docs")
+	assert.Equal(t, items[0].Done, true)
+}
+
+func TestChecklistSetDoneMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := ChecklistModel{db}
+
+	err := m.SetDone(2, true)
+	assert.NilError(t, err)
+
+	item, err := m.Get(2)
+	assert.NilError(t, err)
+	assert.Equal(t, item.Done, true)
+}
+
+func TestChecklistDeleteMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := ChecklistModel{db}
+
+	err := m.Delete(1)
+	assert.NilError(t, err)
+
+	err = m.Delete(1)
+	assert.Equal(t, err, ErrNoRecord)
+}
+
+func TestTaskProgress(t *testing.T) {
+	db := newTestDB(t)
+
+	m := TaskModel{db}
+
+	_, err := m.Insert("Open Subtask", "Open Subtask Body", "LOW", "", 1, []int{1}, nil, 1, "", "")
+	assert.NilError(t, err)
+
+	task, err := m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, task.Progress.SubtasksTotal, 1)
+	assert.Equal(t, task.Progress.SubtasksDone, 0)
+	assert.Equal(t, task.Progress.ChecklistTotal, 2)
+	assert.Equal(t, task.Progress.ChecklistDone, 1)
+	assert.Equal(t, task.Progress.Percent(), 33)
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type Comment struct {
+	ID        int
+	TaskId    int
+	UserId    int
+	ParentId  *int
+	Content   string
+	Created   time.Time
+	Updated   *time.Time
+	FirstName string
+	LastName  string
+	Replies   []Comment
+}
+
+type CommentModelInterface interface {
+	Insert(taskId, userId, parentId int, content string) (int, error)
+	Get(id int) (Comment, error)
+	GetAll(taskId int) ([]Comment, error)
+	Update(id int, content string) error
+	Delete(id int) (int, error)
+}
+
+type CommentModel struct {
+	DB *sql.DB
+}
+
+func (m *CommentModel) Insert(taskId, userId, parentId int, content string) (int, error) {
+	var parent sql.NullInt64
+
+	if parentId > 0 {
+		// Replies are kept one level deep, so answering a reply attaches to its root comment.
+		var rootId sql.NullInt64
+
+		stmt := `SELECT parent_id FROM comments WHERE id = ? AND task_id = ?`
+
+		err := m.DB.QueryRow(stmt, parentId, taskId).Scan(&rootId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return 0, ErrNoRecord
+			}
+			return 0, err
+		}
+
+		if rootId.Valid {
+			parent = rootId
+		} else {
+			parent = sql.NullInt64{Int64: int64(parentId), Valid: true}
+		}
+	}
+
+	stmt := `INSERT INTO comments (task_id, user_id, parent_id, content, created) VALUES (?, ?, ?, ?, UTC_TIMESTAMP())`
+
+	result, err := m.DB.Exec(stmt, taskId, userId, parent, content)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (m *CommentModel) Get(id int) (Comment, error) {
+	stmt := `SELECT c.id, c.task_id, c.user_id, c.parent_id, c.content, c.created, c.updated, u.firstName, u.lastName
+	FROM comments c JOIN users u ON c.user_id = u.id WHERE c.id = ?`
+
+	var c Comment
+
+	err := m.DB.QueryRow(stmt, id).Scan(&c.ID, &c.TaskId, &c.UserId, &c.ParentId, &c.Content, &c.Created, &c.Updated, &c.FirstName, &c.LastName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Comment{}, ErrNoRecord
+		} else {
+			return Comment{}, err
+		}
+	}
+
+	return c, nil
+}
+
+func (m *CommentModel) GetAll(taskId int) ([]Comment, error) {
+	stmt := `SELECT c.id, c.task_id, c.user_id, c.parent_id, c.content, c.created, c.updated, u.firstName, u.lastName
+	FROM comments c JOIN users u ON c.user_id = u.id WHERE c.task_id = ? ORDER BY c.created, c.id`
+
+	rows, err := m.DB.Query(stmt, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var comments []Comment
+	replies := map[int][]Comment{}
+
+	for rows.Next() {
+		var c Comment
+
+		err = rows.Scan(&c.ID, &c.TaskId, &c.UserId, &c.ParentId, &c.Content, &c.Created, &c.Updated, &c.FirstName, &c.LastName)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.ParentId != nil {
+			replies[*c.ParentId] = append(replies[*c.ParentId], c)
+		} else {
+			comments = append(comments, c)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range comments {
+		comments[i].Replies = replies[comments[i].ID]
+	}
+
+	return comments, nil
+}
+
+func (m *CommentModel) Update(id int, content string) error {
+	stmt := `UPDATE comments SET content = ?, updated = UTC_TIMESTAMP() WHERE id = ?`
+
+	_, err := m.DB.Exec(stmt, content, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *CommentModel) Delete(id int) (int, error) {
+	stmt := `DELETE FROM comments WHERE id = ?`
+
+	result, err := m.DB.Exec(stmt, id)
+	if err != nil {
+		return 0, err
+	}
+
+	var r int64
+	r, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(r), nil
+}
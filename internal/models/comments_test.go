@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/andres085/task_manager/internal/assert"
+)
+
+func TestCommentInsertMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := CommentModel{db}
+
+	id, err := m.Insert(1, 1, 0, "Test Comment")
+
+	assert.Equal(t, id, 3)
+	assert.NilError(t, err)
+}
+
+func TestCommentInsertReplyToReply(t *testing.T) {
+	db := newTestDB(t)
+
+	m := CommentModel{db}
+
+	id, err := m.Insert(1, 1, 2, "Test Reply")
+	assert.NilError(t, err)
+
+	comment, err := m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, *comment.ParentId, 1)
+}
+
+func TestCommentGetAllMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := CommentModel{db}
+
+	comments, err := m.GetAll(1)
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(comments), 1)
+	assert.Equal(t, len(comments[0].Replies), 1)
+	assert.Equal(t, comments[0].FirstName, "Test")
+}
+
+func TestCommentUpdateMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := CommentModel{db}
+
+	newContent := "Updated Comment"
+	err := m.Update(1, newContent)
+
+	assert.NilError(t, err)
+
+	comment, err := m.Get(1)
+
+	assert.NilError(t, err)
+	assert.Equal(t, comment.Content, newContent)
+
+	if comment.Updated == nil {
+		t.Errorf("got: nil; expected: %v", comment.Updated)
+	}
+}
+
+func TestCommentDeleteMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := CommentModel{db}
+
+	row, err := m.Delete(1)
+
+	assert.Equal(t, row, 1)
+	assert.NilError(t, err)
+}
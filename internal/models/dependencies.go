@@ -0,0 +1,198 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+)
+
+type DependencyModelInterface interface {
+	Insert(taskId, blockedById int) error
+	Delete(taskId, blockedById int) error
+	GetBlockers(taskId int) ([]Task, error)
+	GetBlocking(taskId int) ([]Task, error)
+}
+
+type DependencyModel struct {
+	DB *sql.DB
+}
+
+// Insert records that taskId can't start until blockedById is finished.
+func (m *DependencyModel) Insert(taskId, blockedById int) error {
+	if taskId == blockedById {
+		return ErrDependencyCycle
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var sameWorkspace bool
+
+	stmt := `SELECT EXISTS (SELECT true FROM tasks t JOIN tasks b ON b.workspace_id = t.workspace_id WHERE t.id = ? AND b.id = ? AND b.deleted_at IS NULL)`
+
+	err = tx.QueryRow(stmt, taskId, blockedById).Scan(&sameWorkspace)
+	if err != nil {
+		return err
+	}
+
+	if !sameWorkspace {
+		return ErrNoRecord
+	}
+
+	var exists bool
+
+	err = tx.QueryRow(`SELECT EXISTS (SELECT true FROM task_dependencies WHERE task_id = ? AND blocked_by_id = ?)`, taskId, blockedById).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return nil
+	}
+
+	// Walk everything the new blocker is waiting on; reaching the task means the new edge closes a cycle.
+	visited := map[int]bool{blockedById: true}
+	queue := []int{blockedById}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		blockers, err := blockerIds(tx, current)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range blockers {
+			if id == taskId {
+				return ErrDependencyCycle
+			}
+
+			if !visited[id] {
+				visited[id] = true
+				queue = append(queue, id)
+			}
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO task_dependencies (task_id, blocked_by_id, created) VALUES (?, ?, UTC_TIMESTAMP())`, taskId, blockedById)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *DependencyModel) Delete(taskId, blockedById int) error {
+	result, err := m.DB.Exec(`DELETE FROM task_dependencies WHERE task_id = ? AND blocked_by_id = ?`, taskId, blockedById)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func (m *DependencyModel) GetBlockers(taskId int) ([]Task, error) {
+	stmt := `SELECT t.* FROM tasks t JOIN task_dependencies d ON d.blocked_by_id = t.id WHERE d.task_id = ? AND t.deleted_at IS NULL ORDER BY t.id`
+
+	return m.queryTasks(stmt, taskId)
+}
+
+func (m *DependencyModel) GetBlocking(taskId int) ([]Task, error) {
+	stmt := `SELECT t.* FROM tasks t JOIN task_dependencies d ON d.task_id = t.id WHERE d.blocked_by_id = ? AND t.deleted_at IS NULL ORDER BY t.id`
+
+	return m.queryTasks(stmt, taskId)
+}
+
+func (m *DependencyModel) queryTasks(stmt string, taskId int) ([]Task, error) {
+	rows, err := m.DB.Query(stmt, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var tasks []Task
+
+	for rows.Next() {
+		var t Task
+
+		err = rows.Scan(&t.ID, &t.Title, &t.Content, &t.Priority, &t.Created, &t.Finished, &t.WorkspaceId, &t.UserId, &t.Status, &t.DueDate, &t.DueTime, &t.ParentId, &t.DeletedAt, &t.Archived)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func blockerIds(tx *sql.Tx, taskId int) ([]int, error) {
+	rows, err := tx.Query(`SELECT blocked_by_id FROM task_dependencies WHERE task_id = ?`, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ids []int
+
+	for rows.Next() {
+		var id int
+
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// A blocked task can stay in, or go back to, the first status of the workflow but can't move past it.
+func checkBlockers(tx *sql.Tx, current Task, status string) error {
+	var firstStatus string
+
+	err := tx.QueryRow(`SELECT name FROM workspace_statuses WHERE workspace_id = ? ORDER BY position LIMIT 1`, current.WorkspaceId).Scan(&firstStatus)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if status == firstStatus {
+		return nil
+	}
+
+	var blocked bool
+
+	stmt := `SELECT EXISTS (SELECT true FROM task_dependencies d JOIN tasks b ON b.id = d.blocked_by_id WHERE d.task_id = ? AND b.finished IS NULL AND b.deleted_at IS NULL)`
+
+	err = tx.QueryRow(stmt, current.ID).Scan(&blocked)
+	if err != nil {
+		return err
+	}
+
+	if blocked {
+		return ErrTaskBlocked
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/andres085/task_manager/internal/assert"
+)
+
+func TestDependencies(t *testing.T) {
+	db := newTestDB(t)
+
+	m := DependencyModel{db}
+	tasks := TaskModel{db}
+
+	firstId, err := tasks.Insert("Blocked Task", "Blocked Task Body", "LOW", "", 1, []int{1}, nil, 0, "", "")
+	assert.NilError(t, err)
+
+	secondId, err := tasks.Insert("Blocker Task", "Blocker Task Body", "LOW", "", 1, []int{1}, nil, 0, "", "")
+	assert.NilError(t, err)
+
+	thirdId, err := tasks.Insert("Upstream Task", "Upstream Task Body", "LOW", "", 1, []int{1}, nil, 0, "", "")
+	assert.NilError(t, err)
+
+	assert.NilError(t, m.Insert(firstId, secondId))
+	assert.NilError(t, m.Insert(secondId, thirdId))
+	assert.NilError(t, m.Insert(firstId, secondId))
+
+	assert.Equal(t, m.Insert(thirdId, firstId), ErrDependencyCycle)
+	assert.Equal(t, m.Insert(firstId, firstId), ErrDependencyCycle)
+	assert.Equal(t, m.Insert(firstId, 99), ErrNoRecord)
+
+	blockers, err := m.GetBlockers(firstId)
+	assert.NilError(t, err)
+	assert.Equal(t, len(blockers), 1)
+	assert.Equal(t, blockers[0].ID, secondId)
+
+	blocking, err := m.GetBlocking(thirdId)
+	assert.NilError(t, err)
+	assert.Equal(t, len(blocking), 1)
+	assert.Equal(t, blocking[0].ID, secondId)
+
+	err = tasks.Update(firstId, 1, "Blocked Task", "Blocked Task Body", "LOW", []int{1}, nil, "In Progress", "", "")
+	assert.Equal(t, err, ErrTaskBlocked)
+
+	err = tasks.UpdateStatus(secondId, 1, "Completed")
+	assert.Equal(t, err, ErrTaskBlocked)
+
+	assert.NilError(t, tasks.UpdateStatus(thirdId, 1, "Completed"))
+	assert.NilError(t, tasks.UpdateStatus(secondId, 1, "Completed"))
+
+	err = tasks.Update(firstId, 1, "Blocked Task", "Blocked Task Body", "LOW", []int{1}, nil, "In Progress", "", "")
+	assert.NilError(t, err)
+
+	assert.NilError(t, m.Delete(secondId, thirdId))
+	assert.Equal(t, m.Delete(secondId, thirdId), ErrNoRecord)
+}
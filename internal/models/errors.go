@@ -10,4 +10,44 @@ var (
 	ErrInvalidCredentials = errors.New("models: invalid credentials")
 
 	ErrDuplicateEmail = errors.New("models: duplicate email")
+
+	ErrDuplicateStatus = errors.New("models: duplicate status")
+
+	ErrStatusInUse = errors.New("models: status in use")
+
+	ErrInvalidStatus = errors.New("models: invalid status")
+
+	ErrInvalidTransition = errors.New("models: status transition not allowed")
+
+	ErrDuplicateLabel = errors.New("models: duplicate label")
+
+	ErrInvalidLabel = errors.New("models: labels must belong to the task's workspace")
+
+	ErrInvalidParent = errors.New("models: invalid parent task")
+
+	ErrOpenSubtasks = errors.New("models: task has open subtasks")
+
+	ErrTaskBlocked = errors.New("models: task is blocked by open tasks")
+
+	ErrDependencyCycle = errors.New("models: dependency would create a cycle")
+
+	ErrDuplicateInvitation = errors.New("models: duplicate invitation")
+
+	ErrWorkspaceLimit = errors.New("models: workspace limit reached")
+
+	ErrInviteEmailMismatch = errors.New("models: invite link belongs to another email")
+
+	ErrOwnerRole = errors.New("models: owner role can only change through a transfer")
+
+	ErrTaskNotFinished = errors.New("models: only finished tasks can be archived")
+
+	ErrInvalidAssignee = errors.New("models: assignees must be workspace members")
+
+	ErrInvalidToken = errors.New("models: invalid or expired token")
+
+	ErrUnverifiedEmail = errors.New("models: email address not verified")
+
+	ErrTwoFactorEnabled = errors.New("models: two-factor authentication already enabled")
+
+	ErrInvalidCode = errors.New("models: invalid authentication code")
 )
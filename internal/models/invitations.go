@@ -0,0 +1,207 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const InvitationTTL = 7 * 24 * time.Hour
+
+type Invitation struct {
+	ID             int       `json:"id"`
+	WorkspaceId    int       `json:"workspace_id"`
+	WorkspaceTitle string    `json:"workspace_title"`
+	UserId         int       `json:"user_id"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	InvitedBy      int       `json:"invited_by"`
+	InviterEmail   string    `json:"inviter_email"`
+	Created        time.Time `json:"created"`
+	Expires        time.Time `json:"expires"`
+}
+
+type InvitationModelInterface interface {
+	Insert(workspaceId, userId, invitedBy int, role string) (int, error)
+	GetPending(userId int) ([]Invitation, error)
+	GetWorkspacePending(workspaceId int) ([]Invitation, error)
+	Accept(id, userId int) (int, error)
+	Decline(id, userId int) error
+	Delete(id, workspaceId int) error
+}
+
+type InvitationModel struct {
+	DB *sql.DB
+}
+
+const invitationColumns = `i.id, i.workspace_id, w.title, i.user_id, u.email, i.role, i.invited_by, ib.email, i.created, i.expires FROM invitations i
+	JOIN workspaces w ON w.id = i.workspace_id AND w.deleted_at IS NULL
+	JOIN users u ON u.id = i.user_id
+	JOIN users ib ON ib.id = i.invited_by`
+
+// Stale invitations are purged here so an expired invite never blocks a new one.
+func (m *InvitationModel) Insert(workspaceId, userId, invitedBy int, role string) (int, error) {
+	_, err := m.DB.Exec(`DELETE FROM invitations WHERE expires <= UTC_TIMESTAMP()`)
+	if err != nil {
+		return 0, err
+	}
+
+	var exists bool
+
+	err = m.DB.QueryRow(`SELECT EXISTS (SELECT true FROM invitations WHERE workspace_id = ? AND user_id = ?)`, workspaceId, userId).Scan(&exists)
+	if err != nil {
+		return 0, err
+	}
+
+	if exists {
+		return 0, ErrDuplicateInvitation
+	}
+
+	err = checkMemberQuota(m.DB, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt := `INSERT INTO invitations (workspace_id, user_id, role, invited_by, created, expires)
+	VALUES (?, ?, ?, ?, UTC_TIMESTAMP(), DATE_ADD(UTC_TIMESTAMP(), INTERVAL ? SECOND))`
+
+	result, err := m.DB.Exec(stmt, workspaceId, userId, role, invitedBy, int(InvitationTTL.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (m *InvitationModel) GetPending(userId int) ([]Invitation, error) {
+	stmt := `SELECT ` + invitationColumns + ` WHERE i.user_id = ? AND i.expires > UTC_TIMESTAMP() ORDER BY i.created DESC`
+
+	return m.query(stmt, userId)
+}
+
+func (m *InvitationModel) GetWorkspacePending(workspaceId int) ([]Invitation, error) {
+	stmt := `SELECT ` + invitationColumns + ` WHERE i.workspace_id = ? AND i.expires > UTC_TIMESTAMP() ORDER BY i.created DESC`
+
+	return m.query(stmt, workspaceId)
+}
+
+// Accept adds the user to the workspace and returns the workspace id.
+func (m *InvitationModel) Accept(id, userId int) (int, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var workspaceId int
+	var role string
+
+	stmt := `SELECT workspace_id, role FROM invitations WHERE id = ? AND user_id = ? AND expires > UTC_TIMESTAMP()
+	AND workspace_id NOT IN (SELECT id FROM workspaces WHERE deleted_at IS NOT NULL) FOR UPDATE`
+
+	err = tx.QueryRow(stmt, id, userId).Scan(&workspaceId, &role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNoRecord
+		}
+		return 0, err
+	}
+
+	member, err := isMember(tx, userId, workspaceId)
+	if err != nil {
+		return 0, err
+	}
+
+	// Someone who already joined, e.g. through an invite link, just has the
+	// stale invitation cleared.
+	if !member {
+		err = joinWorkspace(tx, userId, workspaceId, role)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.Exec(`DELETE FROM invitations WHERE id = ?`, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return workspaceId, tx.Commit()
+}
+
+func joinWorkspace(tx *sql.Tx, userId, workspaceId int, role string) error {
+	err := checkMemberQuota(tx, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO users_workspaces (user_id, workspace_id, role, created) VALUES (?, ?, ?, UTC_TIMESTAMP())`, userId, workspaceId, role)
+	return err
+}
+
+func isMember(tx *sql.Tx, userId, workspaceId int) (bool, error) {
+	var member bool
+
+	err := tx.QueryRow(`SELECT EXISTS (SELECT true FROM users_workspaces WHERE user_id = ? AND workspace_id = ?)`, userId, workspaceId).Scan(&member)
+	return member, err
+}
+
+func (m *InvitationModel) Decline(id, userId int) error {
+	return m.delete(`DELETE FROM invitations WHERE id = ? AND user_id = ?`, id, userId)
+}
+
+func (m *InvitationModel) Delete(id, workspaceId int) error {
+	return m.delete(`DELETE FROM invitations WHERE id = ? AND workspace_id = ?`, id, workspaceId)
+}
+
+func (m *InvitationModel) delete(stmt string, args ...interface{}) error {
+	result, err := m.DB.Exec(stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func (m *InvitationModel) query(stmt string, args ...interface{}) ([]Invitation, error) {
+	rows, err := m.DB.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var invitations []Invitation
+
+	for rows.Next() {
+		var i Invitation
+
+		err = rows.Scan(&i.ID, &i.WorkspaceId, &i.WorkspaceTitle, &i.UserId, &i.Email, &i.Role, &i.InvitedBy, &i.InviterEmail, &i.Created, &i.Expires)
+		if err != nil {
+			return nil, err
+		}
+
+		invitations = append(invitations, i)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/andres085/task_manager/internal/assert"
+)
+
+func TestInvitationAcceptMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := InvitationModel{db}
+	workspaces := WorkspaceModel{db}
+
+	workspaceId, err := workspaces.Insert("Invite Workspace", "Invite Workspace Description", 1)
+	assert.NilError(t, err)
+
+	id, err := m.Insert(workspaceId, 2, 1, RoleViewer)
+	assert.NilError(t, err)
+
+	_, err = m.Insert(workspaceId, 2, 1, RoleViewer)
+	assert.Equal(t, err, ErrDuplicateInvitation)
+
+	pending, err := m.GetPending(2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(pending), 1)
+	assert.Equal(t, pending[0].WorkspaceTitle, "Invite Workspace")
+	assert.Equal(t, pending[0].InvitedBy, 1)
+
+	pending, err = m.GetWorkspacePending(workspaceId)
+	assert.NilError(t, err)
+	assert.Equal(t, len(pending), 1)
+
+	_, err = m.Accept(id, 1)
+	assert.Equal(t, err, ErrNoRecord)
+
+	acceptedId, err := m.Accept(id, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, acceptedId, workspaceId)
+
+	membership, err := workspaces.GetMembership(2, workspaceId)
+	assert.NilError(t, err)
+	assert.Equal(t, membership.Role, RoleViewer)
+
+	_, err = m.Accept(id, 2)
+	assert.Equal(t, err, ErrNoRecord)
+}
+
+func TestInvitationAcceptMember(t *testing.T) {
+	db := newTestDB(t)
+
+	m := InvitationModel{db}
+	links := InviteLinkModel{db}
+	workspaces := WorkspaceModel{db}
+
+	workspaceId, err := workspaces.Insert("Invite Workspace", "Invite Workspace Description", 1)
+	assert.NilError(t, err)
+
+	id, err := m.Insert(workspaceId, 2, 1, RoleViewer)
+	assert.NilError(t, err)
+
+	_, err = db.Exec(`INSERT INTO users_workspaces (user_id, workspace_id, role, created) VALUES (2, ?, ?, UTC_TIMESTAMP())`, workspaceId, RoleEditor)
+	assert.NilError(t, err)
+
+	acceptedId, err := m.Accept(id, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, acceptedId, workspaceId)
+
+	membership, err := workspaces.GetMembership(2, workspaceId)
+	assert.NilError(t, err)
+	assert.Equal(t, membership.Role, RoleEditor)
+
+	var rows int
+	err = db.QueryRow(`SELECT COUNT(*) FROM users_workspaces WHERE user_id = 2 AND workspace_id = ?`, workspaceId).Scan(&rows)
+	assert.NilError(t, err)
+	assert.Equal(t, rows, 1)
+
+	pending, err := m.GetPending(2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(pending), 0)
+
+	_, err = m.Accept(id, 2)
+	assert.Equal(t, err, ErrNoRecord)
+
+	// Redeeming a link clears invitations to the same workspace.
+	otherId, err := workspaces.Insert("Other Workspace", "Other Workspace Description", 1)
+	assert.NilError(t, err)
+
+	_, err = m.Insert(otherId, 2, 1, RoleViewer)
+	assert.NilError(t, err)
+
+	token, err := links.Insert(otherId, 1, "", RoleViewer)
+	assert.NilError(t, err)
+
+	_, err = links.Redeem(token, 2)
+	assert.NilError(t, err)
+
+	pending, err = m.GetPending(2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(pending), 0)
+}
+
+func TestInvitationDeclineMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := InvitationModel{db}
+	workspaces := WorkspaceModel{db}
+
+	workspaceId, err := workspaces.Insert("Invite Workspace", "Invite Workspace Description", 1)
+	assert.NilError(t, err)
+
+	id, err := m.Insert(workspaceId, 2, 1, RoleViewer)
+	assert.NilError(t, err)
+
+	assert.Equal(t, m.Decline(id, 1), ErrNoRecord)
+	assert.NilError(t, m.Decline(id, 2))
+	assert.Equal(t, m.Delete(id, workspaceId), ErrNoRecord)
+}
+
+func TestInvitationExpiry(t *testing.T) {
+	db := newTestDB(t)
+
+	m := InvitationModel{db}
+	workspaces := WorkspaceModel{db}
+
+	workspaceId, err := workspaces.Insert("Invite Workspace", "Invite Workspace Description", 1)
+	assert.NilError(t, err)
+
+	id, err := m.Insert(workspaceId, 2, 1, RoleViewer)
+	assert.NilError(t, err)
+
+	_, err = db.Exec(`UPDATE invitations SET expires = DATE_SUB(UTC_TIMESTAMP(), INTERVAL 1 DAY) WHERE id = ?`, id)
+	assert.NilError(t, err)
+
+	pending, err := m.GetPending(2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(pending), 0)
+
+	_, err = m.Accept(id, 2)
+	assert.Equal(t, err, ErrNoRecord)
+
+	_, err = m.Insert(workspaceId, 2, 1, RoleViewer)
+	assert.NilError(t, err)
+}
+
+func TestInvitationWorkspaceLimit(t *testing.T) {
+	db := newTestDB(t)
+
+	m := InvitationModel{db}
+	workspaces := WorkspaceModel{db}
+
+	_, err := db.Exec(`INSERT INTO user_limits (user_id, max_owned_workspaces) VALUES (1, 10)`)
+	assert.NilError(t, err)
+
+	workspaceId, err := workspaces.Insert("One Too Many", "One Too Many Description", 1)
+	assert.NilError(t, err)
+
+	id, err := m.Insert(workspaceId, 2, 1, RoleViewer)
+	assert.NilError(t, err)
+
+	for i := 0; i < DefaultLimits.MemberWorkspaces-1; i++ {
+		memberWorkspaceId, err := workspaces.Insert(fmt.Sprintf("Member Workspace %d", i), "Member Workspace Description", 1)
+		assert.NilError(t, err)
+
+		_, err = db.Exec(`INSERT INTO users_workspaces (user_id, workspace_id, role, created) VALUES (2, ?, "EDITOR", UTC_TIMESTAMP())`, memberWorkspaceId)
+		assert.NilError(t, err)
+	}
+
+	_, err = m.Accept(id, 2)
+	assert.Equal(t, err, ErrWorkspaceLimit)
+
+	lastWorkspaceId, err := workspaces.Insert("Last Workspace", "Last Workspace Description", 1)
+	assert.NilError(t, err)
+
+	_, err = m.Insert(lastWorkspaceId, 2, 1, RoleViewer)
+	assert.Equal(t, err, ErrWorkspaceLimit)
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const InviteLinkTTL = 3 * 24 * time.Hour
+
+type InviteLink struct {
+	ID             int       `json:"id"`
+	WorkspaceId    int       `json:"workspace_id"`
+	WorkspaceTitle string    `json:"workspace_title"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	CreatedBy      int       `json:"created_by"`
+	Created        time.Time `json:"created"`
+	Expires        time.Time `json:"expires"`
+}
+
+type InviteLinkModelInterface interface {
+	Insert(workspaceId, createdBy int, email, role string) (string, error)
+	GetByToken(plaintext string) (InviteLink, error)
+	GetAll(workspaceId int) ([]InviteLink, error)
+	Delete(id, workspaceId int) error
+	Redeem(plaintext string, userId int) (int, error)
+}
+
+type InviteLinkModel struct {
+	DB *sql.DB
+}
+
+const inviteLinkColumns = `l.id, l.workspace_id, w.title, l.email, l.role, l.created_by, l.created, l.expires FROM invite_links l
+	JOIN workspaces w ON w.id = l.workspace_id AND w.deleted_at IS NULL`
+
+// Insert returns the plaintext token; only its hash is stored. A blank email creates a link anyone can use until it expires.
+func (m *InviteLinkModel) Insert(workspaceId, createdBy int, email, role string) (string, error) {
+	_, err := m.DB.Exec(`DELETE FROM invite_links WHERE expires <= UTC_TIMESTAMP()`)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	stmt := `INSERT INTO invite_links (workspace_id, token_hash, email, role, created_by, created, expires)
+	VALUES (?, ?, ?, ?, ?, UTC_TIMESTAMP(), DATE_ADD(UTC_TIMESTAMP(), INTERVAL ? SECOND))`
+
+	_, err = m.DB.Exec(stmt, workspaceId, hashToken(plaintext), email, role, createdBy, int(InviteLinkTTL.Seconds()))
+	if err != nil {
+		return "", err
+	}
+
+	return plaintext, nil
+}
+
+func (m *InviteLinkModel) GetByToken(plaintext string) (InviteLink, error) {
+	stmt := `SELECT ` + inviteLinkColumns + ` WHERE l.token_hash = ? AND l.expires > UTC_TIMESTAMP()`
+
+	var l InviteLink
+
+	err := m.DB.QueryRow(stmt, hashToken(plaintext)).Scan(&l.ID, &l.WorkspaceId, &l.WorkspaceTitle, &l.Email, &l.Role, &l.CreatedBy, &l.Created, &l.Expires)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return InviteLink{}, ErrNoRecord
+		}
+		return InviteLink{}, err
+	}
+
+	return l, nil
+}
+
+func (m *InviteLinkModel) GetAll(workspaceId int) ([]InviteLink, error) {
+	stmt := `SELECT ` + inviteLinkColumns + ` WHERE l.workspace_id = ? AND l.expires > UTC_TIMESTAMP() ORDER BY l.created DESC, l.id DESC`
+
+	rows, err := m.DB.Query(stmt, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var links []InviteLink
+
+	for rows.Next() {
+		var l InviteLink
+
+		err = rows.Scan(&l.ID, &l.WorkspaceId, &l.WorkspaceTitle, &l.Email, &l.Role, &l.CreatedBy, &l.Created, &l.Expires)
+		if err != nil {
+			return nil, err
+		}
+
+		links = append(links, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func (m *InviteLinkModel) Delete(id, workspaceId int) error {
+	result, err := m.DB.Exec(`DELETE FROM invite_links WHERE id = ? AND workspace_id = ?`, id, workspaceId)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// Redeem adds the user to the link's workspace and returns its id. Links targeted at an
+// email only work for that account and are used up once redeemed.
+func (m *InviteLinkModel) Redeem(plaintext string, userId int) (int, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var id, workspaceId int
+	var email, role string
+
+	stmt := `SELECT id, workspace_id, email, role FROM invite_links WHERE token_hash = ? AND expires > UTC_TIMESTAMP()
+	AND workspace_id NOT IN (SELECT id FROM workspaces WHERE deleted_at IS NOT NULL) FOR UPDATE`
+
+	err = tx.QueryRow(stmt, hashToken(plaintext)).Scan(&id, &workspaceId, &email, &role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNoRecord
+		}
+		return 0, err
+	}
+
+	if email != "" {
+		var matches bool
+
+		err = tx.QueryRow(`SELECT EXISTS (SELECT true FROM users WHERE id = ? AND email = ?)`, userId, email).Scan(&matches)
+		if err != nil {
+			return 0, err
+		}
+
+		if !matches {
+			return 0, ErrInviteEmailMismatch
+		}
+	}
+
+	member, err := isMember(tx, userId, workspaceId)
+	if err != nil {
+		return 0, err
+	}
+
+	if !member {
+		err = joinWorkspace(tx, userId, workspaceId, role)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.Exec(`DELETE FROM invitations WHERE user_id = ? AND workspace_id = ?`, userId, workspaceId)
+	if err != nil {
+		return 0, err
+	}
+
+	if email != "" {
+		_, err = tx.Exec(`DELETE FROM invite_links WHERE id = ?`, id)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return workspaceId, tx.Commit()
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/andres085/task_manager/internal/assert"
+)
+
+func TestInviteLinkRedeemMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := InviteLinkModel{db}
+	workspaces := WorkspaceModel{db}
+
+	workspaceId, err := workspaces.Insert("Link Workspace", "Link Workspace Description", 1)
+	assert.NilError(t, err)
+
+	token, err := m.Insert(workspaceId, 1, "", RoleViewer)
+	assert.NilError(t, err)
+
+	link, err := m.GetByToken(token)
+	assert.NilError(t, err)
+	assert.Equal(t, link.WorkspaceTitle, "Link Workspace")
+
+	_, err = m.GetByToken("not-a-token")
+	assert.Equal(t, err, ErrNoRecord)
+
+	joinedId, err := m.Redeem(token, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, joinedId, workspaceId)
+
+	membership, err := workspaces.GetMembership(2, workspaceId)
+	assert.NilError(t, err)
+	assert.Equal(t, membership.Role, RoleViewer)
+
+	// Open links can be used again until they expire.
+	_, err = m.Redeem(token, 2)
+	assert.NilError(t, err)
+
+	links, err := m.GetAll(workspaceId)
+	assert.NilError(t, err)
+	assert.Equal(t, len(links), 1)
+
+	assert.NilError(t, m.Delete(links[0].ID, workspaceId))
+
+	_, err = m.Redeem(token, 2)
+	assert.Equal(t, err, ErrNoRecord)
+}
+
+func TestInviteLinkEmailMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := InviteLinkModel{db}
+	workspaces := WorkspaceModel{db}
+
+	workspaceId, err := workspaces.Insert("Link Workspace", "Link Workspace Description", 1)
+	assert.NilError(t, err)
+
+	token, err := m.Insert(workspaceId, 1, "member@example.com", RoleEditor)
+	assert.NilError(t, err)
+
+	_, err = m.Redeem(token, 1)
+	assert.Equal(t, err, ErrInviteEmailMismatch)
+
+	_, err = m.Redeem(token, 2)
+	assert.NilError(t, err)
+
+	_, err = m.Redeem(token, 2)
+	assert.Equal(t, err, ErrNoRecord)
+}
+
+func TestInviteLinkExpiry(t *testing.T) {
+	db := newTestDB(t)
+
+	m := InviteLinkModel{db}
+
+	token, err := m.Insert(1, 1, "", RoleEditor)
+	assert.NilError(t, err)
+
+	_, err = db.Exec(`UPDATE invite_links SET expires = DATE_SUB(UTC_TIMESTAMP(), INTERVAL 1 DAY)`)
+	assert.NilError(t, err)
+
+	_, err = m.GetByToken(token)
+	assert.Equal(t, err, ErrNoRecord)
+
+	_, err = m.Redeem(token, 2)
+	assert.Equal(t, err, ErrNoRecord)
+}
@@ -0,0 +1,253 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Colors are mapped to CSS classes, so labels are limited to this palette.
+var LabelColors = []string{"gray", "red", "orange", "yellow", "green", "teal", "blue", "purple"}
+
+type Label struct {
+	ID          int       `json:"id"`
+	WorkspaceId int       `json:"workspace_id"`
+	Name        string    `json:"name"`
+	Color       string    `json:"color"`
+	Created     time.Time `json:"created"`
+}
+
+type LabelModelInterface interface {
+	Insert(workspaceId int, name, color string) (int, error)
+	Get(id int) (Label, error)
+	GetAll(workspaceId int) ([]Label, error)
+	Update(id int, name, color string) error
+	Delete(id int) error
+}
+
+type LabelModel struct {
+	DB *sql.DB
+}
+
+func (m *LabelModel) Insert(workspaceId int, name, color string) (int, error) {
+	var exists bool
+
+	err := m.DB.QueryRow(`SELECT EXISTS (SELECT true FROM labels WHERE workspace_id = ? AND name = ?)`, workspaceId, name).Scan(&exists)
+	if err != nil {
+		return 0, err
+	}
+
+	if exists {
+		return 0, ErrDuplicateLabel
+	}
+
+	stmt := `INSERT INTO labels (workspace_id, name, color, created) VALUES (?, ?, ?, UTC_TIMESTAMP())`
+
+	result, err := m.DB.Exec(stmt, workspaceId, name, color)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (m *LabelModel) Get(id int) (Label, error) {
+	stmt := `SELECT id, workspace_id, name, color, created FROM labels WHERE id = ?`
+
+	var l Label
+
+	err := m.DB.QueryRow(stmt, id).Scan(&l.ID, &l.WorkspaceId, &l.Name, &l.Color, &l.Created)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Label{}, ErrNoRecord
+		} else {
+			return Label{}, err
+		}
+	}
+
+	return l, nil
+}
+
+func (m *LabelModel) GetAll(workspaceId int) ([]Label, error) {
+	stmt := `SELECT id, workspace_id, name, color, created FROM labels WHERE workspace_id = ? ORDER BY name`
+
+	rows, err := m.DB.Query(stmt, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var labels []Label
+
+	for rows.Next() {
+		var l Label
+
+		err = rows.Scan(&l.ID, &l.WorkspaceId, &l.Name, &l.Color, &l.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		labels = append(labels, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return labels, nil
+}
+
+func (m *LabelModel) Update(id int, name, color string) error {
+	var exists bool
+
+	stmt := `SELECT EXISTS (SELECT true FROM labels l JOIN labels o ON o.workspace_id = l.workspace_id WHERE l.id = ? AND o.id <> l.id AND o.name = ?)`
+
+	err := m.DB.QueryRow(stmt, id, name).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return ErrDuplicateLabel
+	}
+
+	result, err := m.DB.Exec(`UPDATE labels SET name = ?, color = ? WHERE id = ?`, name, color, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		_, err = m.Get(id)
+		return err
+	}
+
+	return nil
+}
+
+func (m *LabelModel) Delete(id int) error {
+	result, err := m.DB.Exec(`DELETE FROM labels WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// Labels that belong to another workspace are silently ignored.
+// checkLabels drops duplicate ids and returns ErrInvalidLabel unless every label belongs to the workspace.
+func checkLabels(tx *sql.Tx, workspaceId int, labelIds []int) ([]int, error) {
+	var unique []int
+	seen := make(map[int]bool)
+
+	for _, labelId := range labelIds {
+		if !seen[labelId] {
+			seen[labelId] = true
+			unique = append(unique, labelId)
+		}
+	}
+
+	if len(unique) == 0 {
+		return nil, nil
+	}
+
+	args := []interface{}{workspaceId}
+	for _, labelId := range unique {
+		args = append(args, labelId)
+	}
+
+	var found int
+
+	stmt := `SELECT COUNT(*) FROM labels WHERE workspace_id = ? AND id IN (` + placeholders(len(unique)) + `)`
+
+	err := tx.QueryRow(stmt, args...).Scan(&found)
+	if err != nil {
+		return nil, err
+	}
+
+	if found != len(unique) {
+		return nil, ErrInvalidLabel
+	}
+
+	return unique, nil
+}
+
+func setLabels(tx *sql.Tx, taskId int, labelIds []int) error {
+	_, err := tx.Exec(`DELETE FROM task_labels WHERE task_id = ?`, taskId)
+	if err != nil {
+		return err
+	}
+
+	for _, labelId := range labelIds {
+		_, err = tx.Exec(`INSERT INTO task_labels (task_id, label_id) VALUES (?, ?)`, taskId, labelId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func attachLabels(db *sql.DB, tasks []Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, len(tasks))
+	index := make(map[int]int, len(tasks))
+
+	for i, t := range tasks {
+		args[i] = t.ID
+		index[t.ID] = i
+		tasks[i].Labels = []Label{}
+	}
+
+	stmt := `SELECT tl.task_id, l.id, l.workspace_id, l.name, l.color, l.created FROM task_labels tl
+	JOIN labels l ON l.id = tl.label_id WHERE tl.task_id IN (` + placeholders(len(tasks)) + `) ORDER BY l.name`
+
+	rows, err := db.Query(stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskId int
+		var l Label
+
+		err = rows.Scan(&taskId, &l.ID, &l.WorkspaceId, &l.Name, &l.Color, &l.Created)
+		if err != nil {
+			return err
+		}
+
+		i := index[taskId]
+		tasks[i].Labels = append(tasks[i].Labels, l)
+	}
+
+	return rows.Err()
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
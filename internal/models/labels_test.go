@@ -0,0 +1,154 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/andres085/task_manager/internal/assert"
+)
+
+func TestLabelInsertMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := LabelModel{db}
+
+	id, err := m.Insert(1, "Docs", "blue")
+	assert.NilError(t, err)
+	assert.Equal(t, id, 3)
+
+	label, err := m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, label.Color, "blue")
+
+	_, err = m.Insert(1, "Bug", "red")
+	assert.Equal(t, err, ErrDuplicateLabel)
+}
+
+func TestLabelGetAllMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := LabelModel{db}
+
+	labels, err := m.GetAll(1)
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(labels), 2)
+	assert.Equal(t, labels[0].Name, "Bug")
+}
+
+func TestLabelUpdateMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := LabelModel{db}
+
+	err := m.Update(1, "Defect", "orange")
+	assert.NilError(t, err)
+
+	label, err := m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, label.Name, "Defect")
+	assert.Equal(t, label.Color, "orange")
+
+	err = m.Update(1, "Feature", "red")
+	assert.Equal(t, err, ErrDuplicateLabel)
+
+	err = m.Update(9, "Docs", "red")
+	assert.Equal(t, err, ErrNoRecord)
+}
+
+func TestLabelDeleteMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := LabelModel{db}
+	tasks := TaskModel{db}
+
+	err := m.Delete(1)
+	assert.NilError(t, err)
+
+	task, err := tasks.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(task.Labels), 1)
+
+	err = m.Delete(1)
+	assert.Equal(t, err, ErrNoRecord)
+}
+
+func TestTaskLabels(t *testing.T) {
+	db := newTestDB(t)
+
+	m := LabelModel{db}
+	tasks := TaskModel{db}
+	workspaces := WorkspaceModel{db}
+
+	workspaceId, err := workspaces.Insert("Other", "Other workspace", 1)
+	assert.NilError(t, err)
+
+	otherLabelId, err := m.Insert(workspaceId, "Other", "gray")
+	assert.NilError(t, err)
+
+	id, err := tasks.Insert("Labelled Task", "Labelled Task Body", "LOW", "", 1, []int{1}, []int{2, 2}, 0, "", "")
+	assert.NilError(t, err)
+
+	task, err := tasks.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, len(task.Labels), 1)
+	assert.Equal(t, task.Labels[0].Name, "Feature")
+
+	_, err = tasks.Insert("Other Task", "Other Task Body", "LOW", "", 1, []int{1}, []int{otherLabelId}, 0, "", "")
+	assert.Equal(t, err, ErrInvalidLabel)
+
+	err = tasks.Update(id, 1, "Labelled Task", "Labelled Task Body", "LOW", []int{1}, []int{2, otherLabelId}, "To Do", "", "")
+	assert.Equal(t, err, ErrInvalidLabel)
+
+	task, err = tasks.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, len(task.Labels), 1)
+
+	err = tasks.Update(id, 1, "Labelled Task", "Labelled Task Body", "LOW", []int{1}, nil, "To Do", "", "")
+	assert.NilError(t, err)
+
+	task, err = tasks.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, len(task.Labels), 0)
+}
+
+func TestGetAllLabelFilter(t *testing.T) {
+	db := newTestDB(t)
+
+	m := TaskModel{db}
+
+	tests := []struct {
+		name   string
+		labels []int
+		want   int
+	}{
+		{
+			name:   "No labels",
+			labels: nil,
+			want:   3,
+		},
+		{
+			name:   "Single label",
+			labels: []int{1},
+			want:   2,
+		},
+		{
+			name:   "Every label",
+			labels: []int{1, 2},
+			want:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := TaskFilter{Labels: tt.labels}
+
+			tasks, err := m.GetAll(1, 10, 0, filter)
+			assert.NilError(t, err)
+			assert.Equal(t, len(tasks), tt.want)
+
+			total, err := m.GetTotalTasks(1, filter)
+			assert.NilError(t, err)
+			assert.Equal(t, total, tt.want)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// DefaultLimits apply to every user without a row in user_limits. The web server sets
+// them from its command-line flags.
+var DefaultLimits = Limits{OwnedWorkspaces: 6, MemberWorkspaces: 6}
+
+type Limits struct {
+	OwnedWorkspaces  int `json:"owned_workspaces"`
+	MemberWorkspaces int `json:"member_workspaces"`
+}
+
+type Quota struct {
+	Limits
+	Owned  int `json:"owned"`
+	Member int `json:"member"`
+}
+
+func (q Quota) OwnedRemaining() int {
+	return max(q.OwnedWorkspaces-q.Owned, 0)
+}
+
+func (q Quota) MemberRemaining() int {
+	return max(q.MemberWorkspaces-q.Member, 0)
+}
+
+type LimitModelInterface interface {
+	GetQuota(userId int) (Quota, error)
+}
+
+type LimitModel struct {
+	DB *sql.DB
+}
+
+func (m *LimitModel) GetQuota(userId int) (Quota, error) {
+	return userQuota(m.DB, userId)
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func userQuota(q queryRower, userId int) (Quota, error) {
+	var quota Quota
+
+	stmt := "SELECT COALESCE(l.max_owned_workspaces, ?), COALESCE(l.max_member_workspaces, ?)," +
+		" (SELECT COUNT(*) FROM users_workspaces uw JOIN workspaces w ON w.id = uw.workspace_id WHERE uw.user_id = u.id AND uw.`role` = ? AND w.deleted_at IS NULL)," +
+		" (SELECT COUNT(*) FROM users_workspaces uw JOIN workspaces w ON w.id = uw.workspace_id WHERE uw.user_id = u.id AND uw.`role` <> ? AND w.deleted_at IS NULL)" +
+		" FROM users u LEFT JOIN user_limits l ON l.user_id = u.id WHERE u.id = ?"
+
+	err := q.QueryRow(stmt, DefaultLimits.OwnedWorkspaces, DefaultLimits.MemberWorkspaces, RoleOwner, RoleOwner, userId).
+		Scan(&quota.OwnedWorkspaces, &quota.MemberWorkspaces, &quota.Owned, &quota.Member)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Quota{}, ErrNoRecord
+		}
+		return Quota{}, err
+	}
+
+	return quota, nil
+}
+
+func checkOwnedQuota(q queryRower, userId int) error {
+	quota, err := userQuota(q, userId)
+	if err != nil {
+		return err
+	}
+
+	if quota.OwnedRemaining() == 0 {
+		return ErrWorkspaceLimit
+	}
+
+	return nil
+}
+
+func checkMemberQuota(q queryRower, userId int) error {
+	quota, err := userQuota(q, userId)
+	if err != nil {
+		return err
+	}
+
+	if quota.MemberRemaining() == 0 {
+		return ErrWorkspaceLimit
+	}
+
+	return nil
+}
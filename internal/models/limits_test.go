@@ -0,0 +1,54 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/andres085/task_manager/internal/assert"
+)
+
+func TestLimitGetQuotaMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := LimitModel{db}
+
+	quota, err := m.GetQuota(2)
+	assert.NilError(t, err)
+	assert.Equal(t, quota.Limits, DefaultLimits)
+	assert.Equal(t, quota.Owned, 0)
+	assert.Equal(t, quota.Member, 1)
+	assert.Equal(t, quota.MemberRemaining(), DefaultLimits.MemberWorkspaces-1)
+
+	_, err = db.Exec(`INSERT INTO user_limits (user_id, max_member_workspaces) VALUES (2, 0)`)
+	assert.NilError(t, err)
+
+	quota, err = m.GetQuota(2)
+	assert.NilError(t, err)
+	assert.Equal(t, quota.OwnedWorkspaces, DefaultLimits.OwnedWorkspaces)
+	assert.Equal(t, quota.MemberWorkspaces, 0)
+	assert.Equal(t, quota.MemberRemaining(), 0)
+
+	_, err = m.GetQuota(99)
+	assert.Equal(t, err, ErrNoRecord)
+}
+
+func TestLimitEnforcement(t *testing.T) {
+	db := newTestDB(t)
+
+	workspaces := WorkspaceModel{db}
+	invitations := InvitationModel{db}
+
+	_, err := db.Exec(`INSERT INTO user_limits (user_id, max_owned_workspaces) VALUES (1, 2)`)
+	assert.NilError(t, err)
+
+	workspaceId, err := workspaces.Insert("Second Owned Workspace", "Second Owned Workspace Description", 1)
+	assert.NilError(t, err)
+
+	_, err = workspaces.Insert("Third Owned Workspace", "Third Owned Workspace Description", 1)
+	assert.Equal(t, err, ErrWorkspaceLimit)
+
+	_, err = db.Exec(`INSERT INTO user_limits (user_id, max_member_workspaces) VALUES (2, 1)`)
+	assert.NilError(t, err)
+
+	_, err = invitations.Insert(workspaceId, 2, 1, RoleEditor)
+	assert.Equal(t, err, ErrWorkspaceLimit)
+}
@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/andres085/task_manager/internal/models"
+)
+
+var lastUsed = time.Now()
+
+var firstMockAPIToken = models.APIToken{
+	ID:       1,
+	UserId:   1,
+	Name:     "CI Token",
+	Created:  time.Now(),
+	LastUsed: &lastUsed,
+}
+
+type APITokenModel struct{}
+
+func (m *APITokenModel) Insert(userId int, name string) (string, error) {
+	return "NEWTOKENPLAINTEXT", nil
+}
+
+func (m *APITokenModel) GetAll(userId int) ([]models.APIToken, error) {
+	return []models.APIToken{firstMockAPIToken}, nil
+}
+
+func (m *APITokenModel) Delete(id, userId int) (int, error) {
+	if id == 1 && userId == 1 {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m *APITokenModel) GetUserID(plaintext string) (int, error) {
+	if plaintext == "VALIDTOKEN" {
+		return 1, nil
+	}
+	return 0, models.ErrNoRecord
+}
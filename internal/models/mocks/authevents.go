@@ -0,0 +1,53 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/andres085/task_manager/internal/models"
+)
+
+type AuthEventModel struct {
+	Events     []string
+	SignupWait time.Duration
+}
+
+func (m *AuthEventModel) Insert(event, email, ip string) error {
+	m.Events = append(m.Events, event)
+	return nil
+}
+
+func (m *AuthEventModel) BeginLoginAttempt(event, email, ip string) (int, time.Duration, bool, error) {
+	wait, locked, err := m.LoginThrottle(email, ip)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	m.Events = append(m.Events, event)
+
+	return len(m.Events) - 1, wait, locked, nil
+}
+
+func (m *AuthEventModel) ResolveLoginAttempt(id int, event string) error {
+	m.Events[id] = event
+	return nil
+}
+
+func (m *AuthEventModel) LoginThrottle(email, ip string) (time.Duration, bool, error) {
+	switch email {
+	case "locked@example.com":
+		return 15 * time.Minute, true, nil
+	case "throttled@example.com":
+		return 4 * time.Second, false, nil
+	case "lockme@example.com":
+		if len(m.Events) > 0 && m.Events[len(m.Events)-1] == models.EventLoginFailed {
+			return 15 * time.Minute, true, nil
+		}
+		return 0, false, nil
+	default:
+		return 0, false, nil
+	}
+}
+
+func (m *AuthEventModel) SignupThrottle(ip string) (time.Duration, error) {
+	return m.SignupWait, nil
+}
@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/andres085/task_manager/internal/models"
+)
+
+var mockChecklistItems = []models.ChecklistItem{
+	{ID: 1, TaskId: 1, Content: "Write the docs", Done: true, Created: time.Now()},
+	{ID: 2, TaskId: 1, Content: "Ask for a review", Created: time.Now()},
+	{ID: 3, TaskId: 2, Content: "Other task item", Created: time.Now()},
+}
+
+type ChecklistModel struct{}
+
+func (m *ChecklistModel) Insert(taskId int, content string) (int, error) {
+	return 4, nil
+}
+
+func (m *ChecklistModel) Get(id int) (models.ChecklistItem, error) {
+	for _, item := range mockChecklistItems {
+		if item.ID == id {
+			return item, nil
+		}
+	}
+	return models.ChecklistItem{}, models.ErrNoRecord
+}
+
+func (m *ChecklistModel) GetAll(taskId int) ([]models.ChecklistItem, error) {
+	var items []models.ChecklistItem
+	for _, item := range mockChecklistItems {
+		if item.TaskId == taskId {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (m *ChecklistModel) SetDone(id int, done bool) error {
+	return nil
+}
+
+func (m *ChecklistModel) Delete(id int) error {
+	return nil
+}
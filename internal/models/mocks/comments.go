@@ -0,0 +1,62 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/andres085/task_manager/internal/models"
+)
+
+var firstMockComment = models.Comment{
+	ID:        1,
+	TaskId:    1,
+	UserId:    1,
+	Content:   "First Test Comment",
+	Created:   time.Now(),
+	FirstName: "Test",
+	LastName:  "McTester",
+}
+
+var secondMockComment = models.Comment{
+	ID:        2,
+	TaskId:    1,
+	UserId:    2,
+	Content:   "Second Test Comment",
+	Created:   time.Now(),
+	FirstName: "Pete",
+	LastName:  "Peterson",
+}
+
+type CommentModel struct{}
+
+func (m *CommentModel) Insert(taskId, userId, parentId int, content string) (int, error) {
+	if parentId > 2 {
+		return 0, models.ErrNoRecord
+	}
+	return 3, nil
+}
+
+func (m *CommentModel) Get(id int) (models.Comment, error) {
+	switch id {
+	case 1:
+		return firstMockComment, nil
+	case 2:
+		return secondMockComment, nil
+	default:
+		return models.Comment{}, models.ErrNoRecord
+	}
+}
+
+func (m *CommentModel) GetAll(taskId int) ([]models.Comment, error) {
+	comment := firstMockComment
+	comment.Replies = []models.Comment{secondMockComment}
+
+	return []models.Comment{comment}, nil
+}
+
+func (m *CommentModel) Update(id int, content string) error {
+	return nil
+}
+
+func (m *CommentModel) Delete(id int) (int, error) {
+	return 1, nil
+}
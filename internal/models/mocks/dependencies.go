@@ -0,0 +1,39 @@
+package mocks
+
+import (
+	"github.com/andres085/task_manager/internal/models"
+)
+
+type DependencyModel struct{}
+
+func (m *DependencyModel) Insert(taskId, blockedById int) error {
+	switch {
+	case taskId == blockedById, taskId == 2 && blockedById == 1:
+		return models.ErrDependencyCycle
+	case blockedById != 1 && blockedById != 2:
+		return models.ErrNoRecord
+	default:
+		return nil
+	}
+}
+
+func (m *DependencyModel) Delete(taskId, blockedById int) error {
+	if taskId == 1 && blockedById == 2 {
+		return nil
+	}
+	return models.ErrNoRecord
+}
+
+func (m *DependencyModel) GetBlockers(taskId int) ([]models.Task, error) {
+	if taskId == 1 {
+		return []models.Task{secondMockTask}, nil
+	}
+	return nil, nil
+}
+
+func (m *DependencyModel) GetBlocking(taskId int) ([]models.Task, error) {
+	if taskId == 2 {
+		return []models.Task{firstMockTask}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,71 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/andres085/task_manager/internal/models"
+)
+
+var mockInvitation = models.Invitation{
+	ID:             1,
+	WorkspaceId:    2,
+	WorkspaceTitle: "Invited Workspace",
+	UserId:         1,
+	Email:          "testmctesterson@mail.com",
+	Role:           models.RoleEditor,
+	InvitedBy:      2,
+	InviterEmail:   "pete@mail.com",
+	Created:        time.Now(),
+	Expires:        time.Now().Add(models.InvitationTTL),
+}
+
+type InvitationModel struct{}
+
+func (m *InvitationModel) Insert(workspaceId, userId, invitedBy int, role string) (int, error) {
+	switch userId {
+	case secondMockUser.ID:
+		return 0, models.ErrDuplicateInvitation
+	case 4:
+		return 0, models.ErrWorkspaceLimit
+	default:
+		return 2, nil
+	}
+}
+
+func (m *InvitationModel) GetPending(userId int) ([]models.Invitation, error) {
+	if userId == mockInvitation.UserId {
+		return []models.Invitation{mockInvitation}, nil
+	}
+	return nil, nil
+}
+
+func (m *InvitationModel) GetWorkspacePending(workspaceId int) ([]models.Invitation, error) {
+	return []models.Invitation{
+		{ID: 3, WorkspaceId: workspaceId, UserId: 2, Email: "pete@mail.com", Role: models.RoleViewer, InvitedBy: 1, Created: time.Now(), Expires: time.Now().Add(models.InvitationTTL)},
+	}, nil
+}
+
+func (m *InvitationModel) Accept(id, userId int) (int, error) {
+	switch {
+	case id == 5:
+		return 0, models.ErrWorkspaceLimit
+	case id != mockInvitation.ID || userId != mockInvitation.UserId:
+		return 0, models.ErrNoRecord
+	default:
+		return mockInvitation.WorkspaceId, nil
+	}
+}
+
+func (m *InvitationModel) Decline(id, userId int) error {
+	if id != mockInvitation.ID || userId != mockInvitation.UserId {
+		return models.ErrNoRecord
+	}
+	return nil
+}
+
+func (m *InvitationModel) Delete(id, workspaceId int) error {
+	if id != 3 {
+		return models.ErrNoRecord
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/andres085/task_manager/internal/models"
+)
+
+var mockInviteLink = models.InviteLink{
+	ID:             1,
+	WorkspaceId:    2,
+	WorkspaceTitle: "Linked Workspace",
+	Role:           models.RoleEditor,
+	CreatedBy:      2,
+	Created:        time.Now(),
+	Expires:        time.Now().Add(models.InviteLinkTTL),
+}
+
+type InviteLinkModel struct{}
+
+func (m *InviteLinkModel) Insert(workspaceId, createdBy int, email, role string) (string, error) {
+	return "new-token", nil
+}
+
+func (m *InviteLinkModel) GetByToken(plaintext string) (models.InviteLink, error) {
+	switch plaintext {
+	case "valid-token", "full-token", "other-email-token":
+		return mockInviteLink, nil
+	default:
+		return models.InviteLink{}, models.ErrNoRecord
+	}
+}
+
+func (m *InviteLinkModel) GetAll(workspaceId int) ([]models.InviteLink, error) {
+	return []models.InviteLink{
+		{ID: 1, WorkspaceId: workspaceId, Email: "newcomer@mail.com", Role: models.RoleEditor, CreatedBy: 1, Created: time.Now(), Expires: time.Now().Add(models.InviteLinkTTL)},
+	}, nil
+}
+
+func (m *InviteLinkModel) Delete(id, workspaceId int) error {
+	if id != 1 {
+		return models.ErrNoRecord
+	}
+	return nil
+}
+
+func (m *InviteLinkModel) Redeem(plaintext string, userId int) (int, error) {
+	switch plaintext {
+	case "valid-token":
+		return mockInviteLink.WorkspaceId, nil
+	case "full-token":
+		return 0, models.ErrWorkspaceLimit
+	case "other-email-token":
+		return 0, models.ErrInviteEmailMismatch
+	default:
+		return 0, models.ErrNoRecord
+	}
+}
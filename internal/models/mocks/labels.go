@@ -0,0 +1,49 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/andres085/task_manager/internal/models"
+)
+
+var mockLabels = []models.Label{
+	{ID: 1, WorkspaceId: 1, Name: "Bug", Color: "red", Created: time.Now()},
+	{ID: 2, WorkspaceId: 1, Name: "Feature", Color: "green", Created: time.Now()},
+}
+
+type LabelModel struct{}
+
+func (m *LabelModel) Insert(workspaceId int, name, color string) (int, error) {
+	for _, label := range mockLabels {
+		if label.Name == name {
+			return 0, models.ErrDuplicateLabel
+		}
+	}
+	return 3, nil
+}
+
+func (m *LabelModel) Get(id int) (models.Label, error) {
+	for _, label := range mockLabels {
+		if label.ID == id {
+			return label, nil
+		}
+	}
+	return models.Label{}, models.ErrNoRecord
+}
+
+func (m *LabelModel) GetAll(workspaceId int) ([]models.Label, error) {
+	return mockLabels, nil
+}
+
+func (m *LabelModel) Update(id int, name, color string) error {
+	for _, label := range mockLabels {
+		if label.Name == name && label.ID != id {
+			return models.ErrDuplicateLabel
+		}
+	}
+	return nil
+}
+
+func (m *LabelModel) Delete(id int) error {
+	return nil
+}
@@ -0,0 +1,11 @@
+package mocks
+
+import (
+	"github.com/andres085/task_manager/internal/models"
+)
+
+type LimitModel struct{}
+
+func (m *LimitModel) GetQuota(userId int) (models.Quota, error) {
+	return models.Quota{Limits: models.DefaultLimits, Owned: 2, Member: 5}, nil
+}
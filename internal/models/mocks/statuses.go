@@ -0,0 +1,65 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/andres085/task_manager/internal/models"
+)
+
+var mockStatuses = []models.Status{
+	{ID: 1, WorkspaceId: 1, Name: "To Do", Position: 1, Created: time.Now()},
+	{ID: 2, WorkspaceId: 1, Name: "In Progress", Position: 2, Created: time.Now(), AllowedTo: []int{3}},
+	{ID: 3, WorkspaceId: 1, Name: "Completed", Position: 3, IsDone: true, Created: time.Now()},
+}
+
+type StatusModel struct{}
+
+func (m *StatusModel) Insert(workspaceId int, name string, isDone bool) (int, error) {
+	for _, status := range mockStatuses {
+		if status.Name == name {
+			return 0, models.ErrDuplicateStatus
+		}
+	}
+	return 4, nil
+}
+
+func (m *StatusModel) Get(id int) (models.Status, error) {
+	for _, status := range mockStatuses {
+		if status.ID == id {
+			return status, nil
+		}
+	}
+	return models.Status{}, models.ErrNoRecord
+}
+
+func (m *StatusModel) GetAll(workspaceId int) ([]models.Status, error) {
+	return mockStatuses, nil
+}
+
+func (m *StatusModel) GetNamesByUser(userId int) ([]string, error) {
+	names := make([]string, len(mockStatuses))
+	for i, status := range mockStatuses {
+		names[i] = status.Name
+	}
+	return names, nil
+}
+
+func (m *StatusModel) Update(id int, name string, isDone bool, allowedTo []int) error {
+	for _, status := range mockStatuses {
+		if status.Name == name && status.ID != id {
+			return models.ErrDuplicateStatus
+		}
+	}
+	return nil
+}
+
+func (m *StatusModel) Move(id int, up bool) error {
+	return nil
+}
+
+func (m *StatusModel) Delete(id int) error {
+	if id == 1 {
+		return models.ErrStatusInUse
+	}
+	return nil
+}
@@ -2,11 +2,14 @@ package mocks
 
 import (
 	"errors"
+	"slices"
 	"time"
 
 	"github.com/andres085/task_manager/internal/models"
 )
 
+var pastDueDate = time.Now().AddDate(0, 0, -1)
+
 var firstMockTask = models.Task{
 	ID:          1,
 	Title:       "First Test Task",
@@ -17,6 +20,10 @@ var firstMockTask = models.Task{
 	Status:      "To Do",
 	WorkspaceId: 1,
 	UserId:      2,
+	Labels:      []models.Label{mockLabels[0]},
+	Assignees:   []models.User{{ID: 2, FirstName: "Pete", LastName: "Peterson", Email: "pete@mail.com"}},
+	Watchers:    []models.User{{ID: 1, FirstName: "Test", LastName: "McTester", Email: "testmctesterson@mail.com"}},
+	Progress:    models.TaskProgress{SubtasksTotal: 1, ChecklistTotal: 2, ChecklistDone: 1},
 }
 
 var secondMockTask = models.Task{
@@ -29,6 +36,20 @@ var secondMockTask = models.Task{
 	Status:      "To Do",
 	WorkspaceId: 1,
 	UserId:      1,
+	DueDate:     &pastDueDate,
+}
+
+var subMockTask = models.Task{
+	ID:          5,
+	Title:       "Sub Test Task",
+	Content:     "Sub Test Task Content",
+	Priority:    "LOW",
+	Created:     time.Now(),
+	Finished:    nil,
+	Status:      "To Do",
+	WorkspaceId: 1,
+	UserId:      1,
+	ParentId:    &firstMockTask.ID,
 }
 
 var wrongMockTask = models.Task{
@@ -43,9 +64,74 @@ var wrongMockTask = models.Task{
 	UserId:      1,
 }
 
+var viewerMockTask = models.Task{
+	ID:          6,
+	Title:       "Viewer Test Task",
+	Content:     "Viewer Test Task Content",
+	Priority:    "LOW",
+	Created:     time.Now(),
+	Finished:    nil,
+	Status:      "To Do",
+	WorkspaceId: 7,
+	UserId:      2,
+}
+
+var finishedDate = time.Now().Add(-time.Hour)
+
+var finishedMockTask = models.Task{
+	ID:          12,
+	Title:       "Finished Test Task",
+	Content:     "Finished Test Task Content",
+	Priority:    "LOW",
+	Created:     time.Now(),
+	Finished:    &finishedDate,
+	Status:      "Completed",
+	WorkspaceId: 1,
+	UserId:      1,
+}
+
+var archivedMockTask = models.Task{
+	ID:          11,
+	Title:       "Archived Test Task",
+	Content:     "Archived Test Task Content",
+	Priority:    "LOW",
+	Created:     time.Now(),
+	Finished:    &finishedDate,
+	Status:      "Completed",
+	WorkspaceId: 11,
+	UserId:      1,
+}
+
+var deletedAt = time.Now().Add(-time.Hour)
+
+var trashedMockTask = models.Task{
+	ID:          8,
+	Title:       "Trashed Test Task",
+	Content:     "Trashed Test Task Content",
+	Priority:    "LOW",
+	Created:     time.Now(),
+	Finished:    nil,
+	Status:      "To Do",
+	WorkspaceId: 1,
+	UserId:      1,
+	DeletedAt:   &deletedAt,
+}
+
 type TaskModel struct{}
 
-func (t *TaskModel) Insert(title, content, priority string, workspaceId, userId int) (int, error) {
+func (t *TaskModel) Insert(title, content, priority, status string, workspaceId int, assigneeIds, labelIds []int, parentId int, dueDate, dueTime string) (int, error) {
+	if status == "Blocked" {
+		return 0, models.ErrInvalidStatus
+	}
+	if !mockAssignees(assigneeIds) {
+		return 0, models.ErrInvalidAssignee
+	}
+	if !mockLabelIDs(labelIds) {
+		return 0, models.ErrInvalidLabel
+	}
+	if parentId != 0 && parentId != 1 {
+		return 0, models.ErrInvalidParent
+	}
 	return 2, nil
 }
 
@@ -53,44 +139,176 @@ func (t *TaskModel) Get(id int) (models.Task, error) {
 	switch id {
 	case 1:
 		return firstMockTask, nil
+	case 2:
+		return secondMockTask, nil
 	case 3:
 		return models.Task{}, errors.New("Internal Server Error")
 	case 4:
 		return wrongMockTask, nil
+	case 6:
+		return viewerMockTask, nil
+	case 11:
+		return archivedMockTask, nil
+	case 12:
+		return finishedMockTask, nil
 	default:
 		return models.Task{}, models.ErrNoRecord
 	}
 }
 
-func (m *TaskModel) GetAll(id, limit, offset int, title, priority, status, sort string) ([]models.Task, error) {
+func (m *TaskModel) GetAll(id, limit, offset int, filter models.TaskFilter) ([]models.Task, error) {
 	return []models.Task{firstMockTask, secondMockTask}, nil
 }
 
-func (m *TaskModel) GetTotalTasks(workspaceId int, title, priority, status string) (int, error) {
+func (m *TaskModel) GetSubtasks(parentId int) ([]models.Task, error) {
+	if parentId != 1 {
+		return nil, nil
+	}
+	return []models.Task{subMockTask}, nil
+}
+
+func (m *TaskModel) GetTotalTasks(workspaceId int, filter models.TaskFilter) (int, error) {
 	return 0, nil
 }
 
-func (m *TaskModel) Update(id int, title, content, priority string, userId int, status string) error {
+func (m *TaskModel) GetAssigned(userId, limit, offset int, filter models.TaskFilter) ([]models.Task, error) {
+	first, second, viewer := firstMockTask, secondMockTask, viewerMockTask
+	first.WorkspaceTitle = "First Workspace"
+	second.WorkspaceTitle = "First Workspace"
+	viewer.WorkspaceTitle = "Viewer Workspace"
+	return []models.Task{first, second, viewer}, nil
+}
+
+func (m *TaskModel) GetTotalAssigned(userId int, filter models.TaskFilter) (int, error) {
+	return 3, nil
+}
+
+func (m *TaskModel) Update(id, actorId int, title, content, priority string, assigneeIds, labelIds []int, status, dueDate, dueTime string) error {
+	if !mockAssignees(assigneeIds) {
+		return models.ErrInvalidAssignee
+	}
+	if !mockLabelIDs(labelIds) {
+		return models.ErrInvalidLabel
+	}
+	return mockStatusChange(status)
+}
+
+func mockLabelIDs(labelIds []int) bool {
+	for _, id := range labelIds {
+		if !slices.ContainsFunc(mockLabels, func(l models.Label) bool { return l.ID == id }) {
+			return false
+		}
+	}
+	return true
+}
+
+func mockAssignees(assigneeIds []int) bool {
+	for _, id := range assigneeIds {
+		if id != firstMockUser.ID && id != secondMockUser.ID {
+			return false
+		}
+	}
+	return len(assigneeIds) > 0
+}
+
+func (m *TaskModel) Watch(taskId, userId int) error {
 	return nil
 }
 
+func (m *TaskModel) Unwatch(taskId, userId int) error {
+	return nil
+}
+
+func (m *TaskModel) UpdateStatus(id, actorId int, status string) error {
+	return mockStatusChange(status)
+}
+
+func mockStatusChange(status string) error {
+	switch status {
+	case "Blocked":
+		return models.ErrInvalidStatus
+	case "Archived":
+		return models.ErrInvalidTransition
+	case "Shipped":
+		return models.ErrOpenSubtasks
+	case "Started":
+		return models.ErrTaskBlocked
+	default:
+		return nil
+	}
+}
+
+func (m *TaskModel) GetHistory(taskId int) ([]models.TaskEvent, error) {
+	return []models.TaskEvent{
+		{
+			ID:        1,
+			TaskId:    taskId,
+			UserId:    1,
+			Field:     "status",
+			OldValue:  "To Do",
+			NewValue:  "Completed",
+			Created:   time.Now(),
+			FirstName: "Test",
+			LastName:  "McTester",
+		},
+	}, nil
+}
+
 func (m *TaskModel) Delete(id int) (int, error) {
 	return 1, nil
 }
 
-func (m *TaskModel) ValidateOwnership(userId, taskId int) (bool, error) {
-	if userId == 1 && taskId == 1 {
-		return true, nil
+func (m *TaskModel) GetTrash(workspaceId int) ([]models.Task, error) {
+	if workspaceId == 1 {
+		return []models.Task{trashedMockTask}, nil
 	}
-	if userId != 1 && taskId == 1 {
-		return false, errors.New("Internal server error")
+	return nil, nil
+}
+
+func (m *TaskModel) Restore(id, workspaceId int) error {
+	switch {
+	case id == trashedMockTask.ID && workspaceId == 1:
+		return nil
+	case id == subMockTask.ID && workspaceId == 1:
+		return models.ErrInvalidParent
+	default:
+		return models.ErrNoRecord
 	}
-	return false, nil
 }
 
-func (m *TaskModel) ValidateAdmin(userId, taskId int) (bool, error) {
-	if userId == 1 && taskId == 1 {
-		return true, nil
+func (m *TaskModel) Purge(id, workspaceId int) error {
+	if id == trashedMockTask.ID && workspaceId == 1 {
+		return nil
+	}
+	return models.ErrNoRecord
+}
+
+func (m *TaskModel) PurgeDeleted(before time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *TaskModel) SetArchived(id int, archived bool) error {
+	if archived && id == firstMockTask.ID {
+		return models.ErrTaskNotFinished
+	}
+	return nil
+}
+
+func (m *TaskModel) ArchiveFinished(workspaceId int) (int, error) {
+	return 2, nil
+}
+
+func (m *TaskModel) GetMembership(userId, taskId int) (models.Membership, error) {
+	switch {
+	case userId == 1 && (taskId == 1 || taskId == 12):
+		return models.Membership{Role: models.RoleOwner}, nil
+	case userId == 1 && taskId == 6:
+		return models.Membership{Role: models.RoleViewer}, nil
+	case userId == 1 && taskId == 11:
+		return models.Membership{Role: models.RoleOwner, Archived: true}, nil
+	case userId == 1 && taskId == 4:
+		return models.Membership{}, errors.New("Internal server error")
+	default:
+		return models.Membership{}, models.ErrNoRecord
 	}
-	return false, nil
 }
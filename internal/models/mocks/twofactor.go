@@ -0,0 +1,47 @@
+package mocks
+
+import (
+	"github.com/andres085/task_manager/internal/models"
+)
+
+type TwoFactorModel struct{}
+
+func (m *TwoFactorModel) Get(userId int) (*models.TwoFactor, error) {
+	switch userId {
+	case 1:
+		return &models.TwoFactor{UserID: 1, Secret: "JBSWY3DPEHPK3PXP"}, nil
+	case 4:
+		return &models.TwoFactor{UserID: 4, Secret: "JBSWY3DPEHPK3PXP", Enabled: true, RecoveryCodes: 9}, nil
+	default:
+		return nil, models.ErrNoRecord
+	}
+}
+
+func (m *TwoFactorModel) Enabled(userId int) (bool, error) {
+	return userId == 4, nil
+}
+
+func (m *TwoFactorModel) Setup(userId int) (string, error) {
+	if userId == 4 {
+		return "", models.ErrTwoFactorEnabled
+	}
+	return "JBSWY3DPEHPK3PXP", nil
+}
+
+func (m *TwoFactorModel) Enable(userId int, code string) ([]string, error) {
+	if code != "123456" {
+		return nil, models.ErrInvalidCode
+	}
+	return []string{"abcde-fghij", "klmno-pqrst"}, nil
+}
+
+func (m *TwoFactorModel) Disable(userId int) error {
+	return nil
+}
+
+func (m *TwoFactorModel) Authenticate(userId int, code string) error {
+	if code != "123456" && code != "abcde-fghij" {
+		return models.ErrInvalidCode
+	}
+	return nil
+}
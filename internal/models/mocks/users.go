@@ -12,7 +12,7 @@ var firstMockUser = models.UserWithRole{
 	FirstName: "Test",
 	LastName:  "McTester",
 	Email:     "testmctesterson@mail.com",
-	Role:      "ADMIN",
+	Role:      models.RoleOwner,
 }
 
 var secondMockUser = models.UserWithRole{
@@ -20,33 +20,100 @@ var secondMockUser = models.UserWithRole{
 	FirstName: "Pete",
 	LastName:  "Peterson",
 	Email:     "pete@mail.com",
-	Role:      "MEMBER",
+	Role:      models.RoleEditor,
 }
 
-func (m *UserModel) Insert(firstName, lastName, email, password string) error {
+func (m *UserModel) Insert(firstName, lastName, email, password string) (int, error) {
 	switch email {
 	case "dupe@example.com":
-		return models.ErrDuplicateEmail
+		return 0, models.ErrDuplicateEmail
 	default:
-		return nil
+		return 3, nil
 	}
 }
 
 func (m *UserModel) GetUser(userId int) (*models.User, error) {
+	if userId == firstMockUser.ID {
+		return &models.User{ID: firstMockUser.ID, FirstName: firstMockUser.FirstName, LastName: firstMockUser.LastName, Email: firstMockUser.Email, Verified: true}, nil
+	}
 	return &models.User{}, nil
 }
 
-func (m *UserModel) GetUserToInvite(email string, workspaceId int) (*models.User, error) {
-	if email != firstMockUser.Email {
-		return nil, models.ErrNoRecord
+func (m *UserModel) GetByEmail(email string) (*models.User, error) {
+	switch email {
+	case firstMockUser.Email:
+		return &models.User{ID: firstMockUser.ID, FirstName: firstMockUser.FirstName, LastName: firstMockUser.LastName, Email: firstMockUser.Email, Verified: true}, nil
+	case "unverified@example.com":
+		return &models.User{ID: 3, FirstName: "Una", LastName: "Verified", Email: email}, nil
+	default:
+		return &models.User{}, models.ErrNoRecord
 	}
-	return &models.User{}, nil
 }
 
-func (m *UserModel) AddUserToWorkspace(userId, workspaceId int) error {
+func (m *UserModel) Verify(token string) error {
+	if token != "VALIDUSERTOKEN" {
+		return models.ErrInvalidToken
+	}
 	return nil
 }
 
+func (m *UserModel) ResetPassword(token, password string) (int, error) {
+	if token != "VALIDUSERTOKEN" {
+		return 0, models.ErrInvalidToken
+	}
+	return 1, nil
+}
+
+func (m *UserModel) UpdateProfile(userId int, firstName, lastName string) error {
+	return nil
+}
+
+func (m *UserModel) ChangePassword(userId int, currentPassword, newPassword string) error {
+	if currentPassword != "pa$$word" {
+		return models.ErrInvalidCredentials
+	}
+	return nil
+}
+
+func (m *UserModel) RequestEmailChange(userId int, password, email string) error {
+	if password != "pa$$word" {
+		return models.ErrInvalidCredentials
+	}
+	if email == "dupe@example.com" {
+		return models.ErrDuplicateEmail
+	}
+	return nil
+}
+
+func (m *UserModel) CheckPassword(userId int, password string) error {
+	if password != "pa$$word" {
+		return models.ErrInvalidCredentials
+	}
+	return nil
+}
+
+func (m *UserModel) ConfirmEmailChange(token string) error {
+	if token != "VALIDUSERTOKEN" {
+		return models.ErrInvalidToken
+	}
+	return nil
+}
+
+func (m *UserModel) GetUserToInvite(email string, workspaceId int) (*models.User, error) {
+	switch email {
+	case firstMockUser.Email:
+		return &models.User{}, nil
+	case secondMockUser.Email:
+		return &models.User{ID: secondMockUser.ID, FirstName: secondMockUser.FirstName, LastName: secondMockUser.LastName, Email: email, Verified: true}, nil
+	case "new@mail.com":
+		return &models.User{ID: 3, FirstName: "New", LastName: "User", Email: email, Verified: true}, nil
+	case "full@mail.com":
+		return &models.User{ID: 4, FirstName: "Full", LastName: "User", Email: email, Verified: true}, nil
+	default:
+		return nil, models.ErrNoRecord
+	}
+}
+
 func (m *UserModel) GetWorkspaceUsers(workspaceId int) ([]models.UserWithRole, error) {
 	return []models.UserWithRole{firstMockUser, secondMockUser}, nil
 }
@@ -56,6 +123,14 @@ func (m *UserModel) Authenticate(email, password string) (int, error) {
 		return 1, nil
 	}
 
+	if email == "unverified@example.com" && password == "pa$$word" {
+		return 0, models.ErrUnverifiedEmail
+	}
+
+	if email == "twofactor@example.com" && password == "pa$$word" {
+		return 4, nil
+	}
+
 	return 0, models.ErrInvalidCredentials
 }
 
@@ -68,14 +143,14 @@ func (m *UserModel) GetWorkspacesAsMemberCount(email string) (int, error) {
 
 func (m *UserModel) Exists(id int) (bool, error) {
 	switch id {
-	case 1:
+	case 1, 4:
 		return true, nil
 	default:
 		return false, nil
 	}
 }
 
-func (m *UserModel) RemoveUserFromWorkspace(workspaceId, userId int) (int, error) {
+func (m *UserModel) RemoveUserFromWorkspace(workspaceId, userId, actorId int) (int, error) {
 	switch userId {
 	case 1:
 		return 1, nil
@@ -85,3 +160,36 @@ func (m *UserModel) RemoveUserFromWorkspace(workspaceId, userId int) (int, error
 		return 1, nil
 	}
 }
+
+func (m *UserModel) UpdateWorkspaceRole(workspaceId, userId int, role string) error {
+	switch userId {
+	case 1:
+		return models.ErrOwnerRole
+	case 2:
+		return nil
+	default:
+		return models.ErrNoRecord
+	}
+}
+
+func (m *UserModel) TransferOwnership(workspaceId, ownerId, newOwnerId int) error {
+	switch newOwnerId {
+	case 2:
+		return nil
+	case 4:
+		return models.ErrWorkspaceLimit
+	default:
+		return models.ErrNoRecord
+	}
+}
+
+func (m *UserModel) LeaveWorkspace(workspaceId, userId int) (int, error) {
+	switch workspaceId {
+	case 1:
+		return 0, models.ErrOwnerRole
+	case 7:
+		return 2, nil
+	default:
+		return 0, models.ErrNoRecord
+	}
+}
@@ -0,0 +1,11 @@
+package mocks
+
+import (
+	"time"
+)
+
+type UserTokenModel struct{}
+
+func (m *UserTokenModel) Insert(userId int, scope string, ttl time.Duration) (string, error) {
+	return "VALIDUSERTOKEN", nil
+}
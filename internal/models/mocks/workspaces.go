@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"errors"
+	"time"
 
 	"github.com/andres085/task_manager/internal/models"
 )
@@ -18,9 +19,33 @@ var secondMockWorkspace = models.Workspace{
 	Description: "Second workspace Description",
 }
 
+var viewerMockWorkspace = models.Workspace{
+	ID:          7,
+	Title:       "Viewer Workspace",
+	Description: "Viewer workspace Description",
+}
+
+var archivedMockWorkspace = models.Workspace{
+	ID:          11,
+	Title:       "Archived Workspace",
+	Description: "Archived workspace Description",
+	Archived:    true,
+}
+
+var trashedMockWorkspace = models.Workspace{
+	ID:          9,
+	Title:       "Trashed Workspace",
+	Description: "Trashed workspace Description",
+	DeletedAt:   &deletedAt,
+	Role:        models.RoleOwner,
+}
+
 type WorkspaceModel struct{}
 
 func (t *WorkspaceModel) Insert(title, description string, userId int) (int, error) {
+	if title == "Over The Limit" {
+		return 0, models.ErrWorkspaceLimit
+	}
 	return 2, nil
 }
 
@@ -28,16 +53,29 @@ func (t *WorkspaceModel) Get(id int) (models.Workspace, error) {
 	switch id {
 	case 1:
 		return firstMockWorkspace, nil
+	case 2:
+		return secondMockWorkspace, nil
+	case 7:
+		return viewerMockWorkspace, nil
+	case 11:
+		return archivedMockWorkspace, nil
 	default:
 		return models.Workspace{}, models.ErrNoRecord
 	}
 }
 
-func (m *WorkspaceModel) GetAll(userId int, role string) ([]models.Workspace, error) {
+func (m *WorkspaceModel) GetAll(userId int, owned, includeArchived bool) ([]models.Workspace, error) {
+	if includeArchived {
+		return []models.Workspace{firstMockWorkspace, secondMockWorkspace, archivedMockWorkspace}, nil
+	}
 	return []models.Workspace{firstMockWorkspace, secondMockWorkspace}, nil
 }
 
-func (m *WorkspaceModel) Update(id int, title, description string) error {
+func (m *WorkspaceModel) Update(id int, title, description string, blockOpenSubtasks bool) error {
+	return nil
+}
+
+func (m *WorkspaceModel) SetArchived(id int, archived bool) error {
 	return nil
 }
 
@@ -45,22 +83,43 @@ func (m *WorkspaceModel) Delete(id int) (int, error) {
 	return 1, nil
 }
 
-func (m *WorkspaceModel) ValidateOwnership(userId, workspaceId int) (bool, error) {
-	if userId == 1 && workspaceId == 1 {
-		return true, nil
-	}
-	if userId == 1 && workspaceId == 0 {
-		return false, errors.New("Internal Server Error")
+func (m *WorkspaceModel) GetTrash(userId int) ([]models.Workspace, error) {
+	return []models.Workspace{trashedMockWorkspace}, nil
+}
+
+func (m *WorkspaceModel) Restore(id, userId int) error {
+	switch id {
+	case trashedMockWorkspace.ID:
+		return nil
+	case 10:
+		return models.ErrWorkspaceLimit
+	default:
+		return models.ErrNoRecord
 	}
-	return false, nil
 }
 
-func (m *WorkspaceModel) ValidateAdmin(userId, workspaceId int) (bool, error) {
-	if userId == 1 && workspaceId == 1 {
-		return true, nil
+func (m *WorkspaceModel) Purge(id, userId int) error {
+	if id == trashedMockWorkspace.ID {
+		return nil
 	}
-	if userId == 1 && workspaceId == -1 {
-		return false, errors.New("Internal server error")
+	return models.ErrNoRecord
+}
+
+func (m *WorkspaceModel) PurgeDeleted(before time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *WorkspaceModel) GetMembership(userId, workspaceId int) (models.Membership, error) {
+	switch {
+	case userId == 1 && workspaceId == 1:
+		return models.Membership{Role: models.RoleOwner}, nil
+	case userId == 1 && workspaceId == 7:
+		return models.Membership{Role: models.RoleViewer}, nil
+	case userId == 1 && workspaceId == 11:
+		return models.Membership{Role: models.RoleOwner, Archived: true}, nil
+	case userId == 1 && workspaceId < 1:
+		return models.Membership{}, errors.New("Internal server error")
+	default:
+		return models.Membership{}, models.ErrNoRecord
 	}
-	return false, nil
 }
@@ -0,0 +1,70 @@
+package models
+
+const (
+	RoleOwner  = "OWNER"
+	RoleAdmin  = "ADMIN"
+	RoleEditor = "EDITOR"
+	RoleViewer = "VIEWER"
+)
+
+// InviteRoles are the roles a user can be invited with; a workspace has a single owner.
+var InviteRoles = []string{RoleAdmin, RoleEditor, RoleViewer}
+
+type Action int
+
+const (
+	ActionView Action = iota
+	ActionEditTasks
+	ActionDeleteTasks
+	ActionManageWorkspace
+	ActionDeleteWorkspace
+	ActionTransferOwnership
+	ActionArchiveWorkspace
+	// ActionParticipate covers the changes members make for themselves, like watching a task
+	// or leaving the workspace.
+	ActionParticipate
+)
+
+var rolePermissions = map[string][]Action{
+	RoleOwner:  {ActionView, ActionParticipate, ActionEditTasks, ActionDeleteTasks, ActionManageWorkspace, ActionDeleteWorkspace, ActionTransferOwnership, ActionArchiveWorkspace},
+	RoleAdmin:  {ActionView, ActionParticipate, ActionEditTasks, ActionDeleteTasks, ActionManageWorkspace, ActionArchiveWorkspace},
+	RoleEditor: {ActionView, ActionParticipate, ActionEditTasks},
+	RoleViewer: {ActionView, ActionParticipate},
+}
+
+// Can reports whether a workspace role allows the action. Unknown roles, including the
+// empty role of non-members, can't do anything.
+func Can(role string, action Action) bool {
+	for _, a := range rolePermissions[role] {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// archivedActions are the only actions left in an archived workspace, which is read-only
+// until it is unarchived.
+var archivedActions = []Action{ActionView, ActionDeleteWorkspace, ActionArchiveWorkspace}
+
+// CanArchived reports whether the action is still possible once a workspace is archived.
+func CanArchived(action Action) bool {
+	for _, a := range archivedActions {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ValidInviteRole(role string) bool {
+	for _, r := range InviteRoles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
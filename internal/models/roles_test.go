@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/andres085/task_manager/internal/assert"
+)
+
+func TestCan(t *testing.T) {
+	tests := []struct {
+		name   string
+		role   string
+		action Action
+		want   bool
+	}{
+		{name: "Owner deletes workspace", role: RoleOwner, action: ActionDeleteWorkspace, want: true},
+		{name: "Admin manages workspace", role: RoleAdmin, action: ActionManageWorkspace, want: true},
+		{name: "Admin can't delete workspace", role: RoleAdmin, action: ActionDeleteWorkspace, want: false},
+		{name: "Owner transfers ownership", role: RoleOwner, action: ActionTransferOwnership, want: true},
+		{name: "Admin can't transfer ownership", role: RoleAdmin, action: ActionTransferOwnership, want: false},
+		{name: "Admin archives workspace", role: RoleAdmin, action: ActionArchiveWorkspace, want: true},
+		{name: "Editor can't archive workspace", role: RoleEditor, action: ActionArchiveWorkspace, want: false},
+		{name: "Editor edits tasks", role: RoleEditor, action: ActionEditTasks, want: true},
+		{name: "Editor can't delete tasks", role: RoleEditor, action: ActionDeleteTasks, want: false},
+		{name: "Viewer views", role: RoleViewer, action: ActionView, want: true},
+		{name: "Viewer participates", role: RoleViewer, action: ActionParticipate, want: true},
+		{name: "Viewer can't edit tasks", role: RoleViewer, action: ActionEditTasks, want: false},
+		{name: "Non-member can't view", role: "", action: ActionView, want: false},
+		{name: "Legacy role can't view", role: "MEMBER", action: ActionView, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, Can(tt.role, tt.action), tt.want)
+		})
+	}
+}
+
+func TestCanArchived(t *testing.T) {
+	assert.Equal(t, CanArchived(ActionView), true)
+	assert.Equal(t, CanArchived(ActionArchiveWorkspace), true)
+	assert.Equal(t, CanArchived(ActionEditTasks), false)
+	assert.Equal(t, CanArchived(ActionManageWorkspace), false)
+	assert.Equal(t, CanArchived(ActionParticipate), false)
+}
@@ -0,0 +1,375 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type Status struct {
+	ID          int       `json:"id"`
+	WorkspaceId int       `json:"workspace_id"`
+	Name        string    `json:"name"`
+	Position    int       `json:"position"`
+	IsDone      bool      `json:"is_done"`
+	Created     time.Time `json:"created"`
+	AllowedTo   []int     `json:"allowed_to"`
+}
+
+// A status without any transitions configured can move to any other status.
+func (s Status) CanMoveTo(statusId int) bool {
+	return len(s.AllowedTo) == 0 || s.ID == statusId || s.HasTransitionTo(statusId)
+}
+
+func (s Status) HasTransitionTo(statusId int) bool {
+	for _, id := range s.AllowedTo {
+		if id == statusId {
+			return true
+		}
+	}
+
+	return false
+}
+
+type StatusModelInterface interface {
+	Insert(workspaceId int, name string, isDone bool) (int, error)
+	Get(id int) (Status, error)
+	GetAll(workspaceId int) ([]Status, error)
+	GetNamesByUser(userId int) ([]string, error)
+	Update(id int, name string, isDone bool, allowedTo []int) error
+	Move(id int, up bool) error
+	Delete(id int) error
+}
+
+type StatusModel struct {
+	DB *sql.DB
+}
+
+var defaultStatuses = []struct {
+	name   string
+	isDone bool
+}{
+	{"To Do", false},
+	{"In Progress", false},
+	{"Completed", true},
+}
+
+func insertDefaultStatuses(tx *sql.Tx, workspaceId int64) error {
+	stmt := `INSERT INTO workspace_statuses (workspace_id, name, position, is_done, created) VALUES (?, ?, ?, ?, UTC_TIMESTAMP())`
+
+	for i, status := range defaultStatuses {
+		_, err := tx.Exec(stmt, workspaceId, status.name, i+1, status.isDone)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *StatusModel) Insert(workspaceId int, name string, isDone bool) (int, error) {
+	var exists bool
+
+	err := m.DB.QueryRow(`SELECT EXISTS (SELECT true FROM workspace_statuses WHERE workspace_id = ? AND name = ?)`, workspaceId, name).Scan(&exists)
+	if err != nil {
+		return 0, err
+	}
+
+	if exists {
+		return 0, ErrDuplicateStatus
+	}
+
+	stmt := `INSERT INTO workspace_statuses (workspace_id, name, position, is_done, created)
+	SELECT ?, ?, COALESCE(MAX(position), 0) + 1, ?, UTC_TIMESTAMP() FROM workspace_statuses WHERE workspace_id = ?`
+
+	result, err := m.DB.Exec(stmt, workspaceId, name, isDone, workspaceId)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (m *StatusModel) Get(id int) (Status, error) {
+	stmt := `SELECT id, workspace_id, name, position, is_done, created FROM workspace_statuses WHERE id = ?`
+
+	var s Status
+
+	err := m.DB.QueryRow(stmt, id).Scan(&s.ID, &s.WorkspaceId, &s.Name, &s.Position, &s.IsDone, &s.Created)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Status{}, ErrNoRecord
+		} else {
+			return Status{}, err
+		}
+	}
+
+	s.AllowedTo, err = allowedTransitions(m.DB, s.ID)
+	if err != nil {
+		return Status{}, err
+	}
+
+	return s, nil
+}
+
+func (m *StatusModel) GetAll(workspaceId int) ([]Status, error) {
+	stmt := `SELECT id, workspace_id, name, position, is_done, created FROM workspace_statuses WHERE workspace_id = ? ORDER BY position`
+
+	rows, err := m.DB.Query(stmt, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var statuses []Status
+
+	for rows.Next() {
+		var s Status
+
+		err = rows.Scan(&s.ID, &s.WorkspaceId, &s.Name, &s.Position, &s.IsDone, &s.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		statuses = append(statuses, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range statuses {
+		statuses[i].AllowedTo, err = allowedTransitions(m.DB, statuses[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return statuses, nil
+}
+
+// GetNamesByUser lists the status names used across the workspaces the user belongs to,
+// in the order they usually appear in a workflow.
+func (m *StatusModel) GetNamesByUser(userId int) ([]string, error) {
+	stmt := `SELECT s.name FROM workspace_statuses s JOIN users_workspaces uw ON uw.workspace_id = s.workspace_id
+	JOIN workspaces w ON w.id = s.workspace_id WHERE uw.user_id = ? AND w.deleted_at IS NULL AND w.archived = false
+	GROUP BY s.name ORDER BY MIN(s.position), s.name`
+
+	rows, err := m.DB.Query(stmt, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var names []string
+
+	for rows.Next() {
+		var name string
+
+		err = rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+func (m *StatusModel) Update(id int, name string, isDone bool, allowedTo []int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var current Status
+
+	err = tx.QueryRow(`SELECT id, workspace_id, name, is_done FROM workspace_statuses WHERE id = ? FOR UPDATE`, id).Scan(&current.ID, &current.WorkspaceId, &current.Name, &current.IsDone)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	if current.Name != name {
+		var exists bool
+
+		err = tx.QueryRow(`SELECT EXISTS (SELECT true FROM workspace_statuses WHERE workspace_id = ? AND name = ?)`, current.WorkspaceId, name).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return ErrDuplicateStatus
+		}
+
+		_, err = tx.Exec(`UPDATE tasks SET status = ? WHERE workspace_id = ? AND status = ?`, name, current.WorkspaceId, current.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE workspace_statuses SET name = ?, is_done = ? WHERE id = ?`, name, isDone, id)
+	if err != nil {
+		return err
+	}
+
+	// Tasks already in the status are finished or reopened along with it.
+	if current.IsDone != isDone {
+		stmt := `UPDATE tasks SET finished = CASE WHEN ? THEN COALESCE(finished, UTC_TIMESTAMP()) ELSE NULL END WHERE workspace_id = ? AND status = ?`
+
+		_, err = tx.Exec(stmt, isDone, current.WorkspaceId, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`DELETE FROM status_transitions WHERE from_status_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	for _, toId := range allowedTo {
+		if toId == id {
+			continue
+		}
+
+		stmt := `INSERT INTO status_transitions (from_status_id, to_status_id)
+		SELECT ?, id FROM workspace_statuses WHERE id = ? AND workspace_id = ?`
+
+		_, err = tx.Exec(stmt, id, toId, current.WorkspaceId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *StatusModel) Move(id int, up bool) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var workspaceId, position int
+
+	err = tx.QueryRow(`SELECT workspace_id, position FROM workspace_statuses WHERE id = ? FOR UPDATE`, id).Scan(&workspaceId, &position)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	stmt := `SELECT id, position FROM workspace_statuses WHERE workspace_id = ? AND position > ? ORDER BY position ASC LIMIT 1 FOR UPDATE`
+	if up {
+		stmt = `SELECT id, position FROM workspace_statuses WHERE workspace_id = ? AND position < ? ORDER BY position DESC LIMIT 1 FOR UPDATE`
+	}
+
+	var neighbourId, neighbourPosition int
+
+	err = tx.QueryRow(stmt, workspaceId, position).Scan(&neighbourId, &neighbourPosition)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE workspace_statuses SET position = ? WHERE id = ?`, neighbourPosition, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE workspace_statuses SET position = ? WHERE id = ?`, position, neighbourId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *StatusModel) Delete(id int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var workspaceId int
+	var name string
+
+	err = tx.QueryRow(`SELECT workspace_id, name FROM workspace_statuses WHERE id = ? FOR UPDATE`, id).Scan(&workspaceId, &name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	var inUse, isLast bool
+
+	err = tx.QueryRow(`SELECT EXISTS (SELECT true FROM tasks WHERE workspace_id = ? AND status = ?)`, workspaceId, name).Scan(&inUse)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`SELECT COUNT(*) = 1 FROM workspace_statuses WHERE workspace_id = ?`, workspaceId).Scan(&isLast)
+	if err != nil {
+		return err
+	}
+
+	if inUse || isLast {
+		return ErrStatusInUse
+	}
+
+	_, err = tx.Exec(`DELETE FROM workspace_statuses WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func allowedTransitions(db *sql.DB, statusId int) ([]int, error) {
+	rows, err := db.Query(`SELECT to_status_id FROM status_transitions WHERE from_status_id = ?`, statusId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ids []int
+
+	for rows.Next() {
+		var id int
+
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
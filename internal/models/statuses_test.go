@@ -0,0 +1,162 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/andres085/task_manager/internal/assert"
+)
+
+func TestStatusInsertMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := StatusModel{db}
+
+	id, err := m.Insert(1, "Review", false)
+	assert.NilError(t, err)
+	assert.Equal(t, id, 4)
+
+	status, err := m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, status.Position, 4)
+
+	_, err = m.Insert(1, "Review", false)
+	assert.Equal(t, err, ErrDuplicateStatus)
+}
+
+func TestStatusGetAllMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := StatusModel{db}
+
+	statuses, err := m.GetAll(1)
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(statuses), 3)
+	assert.Equal(t, statuses[0].Name, "To Do")
+	assert.Equal(t, statuses[2].IsDone, true)
+}
+
+func TestStatusGetNamesByUserMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := StatusModel{db}
+
+	names, err := m.GetNamesByUser(1)
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(names), 3)
+	assert.Equal(t, names[0], "To Do")
+
+	names, err = m.GetNamesByUser(3)
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(names), 0)
+}
+
+func TestStatusUpdateMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := StatusModel{db}
+	tasks := TaskModel{db}
+
+	err := m.Update(1, "Backlog", false, []int{2})
+	assert.NilError(t, err)
+
+	status, err := m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, status.Name, "Backlog")
+	assert.Equal(t, len(status.AllowedTo), 1)
+	assert.Equal(t, status.CanMoveTo(3), false)
+
+	task, err := tasks.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, task.Status, "Backlog")
+
+	err = m.Update(1, "In Progress", false, nil)
+	assert.Equal(t, err, ErrDuplicateStatus)
+}
+
+func TestStatusUpdateIsDone(t *testing.T) {
+	db := newTestDB(t)
+
+	m := StatusModel{db}
+	tasks := TaskModel{db}
+
+	taskId, err := tasks.Insert("Open Task", "Open Task Body", "LOW", "To Do", 1, []int{1}, nil, 0, "", "")
+	assert.NilError(t, err)
+
+	err = m.Update(1, "To Do", true, nil)
+	assert.NilError(t, err)
+
+	task, err := tasks.Get(taskId)
+	assert.NilError(t, err)
+	assert.Equal(t, task.Finished != nil, true)
+
+	err = m.Update(1, "To Do", false, nil)
+	assert.NilError(t, err)
+
+	task, err = tasks.Get(taskId)
+	assert.NilError(t, err)
+	assert.Equal(t, task.Finished == nil, true)
+}
+
+func TestStatusMoveMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := StatusModel{db}
+
+	err := m.Move(3, true)
+	assert.NilError(t, err)
+
+	statuses, err := m.GetAll(1)
+	assert.NilError(t, err)
+	assert.Equal(t, statuses[1].Name, "Completed")
+	assert.Equal(t, statuses[2].Name, "In Progress")
+}
+
+func TestStatusDeleteMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := StatusModel{db}
+
+	err := m.Delete(1)
+	assert.Equal(t, err, ErrStatusInUse)
+
+	err = m.Delete(2)
+	assert.NilError(t, err)
+
+	_, err = m.Get(2)
+	assert.Equal(t, err, ErrNoRecord)
+}
+
+func TestTaskStatusWorkflow(t *testing.T) {
+	db := newTestDB(t)
+
+	m := StatusModel{db}
+	tasks := TaskModel{db}
+
+	err := tasks.UpdateStatus(1, 1, "Blocked")
+	assert.Equal(t, err, ErrInvalidStatus)
+
+	err = m.Update(1, "To Do", false, []int{2})
+	assert.NilError(t, err)
+
+	err = tasks.UpdateStatus(1, 1, "Completed")
+	assert.Equal(t, err, ErrInvalidTransition)
+
+	err = tasks.UpdateStatus(1, 1, "In Progress")
+	assert.NilError(t, err)
+
+	err = m.Update(3, "Done", true, nil)
+	assert.NilError(t, err)
+
+	err = tasks.UpdateStatus(1, 1, "Done")
+	assert.NilError(t, err)
+
+	task, err := tasks.Get(1)
+	assert.NilError(t, err)
+
+	if task.Finished == nil {
+		t.Errorf("got: nil; expected: %v", task.Finished)
+	}
+}
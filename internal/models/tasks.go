@@ -4,40 +4,199 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
 type Task struct {
-	ID          int
-	Title       string
-	Content     string
-	Priority    string
-	Created     time.Time
-	Finished    *time.Time
-	WorkspaceId int
-	UserId      int
-	Status      string
+	ID          int          `json:"id"`
+	Title       string       `json:"title"`
+	Content     string       `json:"content"`
+	Priority    string       `json:"priority"`
+	Created     time.Time    `json:"created"`
+	Finished    *time.Time   `json:"finished"`
+	WorkspaceId int          `json:"workspace_id"`
+	UserId      int          `json:"user_id"`
+	Status      string       `json:"status"`
+	DueDate     *time.Time   `json:"due_date"`
+	DueTime     *string      `json:"due_time"`
+	ParentId    *int         `json:"parent_id"`
+	Labels      []Label      `json:"labels"`
+	Assignees   []User       `json:"assignees"`
+	Watchers    []User       `json:"watchers"`
+	Progress    TaskProgress `json:"progress"`
+	DeletedAt   *time.Time   `json:"deleted_at,omitempty"`
+	Archived    bool         `json:"archived"`
+
+	WorkspaceTitle string `json:"workspace_title,omitempty"`
+}
+
+type TaskProgress struct {
+	SubtasksTotal  int `json:"subtasks_total"`
+	SubtasksDone   int `json:"subtasks_done"`
+	ChecklistTotal int `json:"checklist_total"`
+	ChecklistDone  int `json:"checklist_done"`
+}
+
+func (p TaskProgress) Total() int {
+	return p.SubtasksTotal + p.ChecklistTotal
+}
+
+func (p TaskProgress) Done() int {
+	return p.SubtasksDone + p.ChecklistDone
+}
+
+func (p TaskProgress) Percent() int {
+	if p.Total() == 0 {
+		return 0
+	}
+
+	return p.Done() * 100 / p.Total()
+}
+
+type TaskEvent struct {
+	ID        int
+	TaskId    int
+	UserId    int
+	Field     string
+	OldValue  string
+	NewValue  string
+	Created   time.Time
+	FirstName string
+	LastName  string
+}
+
+type TaskFilter struct {
+	Title    string
+	Priority string
+	Status   string
+	Due      string
+	Labels   []int
+	SortBy   string
+	Sort     string
+
+	IncludeArchived bool
+	AssignedTo      int
+	WatchedBy       int
+}
+
+func (t Task) WatchedBy(userId int) bool {
+	for _, u := range t.Watchers {
+		if u.ID == userId {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Task) IsOverdue() bool {
+	if t.DueDate == nil || t.Finished != nil {
+		return false
+	}
+
+	due := t.DueDate.UTC().Add(24*time.Hour - time.Second)
+	if t.DueTime != nil {
+		dueTime, err := time.Parse("15:04:05", *t.DueTime)
+		if err == nil {
+			due = time.Date(due.Year(), due.Month(), due.Day(), dueTime.Hour(), dueTime.Minute(), dueTime.Second(), 0, time.UTC)
+		}
+	}
+
+	return time.Now().UTC().After(due)
 }
 
 type TaskModelInterface interface {
-	Insert(title, content, priority string, workspaceId, userId int) (int, error)
+	Insert(title, content, priority, status string, workspaceId int, assigneeIds, labelIds []int, parentId int, dueDate, dueTime string) (int, error)
 	Get(id int) (Task, error)
-	GetAll(workspaceId, limit, offset int, title, priority, status, sort string) ([]Task, error)
-	GetTotalTasks(workspaceId int, title, priority, status string) (int, error)
-	Update(id int, title, content, priority string, userId int, status string) error
+	GetSubtasks(parentId int) ([]Task, error)
+	GetAll(workspaceId, limit, offset int, filter TaskFilter) ([]Task, error)
+	GetTotalTasks(workspaceId int, filter TaskFilter) (int, error)
+	GetAssigned(userId, limit, offset int, filter TaskFilter) ([]Task, error)
+	GetTotalAssigned(userId int, filter TaskFilter) (int, error)
+	Update(id, actorId int, title, content, priority string, assigneeIds, labelIds []int, status, dueDate, dueTime string) error
+	UpdateStatus(id, actorId int, status string) error
+	Watch(taskId, userId int) error
+	Unwatch(taskId, userId int) error
+	GetHistory(taskId int) ([]TaskEvent, error)
+	SetArchived(id int, archived bool) error
+	ArchiveFinished(workspaceId int) (int, error)
 	Delete(id int) (int, error)
-	ValidateOwnership(userId, taskId int) (bool, error)
-	ValidateAdmin(userId, taskId int) (bool, error)
+	GetTrash(workspaceId int) ([]Task, error)
+	Restore(id, workspaceId int) error
+	Purge(id, workspaceId int) error
+	PurgeDeleted(before time.Time) (int, error)
+	GetMembership(userId, taskId int) (Membership, error)
 }
 
 type TaskModel struct {
 	DB *sql.DB
 }
 
-func (m *TaskModel) Insert(title, content, priority string, workspaceId, userId int) (int, error) {
-	stmt := `INSERT INTO tasks (title, content, priority, created, workspace_id, user_id)  VALUES (?, ?, ?, UTC_TIMESTAMP(), ?, ?)`
+// Insert creates a task assigned to every user in assigneeIds. The first assignee is also
+// stored in tasks.user_id as the primary one.
+func (m *TaskModel) Insert(title, content, priority, status string, workspaceId int, assigneeIds, labelIds []int, parentId int, dueDate, dueTime string) (int, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	if parentId != 0 {
+		var parentWorkspaceId int
+		var grandparentId *int
+
+		err := tx.QueryRow(`SELECT workspace_id, parent_id FROM tasks WHERE id = ? AND deleted_at IS NULL`, parentId).Scan(&parentWorkspaceId, &grandparentId)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return 0, err
+		}
+
+		// Subtasks are only one level deep.
+		if errors.Is(err, sql.ErrNoRows) || parentWorkspaceId != workspaceId || grandparentId != nil {
+			return 0, ErrInvalidParent
+		}
+	}
+
+	if status != "" {
+		var exists bool
+
+		err := tx.QueryRow(`SELECT EXISTS (SELECT true FROM workspace_statuses WHERE workspace_id = ? AND name = ?)`, workspaceId, status).Scan(&exists)
+		if err != nil {
+			return 0, err
+		}
+
+		if !exists {
+			return 0, ErrInvalidStatus
+		}
+	} else {
+		err := tx.QueryRow(`SELECT name FROM workspace_statuses WHERE workspace_id = ? ORDER BY position LIMIT 1`, workspaceId).Scan(&status)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return 0, ErrInvalidStatus
+			}
+			return 0, err
+		}
+	}
+
+	// A new task has no previous status or subtasks, so this only checks whether it starts out done.
+	finished, err := nextFinished(tx, Task{WorkspaceId: workspaceId, Status: status}, status)
+	if err != nil {
+		return 0, err
+	}
+
+	assigneeIds, err = checkAssignees(tx, workspaceId, assigneeIds)
+	if err != nil {
+		return 0, err
+	}
+
+	labelIds, err = checkLabels(tx, workspaceId, labelIds)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt := `INSERT INTO tasks (title, content, priority, created, finished, workspace_id, user_id, due_date, due_time, parent_id, status)  VALUES (?, ?, ?, UTC_TIMESTAMP(), ?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := m.DB.Exec(stmt, title, content, priority, workspaceId, userId)
+	result, err := tx.Exec(stmt, title, content, priority, finished, workspaceId, assigneeIds[0], nullString(dueDate), nullString(dueTime), nullInt(parentId), status)
 	if err != nil {
 		return 0, err
 	}
@@ -47,15 +206,30 @@ func (m *TaskModel) Insert(title, content, priority string, workspaceId, userId
 		return 0, err
 	}
 
+	err = setAssignees(tx, int(id), assigneeIds)
+	if err != nil {
+		return 0, err
+	}
+
+	err = setLabels(tx, int(id), labelIds)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
 	return int(id), nil
 }
 
 func (m *TaskModel) Get(id int) (Task, error) {
-	stmt := `SELECT * FROM tasks WHERE id = ?`
+	stmt := `SELECT * FROM tasks WHERE id = ? AND deleted_at IS NULL`
 
 	var t Task
 
-	err := m.DB.QueryRow(stmt, id).Scan(&t.ID, &t.Title, &t.Content, &t.Priority, &t.Created, &t.Finished, &t.WorkspaceId, &t.UserId, &t.Status)
+	err := m.DB.QueryRow(stmt, id).Scan(&t.ID, &t.Title, &t.Content, &t.Priority, &t.Created, &t.Finished, &t.WorkspaceId, &t.UserId, &t.Status, &t.DueDate, &t.DueTime, &t.ParentId, &t.DeletedAt, &t.Archived)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Task{}, ErrNoRecord
@@ -64,24 +238,71 @@ func (m *TaskModel) Get(id int) (Task, error) {
 		}
 	}
 
-	return t, nil
+	tasks := []Task{t}
+
+	err = attachLabels(m.DB, tasks)
+	if err != nil {
+		return Task{}, err
+	}
+
+	err = attachUsers(m.DB, tasks)
+	if err != nil {
+		return Task{}, err
+	}
+
+	err = attachProgress(m.DB, tasks)
+	if err != nil {
+		return Task{}, err
+	}
+
+	return tasks[0], nil
 }
 
-func (m *TaskModel) GetAll(workspaceId, limit, offset int, title, priority, status, sort string) ([]Task, error) {
+func (m *TaskModel) GetSubtasks(parentId int) ([]Task, error) {
+	stmt := `SELECT * FROM tasks WHERE parent_id = ? AND deleted_at IS NULL ORDER BY created, id`
 
-	stmt := `SELECT * FROM tasks where workspace_id = ?`
+	rows, err := m.DB.Query(stmt, parentId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var tasks []Task
+
+	for rows.Next() {
+		var t Task
 
-	conditions := map[string]interface{}{
-		"workspaceId": workspaceId,
-		"title":       title,
-		"priority":    priority,
-		"status":      status,
-		"sort":        sort,
-		"limit":       limit,
-		"offset":      offset,
+		err = rows.Scan(&t.ID, &t.Title, &t.Content, &t.Priority, &t.Created, &t.Finished, &t.WorkspaceId, &t.UserId, &t.Status, &t.DueDate, &t.DueTime, &t.ParentId, &t.DeletedAt, &t.Archived)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, t)
 	}
 
-	preparedStmt, args := prepareStmt(stmt, conditions)
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = attachLabels(m.DB, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	err = attachUsers(m.DB, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (m *TaskModel) GetAll(workspaceId, limit, offset int, filter TaskFilter) ([]Task, error) {
+
+	stmt := `SELECT * FROM tasks where workspace_id = ? AND deleted_at IS NULL`
+
+	preparedStmt, args := prepareStmt(stmt, workspaceId, limit, offset, filter)
 
 	rows, err := m.DB.Query(preparedStmt, args...)
 	if err != nil {
@@ -95,7 +316,7 @@ func (m *TaskModel) GetAll(workspaceId, limit, offset int, title, priority, stat
 	for rows.Next() {
 		var t Task
 
-		err = rows.Scan(&t.ID, &t.Title, &t.Content, &t.Priority, &t.Created, &t.Finished, &t.WorkspaceId, &t.UserId, &t.Status)
+		err = rows.Scan(&t.ID, &t.Title, &t.Content, &t.Priority, &t.Created, &t.Finished, &t.WorkspaceId, &t.UserId, &t.Status, &t.DueDate, &t.DueTime, &t.ParentId, &t.DeletedAt, &t.Archived)
 		if err != nil {
 			return nil, err
 		}
@@ -107,30 +328,115 @@ func (m *TaskModel) GetAll(workspaceId, limit, offset int, title, priority, stat
 		return nil, err
 	}
 
+	err = attachLabels(m.DB, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	err = attachUsers(m.DB, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	err = attachProgress(m.DB, tasks)
+	if err != nil {
+		return nil, err
+	}
+
 	return tasks, nil
 }
 
-func (m *TaskModel) GetTotalTasks(workspaceId int, title, priority, status string) (int, error) {
+func (m *TaskModel) GetTotalTasks(workspaceId int, filter TaskFilter) (int, error) {
 	var totalTasks int
 
-	countStmt := `SELECT COUNT(*) FROM tasks WHERE workspace_id = ? `
+	countStmt := `SELECT COUNT(*) FROM tasks WHERE workspace_id = ? AND deleted_at IS NULL `
 
-	args := []interface{}{workspaceId}
+	conditions, args := filterConditions(filter)
+	countStmt += conditions
+	args = append([]interface{}{workspaceId}, args...)
 
-	if title != "" {
-		countStmt += "AND title LIKE ? "
-		args = append(args, "%"+title+"%")
+	err := m.DB.QueryRow(countStmt, args...).Scan(&totalTasks)
+	if err != nil {
+		return 0, err
 	}
-	if priority != "" {
-		countStmt += "AND priority = ? "
-		args = append(args, priority)
+
+	return totalTasks, nil
+}
+
+// assignedStmt selects the tasks assigned to a user in every workspace they still belong to,
+// together with the workspace title and the position of the task status in its workflow.
+const assignedStmt = `SELECT %s FROM (SELECT t.*, w.title AS workspace_title, COALESCE(s.position, 0) AS status_position FROM tasks t
+	JOIN workspaces w ON w.id = t.workspace_id AND w.deleted_at IS NULL AND w.archived = false
+	JOIN users_workspaces uw ON uw.workspace_id = t.workspace_id AND uw.user_id = ?
+	LEFT JOIN workspace_statuses s ON s.workspace_id = t.workspace_id AND s.name = t.status) AS assigned
+	WHERE deleted_at IS NULL `
+
+func (m *TaskModel) GetAssigned(userId, limit, offset int, filter TaskFilter) ([]Task, error) {
+	filter.AssignedTo = userId
+
+	stmt := fmt.Sprintf(assignedStmt, "*")
+
+	conditions, args := filterConditions(filter)
+	stmt += conditions + " ORDER BY workspace_title, workspace_id, status_position, " + orderBy(filter)
+	args = append([]interface{}{userId}, args...)
+
+	if limit > 0 {
+		stmt += " LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
 	}
-	if status != "" {
-		countStmt += "AND status = ? "
-		args = append(args, status)
+
+	rows, err := m.DB.Query(stmt, args...)
+	if err != nil {
+		return nil, err
 	}
 
-	err := m.DB.QueryRow(countStmt, args...).Scan(&totalTasks)
+	defer rows.Close()
+
+	var tasks []Task
+
+	for rows.Next() {
+		var t Task
+		var position int
+
+		err = rows.Scan(&t.ID, &t.Title, &t.Content, &t.Priority, &t.Created, &t.Finished, &t.WorkspaceId, &t.UserId, &t.Status, &t.DueDate, &t.DueTime, &t.ParentId, &t.DeletedAt, &t.Archived, &t.WorkspaceTitle, &position)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = attachLabels(m.DB, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	err = attachUsers(m.DB, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	err = attachProgress(m.DB, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (m *TaskModel) GetTotalAssigned(userId int, filter TaskFilter) (int, error) {
+	var totalTasks int
+
+	filter.AssignedTo = userId
+
+	conditions, args := filterConditions(filter)
+	args = append([]interface{}{userId}, args...)
+
+	err := m.DB.QueryRow(fmt.Sprintf(assignedStmt, "COUNT(*)")+conditions, args...).Scan(&totalTasks)
 	if err != nil {
 		return 0, err
 	}
@@ -138,30 +444,205 @@ func (m *TaskModel) GetTotalTasks(workspaceId int, title, priority, status strin
 	return totalTasks, nil
 }
 
-func (m *TaskModel) Update(id int, title, content, priority string, userId int, status string) error {
-	var finished *time.Time
+func (m *TaskModel) Update(id, actorId int, title, content, priority string, assigneeIds, labelIds []int, status, dueDate, dueTime string) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
 
-	if status == "Completed" {
-		now := time.Now()
-		finished = &now
-	} else {
-		finished = nil
+	defer tx.Rollback()
+
+	var current Task
+
+	err = tx.QueryRow(`SELECT * FROM tasks WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, id).Scan(&current.ID, &current.Title, &current.Content, &current.Priority, &current.Created, &current.Finished, &current.WorkspaceId, &current.UserId, &current.Status, &current.DueDate, &current.DueTime, &current.ParentId, &current.DeletedAt, &current.Archived)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		}
+		return err
 	}
 
-	stmt := `UPDATE tasks SET title = ?, content = ?, priority = ?, user_id = ?, status = ?, finished = ? where id = ?`
+	finished, err := nextFinished(tx, current, status)
+	if err != nil {
+		return err
+	}
 
-	_, err := m.DB.Exec(stmt, title, content, priority, userId, status, finished, id)
+	assigneeIds, err = checkAssignees(tx, current.WorkspaceId, assigneeIds)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	labelIds, err = checkLabels(tx, current.WorkspaceId, labelIds)
+	if err != nil {
+		return err
+	}
+
+	oldAssignees, err := assigneeNames(tx, id)
+	if err != nil {
+		return err
+	}
+
+	err = setAssignees(tx, id, assigneeIds)
+	if err != nil {
+		return err
+	}
+
+	err = setLabels(tx, id, labelIds)
+	if err != nil {
+		return err
+	}
+
+	newAssignees, err := assigneeNames(tx, id)
+	if err != nil {
+		return err
+	}
+
+	// Reopening an archived task brings it back to the tasks view.
+	archived := current.Archived && finished != nil
+
+	stmt := `UPDATE tasks SET title = ?, content = ?, priority = ?, user_id = ?, status = ?, finished = ?, due_date = ?, due_time = ?, archived = ? where id = ?`
+
+	_, err = tx.Exec(stmt, title, content, priority, assigneeIds[0], status, finished, nullString(dueDate), nullString(dueTime), archived, id)
+	if err != nil {
+		return err
+	}
+
+	changes := [][3]string{
+		{"title", current.Title, title},
+		{"content", current.Content, content},
+		{"priority", current.Priority, priority},
+		{"status", current.Status, status},
+		{"due", formatDue(current.DueDate, current.DueTime), strings.TrimSpace(dueDate + " " + dueTime)},
+		{"assignees", oldAssignees, newAssignees},
+	}
+
+	for _, change := range changes {
+		if change[1] == change[2] {
+			continue
+		}
+
+		err = insertTaskEvent(tx, id, actorId, change[0], change[1], change[2])
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *TaskModel) UpdateStatus(id, actorId int, status string) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var current Task
+
+	err = tx.QueryRow(`SELECT id, workspace_id, status, finished, archived FROM tasks WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, id).Scan(&current.ID, &current.WorkspaceId, &current.Status, &current.Finished, &current.Archived)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	if current.Status == status {
+		return nil
+	}
+
+	finished, err := nextFinished(tx, current, status)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE tasks SET status = ?, finished = ?, archived = ? WHERE id = ?`, status, finished, current.Archived && finished != nil, id)
+	if err != nil {
+		return err
+	}
+
+	err = insertTaskEvent(tx, id, actorId, "status", current.Status, status)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *TaskModel) GetHistory(taskId int) ([]TaskEvent, error) {
+	stmt := `SELECT e.id, e.task_id, e.user_id, e.field, COALESCE(e.old_value, ''), COALESCE(e.new_value, ''), e.created, u.firstName, u.lastName
+	FROM task_events e JOIN users u ON e.user_id = u.id WHERE e.task_id = ? ORDER BY e.created DESC, e.id DESC`
+
+	rows, err := m.DB.Query(stmt, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var events []TaskEvent
+
+	for rows.Next() {
+		var e TaskEvent
+
+		err = rows.Scan(&e.ID, &e.TaskId, &e.UserId, &e.Field, &e.OldValue, &e.NewValue, &e.Created, &e.FirstName, &e.LastName)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// SetArchived archives or unarchives a task. Only finished tasks can be archived.
+func (m *TaskModel) SetArchived(id int, archived bool) error {
+	var finished *time.Time
+
+	err := m.DB.QueryRow(`SELECT finished FROM tasks WHERE id = ? AND deleted_at IS NULL`, id).Scan(&finished)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	if archived && finished == nil {
+		return ErrTaskNotFinished
+	}
+
+	_, err = m.DB.Exec(`UPDATE tasks SET archived = ? WHERE id = ?`, archived, id)
+	return err
 }
 
+// ArchiveFinished archives every finished task of the workspace and returns how many were archived.
+func (m *TaskModel) ArchiveFinished(workspaceId int) (int, error) {
+	stmt := `UPDATE tasks SET archived = true WHERE workspace_id = ? AND finished IS NOT NULL AND archived = false AND deleted_at IS NULL`
+
+	result, err := m.DB.Exec(stmt, workspaceId)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+// Delete moves the task and its subtasks to the trash of the workspace.
 func (m *TaskModel) Delete(id int) (int, error) {
-	stmt := `DELETE FROM tasks where id = ?`
+	stmt := `UPDATE tasks SET deleted_at = UTC_TIMESTAMP() WHERE (id = ? OR parent_id = ?) AND deleted_at IS NULL`
 
-	result, err := m.DB.Exec(stmt, id)
+	result, err := m.DB.Exec(stmt, id, id)
 	if err != nil {
 		return 0, err
 	}
@@ -175,51 +656,286 @@ func (m *TaskModel) Delete(id int) (int, error) {
 	return int(r), nil
 }
 
-func (m *TaskModel) ValidateOwnership(userId, taskId int) (bool, error) {
-	var exists bool
+// GetTrash returns the deleted tasks of the workspace, leaving out subtasks that were deleted along with their parent.
+func (m *TaskModel) GetTrash(workspaceId int) ([]Task, error) {
+	stmt := `SELECT * FROM tasks WHERE workspace_id = ? AND deleted_at IS NOT NULL
+	AND (parent_id IS NULL OR parent_id NOT IN (SELECT id FROM tasks WHERE workspace_id = ? AND deleted_at IS NOT NULL))
+	ORDER BY deleted_at DESC, id`
 
-	stmt := "SELECT EXISTS (SELECT true FROM tasks JOIN users_workspaces uw ON tasks.workspace_id = uw.workspace_id WHERE tasks.id = ? AND uw.user_id = ?)"
+	rows, err := m.DB.Query(stmt, workspaceId, workspaceId)
+	if err != nil {
+		return nil, err
+	}
 
-	err := m.DB.QueryRow(stmt, taskId, userId).Scan(&exists)
-	return exists, err
-}
+	defer rows.Close()
+
+	var tasks []Task
+
+	for rows.Next() {
+		var t Task
 
-func (m *TaskModel) ValidateAdmin(userId, taskId int) (bool, error) {
-	var isAdmin bool
+		err = rows.Scan(&t.ID, &t.Title, &t.Content, &t.Priority, &t.Created, &t.Finished, &t.WorkspaceId, &t.UserId, &t.Status, &t.DueDate, &t.DueTime, &t.ParentId, &t.DeletedAt, &t.Archived)
+		if err != nil {
+			return nil, err
+		}
 
-	stmt := "SELECT EXISTS (SELECT true FROM tasks JOIN users_workspaces uw ON tasks.workspace_id = uw.workspace_id WHERE tasks.id = ? AND uw.user_id = ? AND uw.role = 'ADMIN')"
+		tasks = append(tasks, t)
+	}
 
-	err := m.DB.QueryRow(stmt, taskId, userId).Scan(&isAdmin)
-	return isAdmin, err
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
 }
 
-func prepareStmt(baseStmt string, conditions map[string]interface{}) (string, []interface{}) {
-	workspaceId := conditions["workspaceId"]
-	args := []interface{}{workspaceId}
+// Restore takes a task out of the trash together with the subtasks that were deleted with it.
+func (m *TaskModel) Restore(id, workspaceId int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
 
-	for column, value := range conditions {
+	defer tx.Rollback()
 
-		if column == "title" && value != "" {
-			baseStmt += fmt.Sprintf(" AND %s LIKE ? ", column)
-			args = append(args, fmt.Sprintf(`%%%s%%`, value))
-		}
-		if column == "priority" && value != "" {
-			baseStmt += fmt.Sprintf(" AND %s = ? ", column)
-			args = append(args, value)
+	var deletedAt time.Time
+	var parentDeleted bool
+
+	stmt := `SELECT t.deleted_at, COALESCE(p.deleted_at IS NOT NULL, false) FROM tasks t LEFT JOIN tasks p ON p.id = t.parent_id
+	WHERE t.id = ? AND t.workspace_id = ? AND t.deleted_at IS NOT NULL`
+
+	err = tx.QueryRow(stmt, id, workspaceId).Scan(&deletedAt, &parentDeleted)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
 		}
-		if column == "status" && value != "" {
-			baseStmt += fmt.Sprintf(" AND %s = ? ", column)
-			args = append(args, value)
+		return err
+	}
+
+	if parentDeleted {
+		return ErrInvalidParent
+	}
+
+	_, err = tx.Exec(`UPDATE tasks SET deleted_at = NULL WHERE id = ? OR (parent_id = ? AND deleted_at = ?)`, id, id, deletedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Purge permanently deletes a task from the trash.
+func (m *TaskModel) Purge(id, workspaceId int) error {
+	result, err := m.DB.Exec(`DELETE FROM tasks WHERE id = ? AND workspace_id = ? AND deleted_at IS NOT NULL`, id, workspaceId)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// PurgeDeleted permanently deletes the tasks that were moved to the trash before the given time.
+func (m *TaskModel) PurgeDeleted(before time.Time) (int, error) {
+	result, err := m.DB.Exec(`DELETE FROM tasks WHERE deleted_at < ?`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+// GetMembership returns the user's role in the workspace of the task, or ErrNoRecord when they aren't a member.
+func (m *TaskModel) GetMembership(userId, taskId int) (Membership, error) {
+	var membership Membership
+
+	stmt := `SELECT uw.role, w.archived FROM tasks JOIN users_workspaces uw ON tasks.workspace_id = uw.workspace_id JOIN workspaces w ON w.id = tasks.workspace_id
+	WHERE tasks.id = ? AND uw.user_id = ? AND tasks.deleted_at IS NULL AND w.deleted_at IS NULL`
+
+	err := m.DB.QueryRow(stmt, taskId, userId).Scan(&membership.Role, &membership.Archived)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Membership{}, ErrNoRecord
 		}
+		return Membership{}, err
+	}
+
+	return membership, nil
+}
+
+func prepareStmt(baseStmt string, workspaceId, limit, offset int, filter TaskFilter) (string, []interface{}) {
+	conditions, args := filterConditions(filter)
+	baseStmt += conditions
+	args = append([]interface{}{workspaceId}, args...)
+
+	baseStmt += " ORDER BY " + orderBy(filter)
+
+	if limit > 0 {
+		baseStmt += " LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
 	}
 
-	sort := conditions["sort"].(string)
+	return baseStmt, args
+}
+
+func orderBy(filter TaskFilter) string {
+	sort := filter.Sort
 	if sort != "asc" && sort != "desc" {
 		sort = "asc"
 	}
 
-	baseStmt += " ORDER BY created " + sort + " LIMIT ? OFFSET ?"
-	args = append(args, conditions["limit"], conditions["offset"])
+	if filter.SortBy == "due" {
+		return "due_date IS NULL, due_date " + sort + ", due_time " + sort
+	}
 
-	return baseStmt, args
+	return "created " + sort
+}
+
+func filterConditions(filter TaskFilter) (string, []interface{}) {
+	var conditions string
+	var args []interface{}
+
+	if !filter.IncludeArchived {
+		conditions += " AND archived = false "
+	}
+
+	if filter.Title != "" {
+		conditions += " AND title LIKE ? "
+		args = append(args, fmt.Sprintf(`%%%s%%`, filter.Title))
+	}
+	if filter.Priority != "" {
+		conditions += " AND priority = ? "
+		args = append(args, filter.Priority)
+	}
+	if filter.Status != "" {
+		conditions += " AND status = ? "
+		args = append(args, filter.Status)
+	}
+	if len(filter.Labels) > 0 {
+		// A task has to carry every selected label to match.
+		conditions += " AND id IN (SELECT task_id FROM task_labels WHERE label_id IN (" + placeholders(len(filter.Labels)) + ") GROUP BY task_id HAVING COUNT(DISTINCT label_id) = ?) "
+		for _, labelId := range filter.Labels {
+			args = append(args, labelId)
+		}
+		args = append(args, len(filter.Labels))
+	}
+	if filter.AssignedTo != 0 {
+		conditions += " AND id IN (SELECT task_id FROM task_users WHERE user_id = ? AND relation = ?) "
+		args = append(args, filter.AssignedTo, relationAssignee)
+	}
+	if filter.WatchedBy != 0 {
+		conditions += " AND id IN (SELECT task_id FROM task_users WHERE user_id = ? AND relation = ?) "
+		args = append(args, filter.WatchedBy, relationWatcher)
+	}
+
+	switch filter.Due {
+	case "overdue":
+		conditions += " AND finished IS NULL AND (due_date < DATE(UTC_TIMESTAMP()) OR (due_date = DATE(UTC_TIMESTAMP()) AND COALESCE(due_time, '23:59:59') < TIME(UTC_TIMESTAMP()))) "
+	case "week":
+		conditions += " AND YEARWEEK(due_date, 1) = YEARWEEK(DATE(UTC_TIMESTAMP()), 1) "
+	}
+
+	return conditions, args
+}
+
+func nextFinished(tx *sql.Tx, current Task, status string) (*time.Time, error) {
+	var statusId int
+	var isDone bool
+
+	err := tx.QueryRow(`SELECT id, is_done FROM workspace_statuses WHERE workspace_id = ? AND name = ?`, current.WorkspaceId, status).Scan(&statusId, &isDone)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if current.Status != status {
+			return nil, ErrInvalidStatus
+		}
+		return current.Finished, nil
+	}
+
+	if current.Status != status {
+		var restricted, allowed bool
+
+		stmt := `SELECT EXISTS (SELECT true FROM status_transitions t JOIN workspace_statuses s ON t.from_status_id = s.id WHERE s.workspace_id = ? AND s.name = ?),
+		EXISTS (SELECT true FROM status_transitions t JOIN workspace_statuses s ON t.from_status_id = s.id WHERE s.workspace_id = ? AND s.name = ? AND t.to_status_id = ?)`
+
+		err = tx.QueryRow(stmt, current.WorkspaceId, current.Status, current.WorkspaceId, current.Status, statusId).Scan(&restricted, &allowed)
+		if err != nil {
+			return nil, err
+		}
+
+		if restricted && !allowed {
+			return nil, ErrInvalidTransition
+		}
+
+		err = checkBlockers(tx, current, status)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !isDone {
+		return nil, nil
+	}
+
+	if current.Finished != nil {
+		return current.Finished, nil
+	}
+
+	var blocked bool
+
+	stmt := `SELECT w.block_open_subtasks AND EXISTS (SELECT true FROM tasks WHERE parent_id = ? AND finished IS NULL AND deleted_at IS NULL) FROM workspaces w WHERE w.id = ?`
+
+	err = tx.QueryRow(stmt, current.ID, current.WorkspaceId).Scan(&blocked)
+	if err != nil {
+		return nil, err
+	}
+
+	if blocked {
+		return nil, ErrOpenSubtasks
+	}
+
+	now := time.Now()
+	return &now, nil
+}
+
+func insertTaskEvent(tx *sql.Tx, taskId, actorId int, field, oldValue, newValue string) error {
+	stmt := `INSERT INTO task_events (task_id, user_id, field, old_value, new_value, created) VALUES (?, ?, ?, ?, ?, UTC_TIMESTAMP())`
+
+	_, err := tx.Exec(stmt, taskId, actorId, field, nullString(oldValue), nullString(newValue))
+	return err
+}
+
+func formatDue(date *time.Time, dueTime *string) string {
+	if date == nil {
+		return ""
+	}
+
+	due := date.Format("2006-01-02")
+	if dueTime != nil && len(*dueTime) >= 5 {
+		due += " " + (*dueTime)[:5]
+	}
+
+	return due
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+func nullInt(value int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(value), Valid: value != 0}
 }
@@ -2,6 +2,7 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/andres085/task_manager/internal/assert"
 )
@@ -24,10 +25,31 @@ func TestInsertMethod(t *testing.T) {
 
 	m := TaskModel{db}
 
-	id, err := m.Insert("Test Task", "Test Task Body", "HIGH", 1, 1)
+	id, err := m.Insert("Test Task", "Test Task Body", "HIGH", "", 1, []int{1}, nil, 0, "", "")
 
 	assert.Equal(t, id, 4)
 	assert.NilError(t, err)
+
+	task, err := m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, task.Status, "To Do")
+	assert.Equal(t, task.Finished == nil, true)
+
+	id, err = m.Insert("Done Task", "Done Task Body", "HIGH", "Completed", 1, []int{1}, nil, 0, "", "")
+	assert.NilError(t, err)
+
+	task, err = m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, task.Finished != nil, true)
+
+	_, err = m.Insert("Blocked Task", "Blocked Task Body", "HIGH", "Blocked", 1, []int{1}, nil, 0, "", "")
+	assert.Equal(t, err, ErrInvalidStatus)
+
+	_, err = db.Exec(`DELETE FROM workspace_statuses WHERE workspace_id = 1`)
+	assert.NilError(t, err)
+
+	_, err = m.Insert("Statusless Task", "Statusless Task Body", "HIGH", "", 1, []int{1}, nil, 0, "", "")
+	assert.Equal(t, err, ErrInvalidStatus)
 }
 
 func TestGetAllMethod(t *testing.T) {
@@ -36,10 +58,95 @@ func TestGetAllMethod(t *testing.T) {
 	m := TaskModel{db}
 	id := 1
 
-	tasks, err := m.GetAll(id, 10, 0, "", "", "", "")
+	tasks, err := m.GetAll(id, 10, 0, TaskFilter{})
+
+	assert.Equal(t, len(tasks), 3)
+	assert.NilError(t, err)
+}
+
+func TestGetAllDueFilter(t *testing.T) {
+	db := newTestDB(t)
+
+	m := TaskModel{db}
+
+	_, err := m.Insert("Overdue Task", "Overdue Task Body", "HIGH", "", 1, []int{1}, nil, 0, "2020-01-01", "10:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := m.GetAll(1, 10, 0, TaskFilter{Due: "overdue"})
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(tasks), 1)
+	assert.Equal(t, tasks[0].Title, "Overdue Task")
+	assert.Equal(t, tasks[0].IsOverdue(), true)
+
+	total, err := m.GetTotalTasks(1, TaskFilter{Due: "overdue"})
+
+	assert.NilError(t, err)
+	assert.Equal(t, total, 1)
+
+	tasks, err = m.GetAll(1, 10, 0, TaskFilter{SortBy: "due", Sort: "asc"})
+
+	assert.NilError(t, err)
+	assert.Equal(t, tasks[0].Title, "Overdue Task")
+}
+
+func TestGetAssignedMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := TaskModel{db}
+	w := WorkspaceModel{db}
+
+	tasks, err := m.GetAssigned(1, 10, 0, TaskFilter{})
 
+	assert.NilError(t, err)
 	assert.Equal(t, len(tasks), 3)
+	assert.Equal(t, tasks[0].WorkspaceTitle, "First Workspace")
+
+	workspaceId, err := w.Insert("Second Workspace", "Second Workspace Description", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.Insert("Other Task", "Other Task Body", "LOW", "", workspaceId, []int{2}, nil, 0, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.Insert("Shared Task", "Shared Task Body", "HIGH", "", 1, []int{1, 2}, nil, 0, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err = m.GetAssigned(2, 10, 0, TaskFilter{})
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(tasks), 2)
+	assert.Equal(t, tasks[0].Title, "Shared Task")
+	assert.Equal(t, tasks[0].WorkspaceTitle, "First Workspace")
+	assert.Equal(t, tasks[1].WorkspaceTitle, "Second Workspace")
+
+	total, err := m.GetTotalAssigned(2, TaskFilter{Priority: "HIGH"})
+
+	assert.NilError(t, err)
+	assert.Equal(t, total, 1)
+
+	tasks, err = m.GetAssigned(2, 1, 1, TaskFilter{})
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(tasks), 1)
+	assert.Equal(t, tasks[0].Title, "Other Task")
+
+	err = w.SetArchived(workspaceId, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total, err = m.GetTotalAssigned(2, TaskFilter{})
+
 	assert.NilError(t, err)
+	assert.Equal(t, total, 1)
 }
 
 func TestUpdateMethod(t *testing.T) {
@@ -48,20 +155,21 @@ func TestUpdateMethod(t *testing.T) {
 	m := TaskModel{db}
 
 	newTitle := "Updated Title"
-	err := m.Update(1, newTitle, "Test Task Body", "HIGH", 1, "Completed")
+	err := m.Update(1, 2, newTitle, "Test Task Body", "HIGH", []int{1}, nil, "Completed", "2024-03-17", "")
 
 	assert.NilError(t, err)
 
 	updatedTask, err := m.Get(1)
 
 	assert.Equal(t, updatedTask.Title, newTitle)
+	assert.Equal(t, updatedTask.DueDate.Format("2006-01-02"), "2024-03-17")
 
 	d := updatedTask.Finished
 	if d == nil {
 		t.Errorf("got: nil; expected: %v", d)
 	}
 
-	m.Update(1, newTitle, "Test Task Body", "HIGH", 1, "To Do")
+	m.Update(1, 2, newTitle, "Test Task Body", "HIGH", []int{1}, nil, "To Do", "", "")
 
 	updatedTask, err = m.Get(1)
 	d = updatedTask.Finished
@@ -70,6 +178,148 @@ func TestUpdateMethod(t *testing.T) {
 	}
 }
 
+func TestUpdateStatusMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := TaskModel{db}
+
+	err := m.UpdateStatus(1, 2, "Completed")
+	assert.NilError(t, err)
+
+	updatedTask, err := m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, updatedTask.Status, "Completed")
+
+	if updatedTask.Finished == nil {
+		t.Errorf("got: nil; expected: %v", updatedTask.Finished)
+	}
+
+	events, err := m.GetHistory(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].NewValue, "Completed")
+
+	err = m.UpdateStatus(99, 2, "Completed")
+	assert.Equal(t, err, ErrNoRecord)
+}
+
+func TestSubtasks(t *testing.T) {
+	db := newTestDB(t)
+
+	m := TaskModel{db}
+	workspaces := WorkspaceModel{db}
+
+	parentId, err := m.Insert("Parent Task", "Parent Task Body", "LOW", "", 1, []int{1}, nil, 0, "", "")
+	assert.NilError(t, err)
+
+	childId, err := m.Insert("Child Task", "Child Task Body", "LOW", "", 1, []int{1}, nil, parentId, "", "")
+	assert.NilError(t, err)
+
+	_, err = m.Insert("Grandchild Task", "Grandchild Task Body", "LOW", "", 1, []int{1}, nil, childId, "", "")
+	assert.Equal(t, err, ErrInvalidParent)
+
+	_, err = m.Insert("Orphan Task", "Orphan Task Body", "LOW", "", 1, []int{1}, nil, 99, "", "")
+	assert.Equal(t, err, ErrInvalidParent)
+
+	subtasks, err := m.GetSubtasks(parentId)
+	assert.NilError(t, err)
+	assert.Equal(t, len(subtasks), 1)
+	assert.Equal(t, *subtasks[0].ParentId, parentId)
+
+	workspace, err := workspaces.Get(1)
+	assert.NilError(t, err)
+
+	err = workspaces.Update(1, workspace.Title, workspace.Description, true)
+	assert.NilError(t, err)
+
+	err = m.UpdateStatus(parentId, 1, "Completed")
+	assert.Equal(t, err, ErrOpenSubtasks)
+
+	err = m.UpdateStatus(childId, 1, "Completed")
+	assert.NilError(t, err)
+
+	err = m.UpdateStatus(parentId, 1, "Completed")
+	assert.NilError(t, err)
+}
+
+func TestGetHistoryMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := TaskModel{db}
+
+	err := m.Update(1, 2, "First Task", "This is the content of the first task", "LOW", []int{2}, nil, "Completed", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := m.GetHistory(1)
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 2)
+
+	fields := map[string]TaskEvent{}
+	for _, e := range events {
+		fields[e.Field] = e
+	}
+
+	assert.Equal(t, fields["status"].OldValue, "To Do")
+	assert.Equal(t, fields["status"].NewValue, "Completed")
+	assert.Equal(t, fields["status"].FirstName, "Member")
+	assert.Equal(t, fields["assignees"].OldValue, "Test McTester")
+	assert.Equal(t, fields["assignees"].NewValue, "Member Memberino")
+}
+
+func TestAssigneesAndWatchers(t *testing.T) {
+	db := newTestDB(t)
+
+	m := TaskModel{db}
+
+	_, err := m.Insert("Nobody's Task", "Nobody's Task Body", "LOW", "", 1, nil, nil, 0, "", "")
+	assert.Equal(t, err, ErrInvalidAssignee)
+
+	_, err = m.Insert("Stranger's Task", "Stranger's Task Body", "LOW", "", 1, []int{99}, nil, 0, "", "")
+	assert.Equal(t, err, ErrInvalidAssignee)
+
+	id, err := m.Insert("Shared Task", "Shared Task Body", "LOW", "", 1, []int{2, 1, 2}, nil, 0, "", "")
+	assert.NilError(t, err)
+
+	task, err := m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, task.UserId, 2)
+	assert.Equal(t, len(task.Assignees), 2)
+
+	tasks, err := m.GetAll(1, 10, 0, TaskFilter{AssignedTo: 2})
+	assert.NilError(t, err)
+	assert.Equal(t, len(tasks), 1)
+	assert.Equal(t, tasks[0].ID, id)
+
+	err = m.Update(id, 1, "Shared Task", "Shared Task Body", "LOW", []int{1}, nil, "To Do", "", "")
+	assert.NilError(t, err)
+
+	total, err := m.GetTotalTasks(1, TaskFilter{AssignedTo: 2})
+	assert.NilError(t, err)
+	assert.Equal(t, total, 0)
+
+	assert.NilError(t, m.Watch(id, 2))
+	assert.NilError(t, m.Watch(id, 2))
+	assert.Equal(t, m.Watch(id, 99), ErrNoRecord)
+
+	task, err = m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, len(task.Watchers), 1)
+	assert.Equal(t, task.Watchers[0].ID, 2)
+
+	total, err = m.GetTotalTasks(1, TaskFilter{WatchedBy: 2})
+	assert.NilError(t, err)
+	assert.Equal(t, total, 1)
+
+	assert.NilError(t, m.Unwatch(id, 2))
+
+	task, err = m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, len(task.Watchers), 0)
+}
+
 func TestDeleteMethod(t *testing.T) {
 	db := newTestDB(t)
 
@@ -81,24 +331,127 @@ func TestDeleteMethod(t *testing.T) {
 	assert.NilError(t, err)
 }
 
-func TestValidateTaskOwnership(t *testing.T) {
+func TestArchive(t *testing.T) {
 	db := newTestDB(t)
 
 	m := TaskModel{db}
 
-	isOwner, err := m.ValidateOwnership(1, 1)
+	id, err := m.Insert("Open Task", "Open Task Body", "LOW", "", 1, []int{1}, nil, 0, "", "")
+	assert.NilError(t, err)
+
+	err = m.SetArchived(id, true)
+	assert.Equal(t, err, ErrTaskNotFinished)
+
+	err = m.SetArchived(99, true)
+	assert.Equal(t, err, ErrNoRecord)
 
-	assert.Equal(t, isOwner, true)
+	err = m.SetArchived(1, true)
 	assert.NilError(t, err)
+
+	total, err := m.GetTotalTasks(1, TaskFilter{})
+	assert.NilError(t, err)
+	assert.Equal(t, total, 3)
+
+	tasks, err := m.GetAll(1, 10, 0, TaskFilter{IncludeArchived: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(tasks), 4)
+
+	archived, err := m.ArchiveFinished(1)
+	assert.NilError(t, err)
+	assert.Equal(t, archived, 2)
+
+	tasks, err = m.GetAll(1, 10, 0, TaskFilter{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(tasks), 1)
+	assert.Equal(t, tasks[0].ID, id)
+
+	err = m.UpdateStatus(1, 1, "In Progress")
+	assert.NilError(t, err)
+
+	task, err := m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, task.Archived, false)
 }
 
-func TestValidateTaskAdmin(t *testing.T) {
+func TestTrash(t *testing.T) {
 	db := newTestDB(t)
 
 	m := TaskModel{db}
 
-	isAdmin, err := m.ValidateAdmin(1, 1)
+	parentId, err := m.Insert("Parent Task", "Parent Task Body", "LOW", "", 1, []int{1}, nil, 0, "", "")
+	assert.NilError(t, err)
+
+	childId, err := m.Insert("Child Task", "Child Task Body", "LOW", "", 1, []int{1}, nil, parentId, "", "")
+	assert.NilError(t, err)
 
-	assert.Equal(t, isAdmin, true)
+	row, err := m.Delete(parentId)
 	assert.NilError(t, err)
+	assert.Equal(t, row, 2)
+
+	_, err = m.Get(childId)
+	assert.Equal(t, err, ErrNoRecord)
+
+	_, err = m.GetMembership(1, parentId)
+	assert.Equal(t, err, ErrNoRecord)
+
+	total, err := m.GetTotalTasks(1, TaskFilter{})
+	assert.NilError(t, err)
+	assert.Equal(t, total, 3)
+
+	trash, err := m.GetTrash(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(trash), 1)
+	assert.Equal(t, trash[0].ID, parentId)
+
+	err = m.Restore(childId, 1)
+	assert.Equal(t, err, ErrInvalidParent)
+
+	err = m.Restore(parentId, 2)
+	assert.Equal(t, err, ErrNoRecord)
+
+	err = m.Restore(parentId, 1)
+	assert.NilError(t, err)
+
+	subtasks, err := m.GetSubtasks(parentId)
+	assert.NilError(t, err)
+	assert.Equal(t, len(subtasks), 1)
+
+	_, err = m.Delete(1)
+	assert.NilError(t, err)
+
+	err = m.Purge(2, 1)
+	assert.Equal(t, err, ErrNoRecord)
+
+	err = m.Purge(1, 1)
+	assert.NilError(t, err)
+
+	_, err = m.Delete(2)
+	assert.NilError(t, err)
+
+	purged, err := m.PurgeDeleted(time.Now().Add(-time.Hour))
+	assert.NilError(t, err)
+	assert.Equal(t, purged, 0)
+
+	purged, err = m.PurgeDeleted(time.Now().Add(time.Hour))
+	assert.NilError(t, err)
+	assert.Equal(t, purged, 1)
+
+	trash, err = m.GetTrash(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(trash), 0)
+}
+
+func TestTaskGetMembershipMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := TaskModel{db}
+
+	membership, err := m.GetMembership(1, 1)
+
+	assert.Equal(t, membership.Role, RoleOwner)
+	assert.NilError(t, err)
+
+	_, err = m.GetMembership(1, 99)
+
+	assert.Equal(t, err, ErrNoRecord)
 }
@@ -4,32 +4,39 @@ CREATE TABLE users (
 	lastName VARCHAR(255) NOT NULL,
 	email VARCHAR(255) NOT NULL,
 	hashed_password CHAR(60) NOT NULL,
-	created DATETIME NOT NULL
+	created DATETIME NOT NULL,
+	verified BOOLEAN NOT NULL DEFAULT FALSE,
+	pending_email VARCHAR(255) DEFAULT NULL
 );
 
 ALTER TABLE users ADD CONSTRAINT users_uc_email UNIQUE (email);
 
-INSERT INTO users (firstName, lastName, email, hashed_password, created) VALUES (
+INSERT INTO users (firstName, lastName, email, hashed_password, created, verified) VALUES (
     'Test',
     'McTester',
     'test@example.com',
     '$2a$12$NuTjWXm3KKntReFwyBVHyuf/to.HEwTy.eS206TNfkGfr6HzGJSWG',
-    '2022-01-01 09:18:24'
+    '2022-01-01 09:18:24',
+    TRUE
 );
 
-INSERT INTO users (firstName, lastName, email, hashed_password, created) VALUES (
+INSERT INTO users (firstName, lastName, email, hashed_password, created, verified) VALUES (
     'Member',
     'Memberino',
     'member@example.com',
     '$2a$12$NuTjWXm3KKntReFwyBVHyuf/to.HEwTy.eS206TNfkGfr6HzGJSWG',
-    '2022-01-01 09:18:24'
+    '2022-01-01 09:18:24',
+    TRUE
 );
 
 CREATE TABLE workspaces (
     id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
     title VARCHAR(100) NOT NULL UNIQUE,
     description TEXT(255) NOT NULL,
-    created DATETIME NOT NULL
+    created DATETIME NOT NULL,
+    block_open_subtasks BOOLEAN NOT NULL DEFAULT FALSE,
+    deleted_at DATETIME DEFAULT NULL,
+    archived BOOLEAN NOT NULL DEFAULT FALSE
 );
 
 CREATE INDEX idx_workspace_id ON workspaces(id);
@@ -64,14 +71,21 @@ CREATE TABLE tasks (
     workspace_id INTEGER NOT NULL,
     user_id INTEGER NOT NULL,
     status VARCHAR(50) NOT NULL DEFAULT 'To Do',
+    due_date DATE DEFAULT NULL,
+    due_time TIME DEFAULT NULL,
+    parent_id INTEGER DEFAULT NULL,
+    deleted_at DATETIME DEFAULT NULL,
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
     FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
-    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (parent_id) REFERENCES tasks(id) ON DELETE CASCADE
 );
 
-INSERT INTO users_workspaces(user_id, workspace_id, role, created) VALUES (1, 1, "ADMIN", UTC_TIMESTAMP());
-INSERT INTO users_workspaces(user_id, workspace_id, role, created) VALUES (2, 1, "MEMBER", UTC_TIMESTAMP());
+INSERT INTO users_workspaces(user_id, workspace_id, role, created) VALUES (1, 1, "OWNER", UTC_TIMESTAMP());
+INSERT INTO users_workspaces(user_id, workspace_id, role, created) VALUES (2, 1, "EDITOR", UTC_TIMESTAMP());
 
 CREATE INDEX idx_tasks_created ON tasks(created);
+CREATE INDEX idx_tasks_due_date ON tasks(due_date);
 
 INSERT INTO tasks (title, content, priority, created, finished, workspace_id, user_id) VALUES (
     'First Task',
@@ -102,3 +116,199 @@ INSERT INTO tasks (title, content, priority, created, finished, workspace_id, us
     1,
     1
 );
+
+CREATE TABLE comments (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    parent_id INTEGER DEFAULT NULL,
+    content TEXT NOT NULL,
+    created DATETIME NOT NULL,
+    updated DATETIME DEFAULT NULL,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (parent_id) REFERENCES comments(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_comments_task_id ON comments(task_id);
+
+INSERT INTO comments (task_id, user_id, parent_id, content, created) VALUES (1, 1, NULL, 'First comment', UTC_TIMESTAMP());
+INSERT INTO comments (task_id, user_id, parent_id, content, created) VALUES (1, 2, 1, 'First reply', UTC_TIMESTAMP());
+
+CREATE TABLE task_events (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    field VARCHAR(50) NOT NULL,
+    old_value TEXT DEFAULT NULL,
+    new_value TEXT DEFAULT NULL,
+    created DATETIME NOT NULL,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_task_events_task_id ON task_events(task_id);
+
+CREATE TABLE api_tokens (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created DATETIME NOT NULL,
+    last_used DATETIME DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_api_tokens_user_id ON api_tokens(user_id);
+
+INSERT INTO api_tokens (user_id, name, token_hash, created) VALUES (1, 'CI', '2fd67e69907bff621c0c4078b1aef595c8562a11f840dd0ec5c5d7c0a4e59331', UTC_TIMESTAMP());
+
+CREATE TABLE workspace_statuses (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    workspace_id INTEGER NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    position INTEGER NOT NULL,
+    is_done BOOLEAN NOT NULL DEFAULT FALSE,
+    created DATETIME NOT NULL,
+    UNIQUE (workspace_id, name),
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE
+);
+
+CREATE TABLE status_transitions (
+    from_status_id INTEGER NOT NULL,
+    to_status_id INTEGER NOT NULL,
+    PRIMARY KEY (from_status_id, to_status_id),
+    FOREIGN KEY (from_status_id) REFERENCES workspace_statuses(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_status_id) REFERENCES workspace_statuses(id) ON DELETE CASCADE
+);
+
+INSERT INTO workspace_statuses (workspace_id, name, position, is_done, created) VALUES (1, 'To Do', 1, FALSE, UTC_TIMESTAMP());
+INSERT INTO workspace_statuses (workspace_id, name, position, is_done, created) VALUES (1, 'In Progress', 2, FALSE, UTC_TIMESTAMP());
+INSERT INTO workspace_statuses (workspace_id, name, position, is_done, created) VALUES (1, 'Completed', 3, TRUE, UTC_TIMESTAMP());
+
+CREATE TABLE labels (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    workspace_id INTEGER NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(20) NOT NULL,
+    created DATETIME NOT NULL,
+    UNIQUE (workspace_id, name),
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE
+);
+
+CREATE TABLE task_labels (
+    task_id INTEGER NOT NULL,
+    label_id INTEGER NOT NULL,
+    PRIMARY KEY (task_id, label_id),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (label_id) REFERENCES labels(id) ON DELETE CASCADE
+);
+
+INSERT INTO labels (workspace_id, name, color, created) VALUES (1, 'Bug', 'red', UTC_TIMESTAMP());
+INSERT INTO labels (workspace_id, name, color, created) VALUES (1, 'Feature', 'green', UTC_TIMESTAMP());
+INSERT INTO task_labels (task_id, label_id) VALUES (1, 1);
+INSERT INTO task_labels (task_id, label_id) VALUES (1, 2);
+INSERT INTO task_labels (task_id, label_id) VALUES (2, 1);
+
+CREATE TABLE checklist_items (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    task_id INTEGER NOT NULL,
+    content VARCHAR(255) NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    created DATETIME NOT NULL,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+INSERT INTO checklist_items (task_id, content, done, created) VALUES (1, 'Write the docs', TRUE, UTC_TIMESTAMP());
+INSERT INTO checklist_items (task_id, content, done, created) VALUES (1, 'Ask for a review', FALSE, UTC_TIMESTAMP());
+
+CREATE TABLE task_dependencies (
+    task_id INTEGER NOT NULL,
+    blocked_by_id INTEGER NOT NULL,
+    created DATETIME NOT NULL,
+    PRIMARY KEY (task_id, blocked_by_id),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (blocked_by_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE invitations (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    workspace_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    invited_by INTEGER NOT NULL,
+    created DATETIME NOT NULL,
+    expires DATETIME NOT NULL,
+    UNIQUE (workspace_id, user_id),
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE invite_links (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    workspace_id INTEGER NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    role VARCHAR(20) NOT NULL,
+    created_by INTEGER NOT NULL,
+    created DATETIME NOT NULL,
+    expires DATETIME NOT NULL,
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE user_limits (
+    user_id INTEGER NOT NULL PRIMARY KEY,
+    max_owned_workspaces INTEGER DEFAULT NULL,
+    max_member_workspaces INTEGER DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE task_users (
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    relation VARCHAR(20) NOT NULL,
+    PRIMARY KEY (task_id, user_id, relation),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_task_users_user_id (user_id)
+);
+
+INSERT INTO task_users (task_id, user_id, relation) SELECT id, user_id, 'ASSIGNEE' FROM tasks;
+
+CREATE TABLE user_tokens (
+    token_hash CHAR(64) NOT NULL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    scope VARCHAR(20) NOT NULL,
+    created DATETIME NOT NULL,
+    expires DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user_tokens_user_id (user_id)
+);
+
+CREATE TABLE two_factor (
+    user_id INTEGER NOT NULL PRIMARY KEY,
+    secret VARCHAR(64) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    last_step BIGINT NOT NULL DEFAULT 0,
+    created DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE recovery_codes (
+    code_hash CHAR(64) NOT NULL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_recovery_codes_user_id (user_id)
+);
+
+CREATE TABLE auth_events (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    event VARCHAR(30) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL,
+    created DATETIME NOT NULL,
+    INDEX idx_auth_events_email (email, event),
+    INDEX idx_auth_events_ip (ip, event)
+);
@@ -1,3 +1,20 @@
+drop table auth_events;
+drop table recovery_codes;
+drop table two_factor;
+drop table user_tokens;
+drop table task_users;
+drop table user_limits;
+drop table invite_links;
+drop table invitations;
+drop table task_dependencies;
+drop table checklist_items;
+drop table task_labels;
+drop table labels;
+drop table status_transitions;
+drop table workspace_statuses;
+drop table api_tokens;
+drop table task_events;
+drop table comments;
 drop table tasks;
 drop table users_workspaces;
 drop table workspaces;
@@ -0,0 +1,255 @@
+package models
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/andres085/task_manager/internal/totp"
+)
+
+const RecoveryCodeCount = 10
+
+type TwoFactor struct {
+	UserID        int
+	Secret        string
+	Enabled       bool
+	RecoveryCodes int
+}
+
+type TwoFactorModelInterface interface {
+	Get(userId int) (*TwoFactor, error)
+	Enabled(userId int) (bool, error)
+	Setup(userId int) (string, error)
+	Enable(userId int, code string) ([]string, error)
+	Disable(userId int) error
+	Authenticate(userId int, code string) error
+}
+
+type TwoFactorModel struct {
+	DB *sql.DB
+}
+
+func (m *TwoFactorModel) Get(userId int) (*TwoFactor, error) {
+	stmt := `SELECT t.user_id, t.secret, t.enabled, (SELECT COUNT(*) FROM recovery_codes rc WHERE rc.user_id = t.user_id)
+	FROM two_factor t WHERE t.user_id = ?`
+
+	var tf TwoFactor
+
+	err := m.DB.QueryRow(stmt, userId).Scan(&tf.UserID, &tf.Secret, &tf.Enabled, &tf.RecoveryCodes)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return &tf, nil
+}
+
+func (m *TwoFactorModel) Enabled(userId int) (bool, error) {
+	var enabled bool
+
+	err := m.DB.QueryRow(`SELECT EXISTS (SELECT true FROM two_factor WHERE user_id = ? AND enabled = true)`, userId).Scan(&enabled)
+
+	return enabled, err
+}
+
+// Setup stores a new secret that only takes effect once Enable sees a code generated from it,
+// replacing any earlier secret that was never confirmed.
+func (m *TwoFactorModel) Setup(userId int) (string, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return "", err
+	}
+
+	defer tx.Rollback()
+
+	var enabled bool
+
+	err = tx.QueryRow(`SELECT EXISTS (SELECT true FROM two_factor WHERE user_id = ? AND enabled = true)`, userId).Scan(&enabled)
+	if err != nil {
+		return "", err
+	}
+
+	if enabled {
+		return "", ErrTwoFactorEnabled
+	}
+
+	_, err = tx.Exec(`DELETE FROM two_factor WHERE user_id = ?`, userId)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(`INSERT INTO two_factor (user_id, secret, created) VALUES (?, ?, UTC_TIMESTAMP())`, userId, secret)
+	if err != nil {
+		return "", err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+// Enable turns on the pending secret when code matches it and returns a fresh set of recovery
+// codes. Only their hashes are stored, so this is the only time they can be shown.
+func (m *TwoFactorModel) Enable(userId int, code string) ([]string, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	var secret string
+
+	err = tx.QueryRow(`SELECT secret FROM two_factor WHERE user_id = ? AND enabled = false FOR UPDATE`, userId).Scan(&secret)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	_, err = tx.Exec(`UPDATE two_factor SET enabled = true, last_step = ? WHERE user_id = ?`, step, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`DELETE FROM recovery_codes WHERE user_id = ?`, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, RecoveryCodeCount)
+
+	for i := range codes {
+		codes[i], err = generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(`INSERT INTO recovery_codes (code_hash, user_id) VALUES (?, ?)`, hashToken(normalizeRecoveryCode(codes[i])), userId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (m *TwoFactorModel) Disable(userId int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM recovery_codes WHERE user_id = ?`, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM two_factor WHERE user_id = ?`, userId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Authenticate accepts either a TOTP code or one of the user's recovery codes. A TOTP code is
+// refused if it isn't newer than the last one used, and a recovery code is deleted once spent.
+func (m *TwoFactorModel) Authenticate(userId int, code string) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var secret string
+	var lastStep int64
+
+	stmt := `SELECT secret, last_step FROM two_factor WHERE user_id = ? AND enabled = true FOR UPDATE`
+
+	err = tx.QueryRow(stmt, userId).Scan(&secret, &lastStep)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidCode
+		}
+		return err
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if ok {
+		if step <= lastStep {
+			return ErrInvalidCode
+		}
+
+		_, err = tx.Exec(`UPDATE two_factor SET last_step = ? WHERE user_id = ?`, step, userId)
+		if err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	}
+
+	result, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = ? AND code_hash = ?`, userId, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrInvalidCode
+	}
+
+	return tx.Commit()
+}
+
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 6)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ReplaceAll(code, "-", "")
+	code = strings.ReplaceAll(code, " ", "")
+
+	return strings.ToLower(code)
+}
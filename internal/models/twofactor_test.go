@@ -0,0 +1,100 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andres085/task_manager/internal/assert"
+	"github.com/andres085/task_manager/internal/totp"
+)
+
+func TestTwoFactorEnableMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := TwoFactorModel{db}
+
+	_, err := m.Get(1)
+	assert.Equal(t, err, ErrNoRecord)
+
+	secret, err := m.Setup(1)
+	assert.NilError(t, err)
+
+	enabled, err := m.Enabled(1)
+	assert.NilError(t, err)
+	assert.Equal(t, enabled, false)
+
+	_, err = m.Enable(1, "000000")
+	assert.Equal(t, err, ErrInvalidCode)
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes, err := m.Enable(1, code)
+	assert.NilError(t, err)
+	assert.Equal(t, len(codes), RecoveryCodeCount)
+
+	tf, err := m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, tf.Enabled, true)
+	assert.Equal(t, tf.RecoveryCodes, RecoveryCodeCount)
+
+	_, err = m.Setup(1)
+	assert.Equal(t, err, ErrTwoFactorEnabled)
+
+	err = m.Disable(1)
+	assert.NilError(t, err)
+
+	enabled, err = m.Enabled(1)
+	assert.NilError(t, err)
+	assert.Equal(t, enabled, false)
+}
+
+func TestTwoFactorAuthenticateMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := TwoFactorModel{db}
+
+	secret, err := m.Setup(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step := totp.Step(time.Now())
+
+	code, err := totp.Code(secret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes, err := m.Enable(1, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Authenticate(1, code)
+	assert.Equal(t, err, ErrInvalidCode)
+
+	next, err := totp.Code(secret, step+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Authenticate(1, next)
+	assert.NilError(t, err)
+
+	err = m.Authenticate(2, next)
+	assert.Equal(t, err, ErrInvalidCode)
+
+	err = m.Authenticate(1, strings.ToUpper(codes[0]))
+	assert.NilError(t, err)
+
+	err = m.Authenticate(1, codes[0])
+	assert.Equal(t, err, ErrInvalidCode)
+
+	tf, err := m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, tf.RecoveryCodes, RecoveryCodeCount-1)
+}
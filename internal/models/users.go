@@ -11,57 +11,75 @@ import (
 )
 
 type User struct {
-	ID             int
-	FirstName      string
-	LastName       string
-	Email          string
-	HashedPassword string
-	Created        time.Time
+	ID             int       `json:"id"`
+	FirstName      string    `json:"first_name"`
+	LastName       string    `json:"last_name"`
+	Email          string    `json:"email"`
+	HashedPassword string    `json:"-"`
+	Created        time.Time `json:"created"`
+	Verified       bool      `json:"verified"`
+	PendingEmail   *string   `json:"pending_email,omitempty"`
 }
 
 type UserModelInterface interface {
-	Insert(firstName, lastName, email, password string) error
+	Insert(firstName, lastName, email, password string) (int, error)
 	Authenticate(email, password string) (int, error)
 	Exists(id int) (bool, error)
 	GetUser(userId int) (*User, error)
+	GetByEmail(email string) (*User, error)
+	ResetPassword(token, password string) (int, error)
+	Verify(token string) error
+	UpdateProfile(userId int, firstName, lastName string) error
+	ChangePassword(userId int, currentPassword, newPassword string) error
+	RequestEmailChange(userId int, password, email string) error
+	ConfirmEmailChange(token string) error
+	CheckPassword(userId int, password string) error
 	GetUserToInvite(email string, workspaceId int) (*User, error)
-	AddUserToWorkspace(userId, workspaceId int) error
 	GetWorkspaceUsers(workspaceId int) ([]UserWithRole, error)
 	GetWorkspacesAsMemberCount(email string) (int, error)
-	RemoveUserFromWorkspace(workspaceId, userId int) (int, error)
+	RemoveUserFromWorkspace(workspaceId, userId, actorId int) (int, error)
+	UpdateWorkspaceRole(workspaceId, userId int, role string) error
+	TransferOwnership(workspaceId, ownerId, newOwnerId int) error
+	LeaveWorkspace(workspaceId, userId int) (int, error)
 }
 
 type UserModel struct {
 	DB *sql.DB
 }
 
-func (m *UserModel) Insert(firstName, lastName, email, password string) error {
+func (m *UserModel) Insert(firstName, lastName, email, password string) (int, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	stmt := `INSERT INTO users (firstName, lastName, email, hashed_password, created) VALUES (?, ?, ?, ?, UTC_TIMESTAMP())`
 
-	_, err = m.DB.Exec(stmt, firstName, lastName, email, string(hashedPassword))
+	result, err := m.DB.Exec(stmt, firstName, lastName, email, string(hashedPassword))
 	if err != nil {
 		var mySQLError *mysql.MySQLError
 		if errors.As(err, &mySQLError) {
 			if mySQLError.Number == 1062 && strings.Contains(mySQLError.Message, "users_uc_email") {
-				return ErrDuplicateEmail
+				return 0, ErrDuplicateEmail
 			}
 		}
-		return err
+		return 0, err
 	}
-	return nil
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
 }
 
 func (m *UserModel) GetUser(userId int) (*User, error) {
-	stmt := "SELECT firstName, lastName FROM users where id = ?"
+	stmt := "SELECT id, firstName, lastName, email, created, verified, pending_email FROM users where id = ?"
 
 	var u User
 
-	err := m.DB.QueryRow(stmt, userId).Scan(&u.FirstName, &u.LastName)
+	err := m.DB.QueryRow(stmt, userId).Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email, &u.Created, &u.Verified, &u.PendingEmail)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return &User{}, ErrNoRecord
@@ -73,12 +91,209 @@ func (m *UserModel) GetUser(userId int) (*User, error) {
 	return &u, nil
 }
 
+func (m *UserModel) GetByEmail(email string) (*User, error) {
+	stmt := "SELECT id, firstName, lastName, email, created, verified FROM users WHERE email = ?"
+
+	var u User
+
+	err := m.DB.QueryRow(stmt, email).Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email, &u.Created, &u.Verified)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &User{}, ErrNoRecord
+		}
+		return &User{}, err
+	}
+
+	return &u, nil
+}
+
+// ResetPassword spends a password reset token, stores the new password for its user and returns
+// the user's id.
+func (m *UserModel) ResetPassword(token, password string) (int, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	userId, err := consumeToken(tx, token, ScopePasswordReset)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`UPDATE users SET hashed_password = ? WHERE id = ?`, string(hashedPassword), userId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return userId, nil
+}
+
+// Verify spends an email verification token and marks its user as verified.
+func (m *UserModel) Verify(token string) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	userId, err := consumeToken(tx, token, ScopeVerification)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE users SET verified = true WHERE id = ?`, userId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *UserModel) UpdateProfile(userId int, firstName, lastName string) error {
+	_, err := m.DB.Exec(`UPDATE users SET firstName = ?, lastName = ? WHERE id = ?`, firstName, lastName, userId)
+	return err
+}
+
+// ChangePassword also drops any reset links that were sent for the old password.
+func (m *UserModel) ChangePassword(userId int, currentPassword, newPassword string) error {
+	err := m.CheckPassword(userId, currentPassword)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), 12)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE users SET hashed_password = ? WHERE id = ?`, string(hashedPassword), userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM user_tokens WHERE user_id = ? AND scope = ?`, userId, ScopePasswordReset)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RequestEmailChange parks the new address until it is confirmed through ConfirmEmailChange,
+// so a typo can't lock anyone out of their account.
+func (m *UserModel) RequestEmailChange(userId int, password, email string) error {
+	err := m.CheckPassword(userId, password)
+	if err != nil {
+		return err
+	}
+
+	var taken bool
+
+	err = m.DB.QueryRow(`SELECT EXISTS (SELECT true FROM users WHERE email = ?)`, email).Scan(&taken)
+	if err != nil {
+		return err
+	}
+
+	if taken {
+		return ErrDuplicateEmail
+	}
+
+	_, err = m.DB.Exec(`UPDATE users SET pending_email = ? WHERE id = ?`, email, userId)
+	return err
+}
+
+// ConfirmEmailChange spends an email change token and moves the pending address into place.
+func (m *UserModel) ConfirmEmailChange(token string) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	userId, err := consumeToken(tx, token, ScopeEmailChange)
+	if err != nil {
+		return err
+	}
+
+	var email sql.NullString
+
+	err = tx.QueryRow(`SELECT pending_email FROM users WHERE id = ?`, userId).Scan(&email)
+	if err != nil {
+		return err
+	}
+
+	if !email.Valid {
+		return ErrInvalidToken
+	}
+
+	var taken bool
+
+	err = tx.QueryRow(`SELECT EXISTS (SELECT true FROM users WHERE email = ? AND id <> ?)`, email.String, userId).Scan(&taken)
+	if err != nil {
+		return err
+	}
+
+	if taken {
+		return ErrDuplicateEmail
+	}
+
+	_, err = tx.Exec(`UPDATE users SET email = pending_email, pending_email = NULL, verified = true WHERE id = ?`, userId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *UserModel) CheckPassword(userId int, password string) error {
+	var hashedPassword []byte
+
+	err := m.DB.QueryRow(`SELECT hashed_password FROM users WHERE id = ?`, userId).Scan(&hashedPassword)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	err = bcrypt.CompareHashAndPassword(hashedPassword, []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrInvalidCredentials
+		}
+		return err
+	}
+
+	return nil
+}
+
 func (m *UserModel) GetUserToInvite(email string, workspaceId int) (*User, error) {
-	stmt := "SELECT u.* FROM users u LEFT JOIN users_workspaces uw ON u.id = uw.user_id AND uw.workspace_id = ? WHERE u.email = ? AND uw.workspace_id IS NULL"
+	stmt := "SELECT u.* FROM users u LEFT JOIN users_workspaces uw ON u.id = uw.user_id AND uw.workspace_id = ? WHERE u.email = ? AND u.verified = true AND uw.workspace_id IS NULL"
 
 	var u User
 
-	err := m.DB.QueryRow(stmt, workspaceId, email).Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email, &u.HashedPassword, &u.Created)
+	err := m.DB.QueryRow(stmt, workspaceId, email).Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email, &u.HashedPassword, &u.Created, &u.Verified, &u.PendingEmail)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return &User{}, ErrNoRecord
@@ -93,7 +308,7 @@ func (m *UserModel) GetUserToInvite(email string, workspaceId int) (*User, error
 func (m *UserModel) GetWorkspacesAsMemberCount(email string) (int, error) {
 	var totalWorkspaces int
 
-	countStmt := "SELECT COUNT(*) FROM users u LEFT JOIN users_workspaces uw ON u.id = uw.user_id WHERE u.email = ? AND uw.`role` = 'MEMBER';"
+	countStmt := "SELECT COUNT(*) FROM users u LEFT JOIN users_workspaces uw ON u.id = uw.user_id WHERE u.email = ? AND uw.`role` <> 'OWNER';"
 
 	err := m.DB.QueryRow(countStmt, email).Scan(&totalWorkspaces)
 	if err != nil {
@@ -104,11 +319,11 @@ func (m *UserModel) GetWorkspacesAsMemberCount(email string) (int, error) {
 }
 
 type UserWithRole struct {
-	ID        int
-	FirstName string
-	LastName  string
-	Email     string
-	Role      string
+	ID        int    `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
 }
 
 func (m *UserModel) GetWorkspaceUsers(workspaceId int) ([]UserWithRole, error) {
@@ -142,24 +357,14 @@ func (m *UserModel) GetWorkspaceUsers(workspaceId int) ([]UserWithRole, error) {
 
 }
 
-func (m *UserModel) AddUserToWorkspace(userId, workspaceId int) error {
-	stmt := `INSERT INTO users_workspaces (user_id, workspace_id, role, created) VALUES (?, ?, "MEMBER", UTC_TIMESTAMP())`
-
-	_, err := m.DB.Exec(stmt, userId, workspaceId)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func (m *UserModel) Authenticate(email, password string) (int, error) {
 	var id int
 	var hashedPassword []byte
+	var verified bool
 
-	stmt := "SELECT id, hashed_password FROM users WHERE email = ?"
+	stmt := "SELECT id, hashed_password, verified FROM users WHERE email = ?"
 
-	err := m.DB.QueryRow(stmt, email).Scan(&id, &hashedPassword)
+	err := m.DB.QueryRow(stmt, email).Scan(&id, &hashedPassword, &verified)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return 0, ErrInvalidCredentials
@@ -176,6 +381,11 @@ func (m *UserModel) Authenticate(email, password string) (int, error) {
 		}
 	}
 
+	// Only reveal that the address is unverified to someone who knows the password.
+	if !verified {
+		return 0, ErrUnverifiedEmail
+	}
+
 	return id, nil
 }
 
@@ -188,20 +398,250 @@ func (m *UserModel) Exists(id int) (bool, error) {
 	return exists, err
 }
 
-func (m *UserModel) RemoveUserFromWorkspace(workspaceId, userId int) (int, error) {
+// RemoveUserFromWorkspace takes a member out of a workspace with the same task cleanup as
+// LeaveWorkspace, recorded under actorId. It returns 0 when userId isn't a member or is the owner.
+func (m *UserModel) RemoveUserFromWorkspace(workspaceId, userId, actorId int) (int, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	role, err := memberRole(tx, workspaceId, userId)
+	if err != nil {
+		if errors.Is(err, ErrNoRecord) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	if role == RoleOwner {
+		return 0, nil
+	}
+
+	_, err = removeMember(tx, workspaceId, userId, actorId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
+
+// UpdateWorkspaceRole promotes or demotes a member. The owner role is left out on both
+// sides so a workspace always keeps exactly one owner; use TransferOwnership instead.
+func (m *UserModel) UpdateWorkspaceRole(workspaceId, userId int, role string) error {
+	if role == RoleOwner {
+		return ErrOwnerRole
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	current, err := memberRole(tx, workspaceId, userId)
+	if err != nil {
+		return err
+	}
+
+	if current == RoleOwner {
+		return ErrOwnerRole
+	}
+
+	_, err = tx.Exec("UPDATE users_workspaces SET `role` = ? WHERE workspace_id = ? AND user_id = ?", role, workspaceId, userId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// TransferOwnership hands the workspace to another member and keeps the previous owner
+// on as an admin. Both rows change in one transaction.
+func (m *UserModel) TransferOwnership(workspaceId, ownerId, newOwnerId int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	ownerRole, err := memberRole(tx, workspaceId, ownerId)
+	if err != nil {
+		return err
+	}
+
+	newOwnerRole, err := memberRole(tx, workspaceId, newOwnerId)
+	if err != nil {
+		return err
+	}
+
+	if ownerRole != RoleOwner || newOwnerRole == RoleOwner {
+		return ErrNoRecord
+	}
+
+	err = checkOwnedQuota(tx, newOwnerId)
+	if err != nil {
+		return err
+	}
+
+	stmt := "UPDATE users_workspaces SET `role` = ? WHERE workspace_id = ? AND user_id = ?"
+
+	_, err = tx.Exec(stmt, RoleOwner, workspaceId, newOwnerId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(stmt, RoleAdmin, workspaceId, ownerId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// LeaveWorkspace removes a member from a workspace along with their place on its tasks'
+// assignee and watcher lists, so nothing stays assigned to someone outside the workspace.
+// Tasks left without an assignee are handed to the owner. It returns how many tasks the
+// member was assigned to. The owner has to transfer ownership before leaving.
+func (m *UserModel) LeaveWorkspace(workspaceId, userId int) (int, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	role, err := memberRole(tx, workspaceId, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	if role == RoleOwner {
+		return 0, ErrOwnerRole
+	}
+
+	reassigned, err := removeMember(tx, workspaceId, userId, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return reassigned, nil
+}
+
+// removeMember drops userId from the workspace and from its tasks' assignee and watcher
+// lists. Tasks left without an assignee are handed to the owner and every change to the
+// assignees is recorded in the task history under actorId. It returns how many tasks the
+// member was assigned to.
+func removeMember(tx *sql.Tx, workspaceId, userId, actorId int) (int, error) {
+	var ownerId int
+
+	err := tx.QueryRow("SELECT user_id FROM users_workspaces WHERE workspace_id = ? AND `role` = ?", workspaceId, RoleOwner).Scan(&ownerId)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt := `SELECT t.id FROM tasks t JOIN task_users tu ON tu.task_id = t.id
+	WHERE t.workspace_id = ? AND tu.user_id = ? AND tu.relation = ?`
+
+	rows, err := tx.Query(stmt, workspaceId, userId, relationAssignee)
+	if err != nil {
+		return 0, err
+	}
+
+	var taskIds []int
+
+	for rows.Next() {
+		var id int
+
+		err = rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		taskIds = append(taskIds, id)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range taskIds {
+		oldAssignees, err := assigneeNames(tx, id)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec(`DELETE FROM task_users WHERE task_id = ? AND user_id = ? AND relation = ?`, id, userId, relationAssignee)
+		if err != nil {
+			return 0, err
+		}
+
+		var primaryId int
+
+		err = tx.QueryRow(`SELECT user_id FROM task_users WHERE task_id = ? AND relation = ? ORDER BY user_id LIMIT 1`, id, relationAssignee).Scan(&primaryId)
+		if errors.Is(err, sql.ErrNoRows) {
+			primaryId = ownerId
+			err = setAssignees(tx, id, []int{ownerId})
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec(`UPDATE tasks SET user_id = ? WHERE id = ?`, primaryId, id)
+		if err != nil {
+			return 0, err
+		}
 
-	stmt := "DELETE FROM users_workspaces WHERE workspace_id = ? AND user_id = ? AND `role` != 'ADMIN';"
+		newAssignees, err := assigneeNames(tx, id)
+		if err != nil {
+			return 0, err
+		}
 
-	result, err := m.DB.Exec(stmt, workspaceId, userId)
+		err = insertTaskEvent(tx, id, actorId, "assignees", oldAssignees, newAssignees)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.Exec(`DELETE tu FROM task_users tu JOIN tasks t ON t.id = tu.task_id WHERE t.workspace_id = ? AND tu.user_id = ?`, workspaceId, userId)
 	if err != nil {
 		return 0, err
 	}
 
-	var r int64
-	r, err = result.RowsAffected()
+	_, err = tx.Exec(`DELETE FROM users_workspaces WHERE workspace_id = ? AND user_id = ?`, workspaceId, userId)
 	if err != nil {
 		return 0, err
 	}
 
-	return int(r), nil
+	return len(taskIds), nil
+}
+
+func memberRole(tx *sql.Tx, workspaceId, userId int) (string, error) {
+	var role string
+
+	err := tx.QueryRow("SELECT `role` FROM users_workspaces WHERE workspace_id = ? AND user_id = ? FOR UPDATE", workspaceId, userId).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNoRecord
+		}
+		return "", err
+	}
+
+	return role, nil
 }
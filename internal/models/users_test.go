@@ -1,7 +1,9 @@
 package models
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/andres085/task_manager/internal/assert"
 )
@@ -10,9 +12,10 @@ func TestUserInsertMethod(t *testing.T) {
 	db := newTestDB(t)
 
 	m := UserModel{db}
-	err := m.Insert("Test", "McTester", "test@mail.com", "pa$$word")
+	id, err := m.Insert("Test", "McTester", "test@mail.com", "pa$$word")
 
 	assert.NilError(t, err)
+	assert.Equal(t, id, 3)
 }
 
 func TestUserAuthenticateMethod(t *testing.T) {
@@ -22,11 +25,30 @@ func TestUserAuthenticateMethod(t *testing.T) {
 	password := "pa$$word"
 
 	m := UserModel{db}
-	err := m.Insert("Test", "McTester", email, password)
+	tokens := UserTokenModel{db}
+
+	id, err := m.Insert("Test", "McTester", email, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.Authenticate(email, password)
+	assert.Equal(t, err, ErrUnverifiedEmail)
+
+	_, err = m.Authenticate(email, "wrong-pa$$word")
+	assert.Equal(t, err, ErrInvalidCredentials)
+
+	token, err := tokens.Insert(id, ScopeVerification, VerificationTTL)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	err = m.Verify(token)
+	assert.NilError(t, err)
+
+	err = m.Verify(token)
+	assert.Equal(t, err, ErrInvalidToken)
+
 	row, err := m.Authenticate(email, password)
 	if err != nil {
 		t.Fatal(err)
@@ -34,3 +56,346 @@ func TestUserAuthenticateMethod(t *testing.T) {
 
 	assert.Equal(t, row, 3)
 }
+
+func TestUserGetUserToInviteMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := UserModel{db}
+
+	user, err := m.GetUserToInvite("member@example.com", 2)
+
+	assert.NilError(t, err)
+	assert.Equal(t, user.ID, 2)
+
+	_, err = m.GetUserToInvite("member@example.com", 1)
+	assert.Equal(t, err, ErrNoRecord)
+
+	_, err = m.Insert("New", "User", "new@example.com", "pa$$word")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.GetUserToInvite("new@example.com", 1)
+	assert.Equal(t, err, ErrNoRecord)
+}
+
+func TestUserGetByEmailMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := UserModel{db}
+
+	user, err := m.GetByEmail("member@example.com")
+
+	assert.NilError(t, err)
+	assert.Equal(t, user.ID, 2)
+	assert.Equal(t, user.FirstName, "Member")
+
+	_, err = m.GetByEmail("nobody@example.com")
+
+	assert.Equal(t, err, ErrNoRecord)
+}
+
+func TestUserResetPasswordMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := UserModel{db}
+	tokens := UserTokenModel{db}
+
+	_, err := m.Insert("Test", "McTester", "test@mail.com", "pa$$word")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec(`UPDATE users SET verified = true WHERE id = 3`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stale, err := tokens.Insert(3, ScopePasswordReset, PasswordResetTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := tokens.Insert(3, ScopePasswordReset, PasswordResetTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.ResetPassword(stale, "new-pa$$word")
+	assert.Equal(t, err, ErrInvalidToken)
+
+	userId, err := m.ResetPassword(token, "new-pa$$word")
+	assert.NilError(t, err)
+	assert.Equal(t, userId, 3)
+
+	_, err = m.Authenticate("test@mail.com", "pa$$word")
+	assert.Equal(t, err, ErrInvalidCredentials)
+
+	id, err := m.Authenticate("test@mail.com", "new-pa$$word")
+	assert.NilError(t, err)
+	assert.Equal(t, id, 3)
+
+	_, err = m.ResetPassword(token, "another-pa$$word")
+	assert.Equal(t, err, ErrInvalidToken)
+
+	expired, err := tokens.Insert(3, ScopePasswordReset, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.ResetPassword(expired, "another-pa$$word")
+	assert.Equal(t, err, ErrInvalidToken)
+}
+
+func TestUserChangePasswordMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := UserModel{db}
+	tokens := UserTokenModel{db}
+
+	_, err := m.Insert("Test", "McTester", "test@mail.com", "pa$$word")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec(`UPDATE users SET verified = true WHERE id = 3`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := tokens.Insert(3, ScopePasswordReset, PasswordResetTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.ChangePassword(3, "wrong-pa$$word", "new-pa$$word")
+	assert.Equal(t, err, ErrInvalidCredentials)
+
+	err = m.ChangePassword(3, "pa$$word", "new-pa$$word")
+	assert.NilError(t, err)
+
+	_, err = m.ResetPassword(token, "another-pa$$word")
+	assert.Equal(t, err, ErrInvalidToken)
+
+	_, err = m.Authenticate("test@mail.com", "pa$$word")
+	assert.Equal(t, err, ErrInvalidCredentials)
+
+	id, err := m.Authenticate("test@mail.com", "new-pa$$word")
+	assert.NilError(t, err)
+	assert.Equal(t, id, 3)
+
+	err = m.UpdateProfile(3, "Tess", "McTesty")
+	assert.NilError(t, err)
+
+	user, err := m.GetUser(3)
+	assert.NilError(t, err)
+	assert.Equal(t, user.FirstName, "Tess")
+	assert.Equal(t, user.LastName, "McTesty")
+	assert.Equal(t, user.Email, "test@mail.com")
+}
+
+func TestUserEmailChangeMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := UserModel{db}
+	tokens := UserTokenModel{db}
+
+	_, err := m.Insert("Test", "McTester", "test@mail.com", "pa$$word")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec(`UPDATE users SET verified = true WHERE id = 3`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.RequestEmailChange(3, "wrong-pa$$word", "new@mail.com")
+	assert.Equal(t, err, ErrInvalidCredentials)
+
+	err = m.RequestEmailChange(3, "pa$$word", "test@example.com")
+	assert.Equal(t, err, ErrDuplicateEmail)
+
+	err = m.RequestEmailChange(3, "pa$$word", "new@mail.com")
+	assert.NilError(t, err)
+
+	user, err := m.GetUser(3)
+	assert.NilError(t, err)
+	assert.Equal(t, user.Email, "test@mail.com")
+	assert.Equal(t, *user.PendingEmail, "new@mail.com")
+
+	token, err := tokens.Insert(3, ScopeEmailChange, EmailChangeTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.ConfirmEmailChange(token)
+	assert.NilError(t, err)
+
+	err = m.ConfirmEmailChange(token)
+	assert.Equal(t, err, ErrInvalidToken)
+
+	user, err = m.GetUser(3)
+	assert.NilError(t, err)
+	assert.Equal(t, user.Email, "new@mail.com")
+	assert.Equal(t, user.PendingEmail, (*string)(nil))
+
+	id, err := m.Authenticate("new@mail.com", "pa$$word")
+	assert.NilError(t, err)
+	assert.Equal(t, id, 3)
+}
+
+func TestUserUpdateWorkspaceRoleMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := UserModel{db}
+	workspaces := WorkspaceModel{db}
+
+	err := m.UpdateWorkspaceRole(1, 2, RoleAdmin)
+	assert.NilError(t, err)
+
+	membership, err := workspaces.GetMembership(2, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, membership.Role, RoleAdmin)
+
+	err = m.UpdateWorkspaceRole(1, 2, RoleAdmin)
+	assert.NilError(t, err)
+
+	err = m.UpdateWorkspaceRole(1, 2, RoleOwner)
+	assert.Equal(t, err, ErrOwnerRole)
+
+	err = m.UpdateWorkspaceRole(1, 1, RoleViewer)
+	assert.Equal(t, err, ErrOwnerRole)
+
+	err = m.UpdateWorkspaceRole(2, 2, RoleViewer)
+	assert.Equal(t, err, ErrNoRecord)
+}
+
+func TestUserTransferOwnershipMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := UserModel{db}
+	workspaces := WorkspaceModel{db}
+
+	err := m.TransferOwnership(1, 2, 1)
+	assert.Equal(t, err, ErrNoRecord)
+
+	err = m.TransferOwnership(1, 1, 3)
+	assert.Equal(t, err, ErrNoRecord)
+
+	err = m.TransferOwnership(1, 1, 2)
+	assert.NilError(t, err)
+
+	membership, err := workspaces.GetMembership(2, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, membership.Role, RoleOwner)
+
+	membership, err = workspaces.GetMembership(1, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, membership.Role, RoleAdmin)
+
+	removed, err := m.RemoveUserFromWorkspace(1, 2, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, removed, 0)
+
+	for i := 0; i < DefaultLimits.OwnedWorkspaces; i++ {
+		_, err = workspaces.Insert(fmt.Sprintf("Owned Workspace %d", i), "Owned Workspace Description", 1)
+		assert.NilError(t, err)
+	}
+
+	err = m.TransferOwnership(1, 2, 1)
+	assert.Equal(t, err, ErrWorkspaceLimit)
+}
+
+func TestUserLeaveWorkspaceMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := UserModel{db}
+	tasks := TaskModel{db}
+	workspaces := WorkspaceModel{db}
+
+	taskId, err := tasks.Insert("Member Task", "Member Task Content", "LOW", "", 1, []int{2}, nil, 0, "", "")
+	assert.NilError(t, err)
+
+	sharedId, err := tasks.Insert("Shared Task", "Shared Task Content", "LOW", "", 1, []int{2, 1}, nil, 0, "", "")
+	assert.NilError(t, err)
+
+	err = tasks.Watch(1, 2)
+	assert.NilError(t, err)
+
+	_, err = m.LeaveWorkspace(1, 1)
+	assert.Equal(t, err, ErrOwnerRole)
+
+	reassigned, err := m.LeaveWorkspace(1, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, reassigned, 2)
+
+	task, err := tasks.Get(taskId)
+	assert.NilError(t, err)
+	assert.Equal(t, task.UserId, 1)
+	assert.Equal(t, len(task.Assignees), 1)
+
+	history, err := tasks.GetHistory(taskId)
+	assert.NilError(t, err)
+	assert.Equal(t, history[0].Field, "assignees")
+	assert.Equal(t, history[0].NewValue, "Test McTester")
+	assert.Equal(t, history[0].UserId, 2)
+
+	shared, err := tasks.Get(sharedId)
+	assert.NilError(t, err)
+	assert.Equal(t, shared.UserId, 1)
+	assert.Equal(t, len(shared.Assignees), 1)
+
+	watched, err := tasks.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(watched.Watchers), 0)
+
+	_, err = workspaces.GetMembership(2, 1)
+	assert.Equal(t, err, ErrNoRecord)
+
+	_, err = m.LeaveWorkspace(1, 2)
+	assert.Equal(t, err, ErrNoRecord)
+}
+
+func TestUserRemoveUserFromWorkspaceMethod(t *testing.T) {
+	db := newTestDB(t)
+
+	m := UserModel{db}
+	tasks := TaskModel{db}
+	workspaces := WorkspaceModel{db}
+
+	taskId, err := tasks.Insert("Member Task", "Member Task Content", "LOW", "", 1, []int{2}, nil, 0, "", "")
+	assert.NilError(t, err)
+
+	err = tasks.Watch(1, 2)
+	assert.NilError(t, err)
+
+	removed, err := m.RemoveUserFromWorkspace(1, 1, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, removed, 0)
+
+	removed, err = m.RemoveUserFromWorkspace(1, 2, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, removed, 1)
+
+	task, err := tasks.Get(taskId)
+	assert.NilError(t, err)
+	assert.Equal(t, task.UserId, 1)
+	assert.Equal(t, len(task.Assignees), 1)
+
+	history, err := tasks.GetHistory(taskId)
+	assert.NilError(t, err)
+	assert.Equal(t, history[0].Field, "assignees")
+	assert.Equal(t, history[0].UserId, 1)
+
+	watched, err := tasks.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(watched.Watchers), 0)
+
+	_, err = workspaces.GetMembership(2, 1)
+	assert.Equal(t, err, ErrNoRecord)
+
+	removed, err = m.RemoveUserFromWorkspace(1, 2, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, removed, 0)
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	ScopePasswordReset = "PASSWORD_RESET"
+	ScopeVerification  = "VERIFICATION"
+	ScopeEmailChange   = "EMAIL_CHANGE"
+
+	PasswordResetTTL = time.Hour
+	VerificationTTL  = 3 * 24 * time.Hour
+	EmailChangeTTL   = 24 * time.Hour
+)
+
+type UserTokenModelInterface interface {
+	Insert(userId int, scope string, ttl time.Duration) (string, error)
+}
+
+type UserTokenModel struct {
+	DB *sql.DB
+}
+
+// Insert returns the plaintext token; only its hash is stored. Issuing a token replaces any
+// earlier token the user had for the same scope.
+func (m *UserTokenModel) Insert(userId int, scope string, ttl time.Duration) (string, error) {
+	plaintext, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return "", err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM user_tokens WHERE expires <= UTC_TIMESTAMP() OR (user_id = ? AND scope = ?)`, userId, scope)
+	if err != nil {
+		return "", err
+	}
+
+	stmt := `INSERT INTO user_tokens (token_hash, user_id, scope, created, expires)
+	VALUES (?, ?, ?, UTC_TIMESTAMP(), DATE_ADD(UTC_TIMESTAMP(), INTERVAL ? SECOND))`
+
+	_, err = tx.Exec(stmt, hashToken(plaintext), userId, scope, int(ttl.Seconds()))
+	if err != nil {
+		return "", err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return "", err
+	}
+
+	return plaintext, nil
+}
+
+// consumeToken deletes a live token and returns the user it was issued to, so every token
+// works only once.
+func consumeToken(tx *sql.Tx, plaintext, scope string) (int, error) {
+	var userId int
+
+	stmt := `SELECT user_id FROM user_tokens WHERE token_hash = ? AND scope = ? AND expires > UTC_TIMESTAMP() FOR UPDATE`
+
+	err := tx.QueryRow(stmt, hashToken(plaintext), scope).Scan(&userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrInvalidToken
+		}
+		return 0, err
+	}
+
+	_, err = tx.Exec(`DELETE FROM user_tokens WHERE user_id = ? AND scope = ?`, userId, scope)
+	if err != nil {
+		return 0, err
+	}
+
+	return userId, nil
+}
@@ -7,20 +7,34 @@ import (
 )
 
 type Workspace struct {
-	ID          int
-	Title       string
-	Description string
-	Created     time.Time
+	ID                int        `json:"id"`
+	Title             string     `json:"title"`
+	Description       string     `json:"description"`
+	Created           time.Time  `json:"created"`
+	BlockOpenSubtasks bool       `json:"block_open_subtasks"`
+	DeletedAt         *time.Time `json:"deleted_at,omitempty"`
+	Archived          bool       `json:"archived"`
+	Role              string     `json:"role,omitempty"`
+}
+
+// Membership is what authorization needs to know about a user in a workspace.
+type Membership struct {
+	Role     string
+	Archived bool
 }
 
 type WorkspaceModelInterface interface {
 	Insert(title, description string, userId int) (int, error)
 	Get(id int) (Workspace, error)
-	GetAll(userId int, role string) ([]Workspace, error)
-	Update(id int, title, description string) error
+	GetAll(userId int, owned, includeArchived bool) ([]Workspace, error)
+	Update(id int, title, description string, blockOpenSubtasks bool) error
+	SetArchived(id int, archived bool) error
 	Delete(id int) (int, error)
-	ValidateOwnership(userId, workspaceId int) (bool, error)
-	ValidateAdmin(userId, workspaceId int) (bool, error)
+	GetTrash(userId int) ([]Workspace, error)
+	Restore(id, userId int) error
+	Purge(id, userId int) error
+	PurgeDeleted(before time.Time) (int, error)
+	GetMembership(userId, workspaceId int) (Membership, error)
 }
 
 type WorkspaceModel struct {
@@ -28,9 +42,21 @@ type WorkspaceModel struct {
 }
 
 func (m *WorkspaceModel) Insert(title, description string, userId int) (int, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	err = checkOwnedQuota(tx, userId)
+	if err != nil {
+		return 0, err
+	}
+
 	stmt := `INSERT INTO workspaces (title, description, created)  VALUES (?, ?, UTC_TIMESTAMP())`
 
-	result, err := m.DB.Exec(stmt, title, description)
+	result, err := tx.Exec(stmt, title, description)
 	if err != nil {
 		return 0, err
 	}
@@ -41,7 +67,17 @@ func (m *WorkspaceModel) Insert(title, description string, userId int) (int, err
 	}
 
 	stmt = `INSERT INTO users_workspaces(user_id, workspace_id, role, created) VALUES (?, ?, ?, UTC_TIMESTAMP)`
-	result, err = m.DB.Exec(stmt, userId, workspaceId, "ADMIN")
+	_, err = tx.Exec(stmt, userId, workspaceId, RoleOwner)
+	if err != nil {
+		return 0, err
+	}
+
+	err = insertDefaultStatuses(tx, workspaceId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
 	if err != nil {
 		return 0, err
 	}
@@ -50,11 +86,11 @@ func (m *WorkspaceModel) Insert(title, description string, userId int) (int, err
 }
 
 func (m *WorkspaceModel) Get(id int) (Workspace, error) {
-	stmt := `SELECT * FROM workspaces WHERE id = ?`
+	stmt := `SELECT * FROM workspaces WHERE id = ? AND deleted_at IS NULL`
 
 	var w Workspace
 
-	err := m.DB.QueryRow(stmt, id).Scan(&w.ID, &w.Title, &w.Description, &w.Created)
+	err := m.DB.QueryRow(stmt, id).Scan(&w.ID, &w.Title, &w.Description, &w.Created, &w.BlockOpenSubtasks, &w.DeletedAt, &w.Archived)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Workspace{}, ErrNoRecord
@@ -66,10 +102,20 @@ func (m *WorkspaceModel) Get(id int) (Workspace, error) {
 	return w, nil
 }
 
-func (m *WorkspaceModel) GetAll(userId int, role string) ([]Workspace, error) {
-	stmt := `SELECT w.* FROM workspaces as w JOIN users_workspaces as uw ON w.id = uw.workspace_id WHERE uw.user_id = ? and uw.role = ?`
+// GetAll returns the workspaces the user owns, or the ones they were invited to when owned is false.
+// Archived workspaces are left out unless includeArchived is set.
+func (m *WorkspaceModel) GetAll(userId int, owned, includeArchived bool) ([]Workspace, error) {
+	stmt := `SELECT w.*, uw.role FROM workspaces as w JOIN users_workspaces as uw ON w.id = uw.workspace_id WHERE uw.user_id = ? and w.deleted_at IS NULL`
+	if !includeArchived {
+		stmt += ` and w.archived = false`
+	}
+	if owned {
+		stmt += ` and uw.role = ?`
+	} else {
+		stmt += ` and uw.role <> ?`
+	}
 
-	rows, err := m.DB.Query(stmt, userId, role)
+	rows, err := m.DB.Query(stmt, userId, RoleOwner)
 	if err != nil {
 		return nil, err
 	}
@@ -81,7 +127,7 @@ func (m *WorkspaceModel) GetAll(userId int, role string) ([]Workspace, error) {
 	for rows.Next() {
 		var w Workspace
 
-		err = rows.Scan(&w.ID, &w.Title, &w.Description, &w.Created)
+		err = rows.Scan(&w.ID, &w.Title, &w.Description, &w.Created, &w.BlockOpenSubtasks, &w.DeletedAt, &w.Archived, &w.Role)
 		if err != nil {
 			return nil, err
 		}
@@ -96,10 +142,10 @@ func (m *WorkspaceModel) GetAll(userId int, role string) ([]Workspace, error) {
 	return workspaces, nil
 }
 
-func (m *WorkspaceModel) Update(id int, title, description string) error {
-	stmt := `UPDATE workspaces SET title = ?, description = ? where id = ?`
+func (m *WorkspaceModel) Update(id int, title, description string, blockOpenSubtasks bool) error {
+	stmt := `UPDATE workspaces SET title = ?, description = ?, block_open_subtasks = ? where id = ?`
 
-	_, err := m.DB.Exec(stmt, title, description, id)
+	_, err := m.DB.Exec(stmt, title, description, blockOpenSubtasks, id)
 	if err != nil {
 		return err
 	}
@@ -107,8 +153,37 @@ func (m *WorkspaceModel) Update(id int, title, description string) error {
 	return nil
 }
 
+// SetArchived archives or unarchives a workspace. Archived workspaces are read-only.
+func (m *WorkspaceModel) SetArchived(id int, archived bool) error {
+	result, err := m.DB.Exec(`UPDATE workspaces SET archived = ? WHERE id = ? AND deleted_at IS NULL`, archived, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		var exists bool
+
+		err = m.DB.QueryRow(`SELECT EXISTS (SELECT true FROM workspaces WHERE id = ? AND deleted_at IS NULL)`, id).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return ErrNoRecord
+		}
+	}
+
+	return nil
+}
+
+// Delete moves the workspace to the trash of its owner.
 func (m *WorkspaceModel) Delete(id int) (int, error) {
-	stmt := `DELETE FROM workspaces where id = ?`
+	stmt := `UPDATE workspaces SET deleted_at = UTC_TIMESTAMP() where id = ? AND deleted_at IS NULL`
 
 	result, err := m.DB.Exec(stmt, id)
 	if err != nil {
@@ -124,20 +199,126 @@ func (m *WorkspaceModel) Delete(id int) (int, error) {
 	return int(r), nil
 }
 
-func (m *WorkspaceModel) ValidateOwnership(userId, workspaceId int) (bool, error) {
+// GetTrash returns the deleted workspaces the user owns.
+func (m *WorkspaceModel) GetTrash(userId int) ([]Workspace, error) {
+	stmt := `SELECT w.*, uw.role FROM workspaces as w JOIN users_workspaces as uw ON w.id = uw.workspace_id
+	WHERE uw.user_id = ? AND uw.role = ? AND w.deleted_at IS NOT NULL ORDER BY w.deleted_at DESC, w.id`
+
+	rows, err := m.DB.Query(stmt, userId, RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var workspaces []Workspace
+
+	for rows.Next() {
+		var w Workspace
+
+		err = rows.Scan(&w.ID, &w.Title, &w.Description, &w.Created, &w.BlockOpenSubtasks, &w.DeletedAt, &w.Archived, &w.Role)
+		if err != nil {
+			return nil, err
+		}
+
+		workspaces = append(workspaces, w)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return workspaces, nil
+}
+
+// Restore takes a workspace out of the owner's trash. It counts against the owner's quota again.
+func (m *WorkspaceModel) Restore(id, userId int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
 	var exists bool
 
-	stmt := "SELECT EXISTS(SELECT true FROM users_workspaces WHERE user_id = ? AND workspace_id = ?)"
+	stmt := `SELECT EXISTS (SELECT true FROM workspaces w JOIN users_workspaces uw ON w.id = uw.workspace_id
+	WHERE w.id = ? AND uw.user_id = ? AND uw.role = ? AND w.deleted_at IS NOT NULL)`
+
+	err = tx.QueryRow(stmt, id, userId, RoleOwner).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrNoRecord
+	}
+
+	err = checkOwnedQuota(tx, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE workspaces SET deleted_at = NULL WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Purge permanently deletes a workspace from the owner's trash along with its tasks.
+func (m *WorkspaceModel) Purge(id, userId int) error {
+	stmt := `DELETE FROM workspaces WHERE id = ? AND deleted_at IS NOT NULL
+	AND id IN (SELECT workspace_id FROM users_workspaces WHERE user_id = ? AND role = ?)`
+
+	result, err := m.DB.Exec(stmt, id, userId, RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// PurgeDeleted permanently deletes the workspaces that were moved to the trash before the given time.
+func (m *WorkspaceModel) PurgeDeleted(before time.Time) (int, error) {
+	result, err := m.DB.Exec(`DELETE FROM workspaces WHERE deleted_at < ?`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
 
-	err := m.DB.QueryRow(stmt, userId, workspaceId).Scan(&exists)
-	return exists, err
+	return int(rowsAffected), nil
 }
 
-func (m *WorkspaceModel) ValidateAdmin(userId, workspaceId int) (bool, error) {
-	var isAdmin bool
+// GetMembership returns the user's role in the workspace, or ErrNoRecord when they aren't a member or the
+// workspace is in the trash.
+func (m *WorkspaceModel) GetMembership(userId, workspaceId int) (Membership, error) {
+	var membership Membership
 
-	stmt := "SELECT EXISTS(SELECT true FROM users_workspaces WHERE user_id = ? AND workspace_id = ? AND role = 'ADMIN')"
+	stmt := `SELECT uw.role, w.archived FROM users_workspaces uw JOIN workspaces w ON w.id = uw.workspace_id
+	WHERE uw.user_id = ? AND uw.workspace_id = ? AND w.deleted_at IS NULL`
+
+	err := m.DB.QueryRow(stmt, userId, workspaceId).Scan(&membership.Role, &membership.Archived)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Membership{}, ErrNoRecord
+		}
+		return Membership{}, err
+	}
 
-	err := m.DB.QueryRow(stmt, userId, workspaceId).Scan(&isAdmin)
-	return isAdmin, err
+	return membership, nil
 }
@@ -2,6 +2,7 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/andres085/task_manager/internal/assert"
 )
@@ -28,6 +29,11 @@ func TestWorkspacesInsertMethod(t *testing.T) {
 
 	assert.Equal(t, id, 2)
 	assert.NilError(t, err)
+
+	statuses, err := (&StatusModel{db}).GetAll(id)
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(statuses), 3)
 }
 
 func TestWorkspacesGetAllMethod(t *testing.T) {
@@ -35,9 +41,15 @@ func TestWorkspacesGetAllMethod(t *testing.T) {
 
 	m := WorkspaceModel{db}
 
-	workspaces, err := m.GetAll(1, "ADMIN")
+	workspaces, err := m.GetAll(1, true, false)
+
+	assert.Equal(t, len(workspaces), 1)
+	assert.NilError(t, err)
+
+	workspaces, err = m.GetAll(2, false, false)
 
 	assert.Equal(t, len(workspaces), 1)
+	assert.Equal(t, workspaces[0].Role, RoleEditor)
 	assert.NilError(t, err)
 }
 
@@ -47,7 +59,7 @@ func TestWorkspacesUpdateMethod(t *testing.T) {
 	m := WorkspaceModel{db}
 
 	newTitle := "Updated Title"
-	err := m.Update(1, newTitle, "Test Task Description")
+	err := m.Update(1, newTitle, "Test Task Description", true)
 
 	assert.NilError(t, err)
 
@@ -67,24 +79,120 @@ func TestWorkspacesDeleteMethod(t *testing.T) {
 	assert.NilError(t, err)
 }
 
-func TestValidateOwnership(t *testing.T) {
+func TestWorkspacesArchive(t *testing.T) {
+	db := newTestDB(t)
+
+	m := WorkspaceModel{db}
+
+	err := m.SetArchived(1, true)
+	assert.NilError(t, err)
+
+	err = m.SetArchived(1, true)
+	assert.NilError(t, err)
+
+	err = m.SetArchived(99, true)
+	assert.Equal(t, err, ErrNoRecord)
+
+	workspaces, err := m.GetAll(1, true, false)
+	assert.NilError(t, err)
+	assert.Equal(t, len(workspaces), 0)
+
+	workspaces, err = m.GetAll(1, true, true)
+	assert.NilError(t, err)
+	assert.Equal(t, len(workspaces), 1)
+	assert.Equal(t, workspaces[0].Archived, true)
+
+	membership, err := m.GetMembership(2, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, membership, Membership{Role: RoleEditor, Archived: true})
+
+	membership, err = (&TaskModel{db}).GetMembership(1, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, membership, Membership{Role: RoleOwner, Archived: true})
+
+	err = m.SetArchived(1, false)
+	assert.NilError(t, err)
+
+	workspace, err := m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, workspace.Archived, false)
+}
+
+func TestWorkspacesTrash(t *testing.T) {
 	db := newTestDB(t)
 
 	m := WorkspaceModel{db}
 
-	isOwner, err := m.ValidateOwnership(1, 1)
+	_, err := m.Delete(1)
+	assert.NilError(t, err)
+
+	_, err = m.Get(1)
+	assert.Equal(t, err, ErrNoRecord)
+
+	_, err = m.GetMembership(2, 1)
+	assert.Equal(t, err, ErrNoRecord)
+
+	workspaces, err := m.GetAll(1, true, false)
+	assert.NilError(t, err)
+	assert.Equal(t, len(workspaces), 0)
+
+	quota, err := (&LimitModel{db}).GetQuota(1)
+	assert.NilError(t, err)
+	assert.Equal(t, quota.Owned, 0)
+
+	trash, err := m.GetTrash(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(trash), 1)
+
+	trash, err = m.GetTrash(2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(trash), 0)
+
+	err = m.Restore(1, 2)
+	assert.Equal(t, err, ErrNoRecord)
+
+	err = m.Restore(1, 1)
+	assert.NilError(t, err)
 
-	assert.Equal(t, isOwner, true)
+	_, err = m.Get(1)
 	assert.NilError(t, err)
+
+	err = m.Purge(1, 1)
+	assert.Equal(t, err, ErrNoRecord)
+
+	_, err = m.Delete(1)
+	assert.NilError(t, err)
+
+	err = m.Purge(1, 2)
+	assert.Equal(t, err, ErrNoRecord)
+
+	err = m.Purge(1, 1)
+	assert.NilError(t, err)
+
+	_, err = (&TaskModel{db}).Get(1)
+	assert.Equal(t, err, ErrNoRecord)
+
+	purged, err := m.PurgeDeleted(time.Now().Add(time.Hour))
+	assert.NilError(t, err)
+	assert.Equal(t, purged, 0)
 }
 
-func TestValidateAdmin(t *testing.T) {
+func TestWorkspaceGetMembershipMethod(t *testing.T) {
 	db := newTestDB(t)
 
 	m := WorkspaceModel{db}
 
-	isAdmin, err := m.ValidateAdmin(1, 1)
+	membership, err := m.GetMembership(1, 1)
 
-	assert.Equal(t, isAdmin, true)
+	assert.Equal(t, membership.Role, RoleOwner)
 	assert.NilError(t, err)
+
+	membership, err = m.GetMembership(2, 1)
+
+	assert.Equal(t, membership.Role, RoleEditor)
+	assert.NilError(t, err)
+
+	_, err = m.GetMembership(1, 99)
+
+	assert.Equal(t, err, ErrNoRecord)
 }
@@ -0,0 +1,73 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andres085/task_manager/internal/assert"
+)
+
+// The RFC 6238 SHA1 test secret "12345678901234567890" in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		name string
+		unix int64
+		want string
+	}{
+		{name: "59", unix: 59, want: "287082"},
+		{name: "1111111109", unix: 1111111109, want: "081804"},
+		{name: "1111111111", unix: 1111111111, want: "050471"},
+		{name: "1234567890", unix: 1234567890, want: "005924"},
+		{name: "2000000000", unix: 2000000000, want: "279037"},
+		{name: "20000000000", unix: 20000000000, want: "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+
+			assert.NilError(t, err)
+			assert.Equal(t, code, tt.want)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "Current step", code: "050471", wantStep: Step(now), wantOK: true},
+		{name: "Previous step", code: "081804", wantStep: Step(now) - 1, wantOK: true},
+		{name: "Spaces", code: "050 471", wantStep: Step(now), wantOK: true},
+		{name: "Wrong code", code: "123456"},
+		{name: "Too short", code: "05047"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, tt.code, now)
+
+			assert.Equal(t, ok, tt.wantOK)
+			assert.Equal(t, step, tt.wantStep)
+		})
+	}
+}
+
+func TestURL(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NilError(t, err)
+	assert.Equal(t, len(secret), 32)
+
+	u := URL("Task Manager", "alice@example.com", secret)
+
+	assert.StringContains(t, u, "otpauth://totp/Task%20Manager:alice@example.com?")
+	assert.StringContains(t, u, "secret="+secret)
+	assert.StringContains(t, u, "issuer=Task+Manager")
+}
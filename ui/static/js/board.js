@@ -0,0 +1,89 @@
+document.addEventListener('DOMContentLoaded', function () {
+  const board = document.querySelector('.board');
+  if (!board) {
+    return;
+  }
+
+  const csrfToken = board.dataset.csrfToken;
+  const errorAlert = document.getElementById('board-error');
+  let draggedCard = null;
+
+  function updateCounts() {
+    board.querySelectorAll('.board-column').forEach(function (column) {
+      const count = column.querySelectorAll('.board-card').length;
+      column.querySelector('.board-count').textContent = count;
+    });
+  }
+
+  function showError(message) {
+    errorAlert.textContent = message;
+    errorAlert.classList.remove('d-none');
+  }
+
+  board.querySelectorAll('.board-card').forEach(function (card) {
+    card.addEventListener('dragstart', function (event) {
+      draggedCard = card;
+      event.dataTransfer.effectAllowed = 'move';
+      card.classList.add('opacity-50');
+    });
+
+    card.addEventListener('dragend', function () {
+      card.classList.remove('opacity-50');
+    });
+  });
+
+  board.querySelectorAll('.board-dropzone').forEach(function (zone) {
+    zone.addEventListener('dragover', function (event) {
+      event.preventDefault();
+      zone.classList.add('board-dropzone-active');
+    });
+
+    zone.addEventListener('dragleave', function () {
+      zone.classList.remove('board-dropzone-active');
+    });
+
+    zone.addEventListener('drop', function (event) {
+      event.preventDefault();
+      zone.classList.remove('board-dropzone-active');
+
+      if (!draggedCard || draggedCard.parentElement === zone) {
+        return;
+      }
+
+      const card = draggedCard;
+      const previousZone = card.parentElement;
+      const body = new URLSearchParams();
+      body.append('status', zone.dataset.status);
+      body.append('csrf_token', csrfToken);
+
+      zone.appendChild(card);
+      updateCounts();
+      errorAlert.classList.add('d-none');
+
+      fetch('/task/' + card.dataset.taskId + '/status/update', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/x-www-form-urlencoded' },
+        body: body,
+      })
+        .then(function (response) {
+          if (response.ok) {
+            return;
+          }
+
+          return response.json().then(
+            function (data) {
+              throw new Error((data.fields && data.fields.status) || data.error);
+            },
+            function () {
+              throw new Error('The task status could not be updated.');
+            }
+          );
+        })
+        .catch(function (error) {
+          previousZone.appendChild(card);
+          updateCounts();
+          showError(error.message);
+        });
+    });
+  });
+});